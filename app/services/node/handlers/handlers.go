@@ -10,13 +10,19 @@ import (
 
 	"github.com/wtran29/go-blockchain/app/services/node/handlers/debug/checkhandlers"
 	v1 "github.com/wtran29/go-blockchain/app/services/node/handlers/v1"
+	"github.com/wtran29/go-blockchain/app/services/node/handlers/v1/grpcapi"
+	grpcmid "github.com/wtran29/go-blockchain/business/grpc/mid"
+	"github.com/wtran29/go-blockchain/business/grpc/nodepb"
 	"github.com/wtran29/go-blockchain/business/web/v1/mid"
+	"github.com/wtran29/go-blockchain/business/webhook"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
 	"github.com/wtran29/go-blockchain/foundation/events"
 	"github.com/wtran29/go-blockchain/foundation/nameservice"
 	"github.com/wtran29/go-blockchain/foundation/web"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 // MuxConfig contains all the mandatory systems required by handlers.
@@ -26,6 +32,8 @@ type MuxConfig struct {
 	State    *state.State
 	NS       *nameservice.NameService
 	Evts     *events.Events
+	Hooks    *webhook.Webhooks
+	Origins  []string
 }
 
 // PublicMux constructs a http.Handler with all application routes defined.
@@ -51,10 +59,11 @@ func PublicMux(cfg MuxConfig) http.Handler {
 
 	// Load the v1 routes.
 	v1.PublicRoutes(app, v1.Config{
-		Log:   cfg.Log,
-		State: cfg.State,
-		NS:    cfg.NS,
-		Evts:  cfg.Evts,
+		Log:     cfg.Log,
+		State:   cfg.State,
+		NS:      cfg.NS,
+		Evts:    cfg.Evts,
+		Origins: cfg.Origins,
 	})
 
 	return app
@@ -86,11 +95,38 @@ func PrivateMux(cfg MuxConfig) http.Handler {
 		Log:   cfg.Log,
 		State: cfg.State,
 		NS:    cfg.NS,
+		Hooks: cfg.Hooks,
 	})
 
 	return app
 }
 
+// GRPCServer constructs a gRPC server with the public API registered. The
+// interceptors mirror the middleware used by the public mux.
+func GRPCServer(cfg MuxConfig) *grpc.Server {
+	logU, logS := grpcmid.Logger(cfg.Log)
+	errU, errS := grpcmid.Errors(cfg.Log)
+	metU, metS := grpcmid.Metrics()
+	panU, panS := grpcmid.Panics()
+
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(logU, errU, metU, panU),
+		grpc.ChainStreamInterceptor(logS, errS, metS, panS),
+	)
+
+	nodepb.RegisterNodeServer(srv, grpcapi.Handlers{
+		Log:   cfg.Log,
+		State: cfg.State,
+		NS:    cfg.NS,
+		Evts:  cfg.Evts,
+	})
+
+	// Allow tools like grpcurl to discover the service.
+	reflection.Register(srv)
+
+	return srv
+}
+
 // DebugStandardLibraryMux registers all the debug routes from the standard library
 // into a new mux bypassing the use of the DefaultServerMux. Using the
 // DefaultServerMux would be a security risk since a dependency could inject a
@@ -0,0 +1,112 @@
+// Package gql maintains the GraphQL endpoint for querying blocks,
+// transactions, accounts and the mempool in a single request.
+package gql
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	v1 "github.com/wtran29/go-blockchain/business/web/v1"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+	"github.com/wtran29/go-blockchain/foundation/nameservice"
+	"github.com/wtran29/go-blockchain/foundation/web"
+	"go.uber.org/zap"
+)
+
+// CORE NOTE: Every list in the schema is a connection that is paged with a
+// cursor. The cursor is an opaque position in the chain, so a client can keep
+// paging while new blocks are mined without seeing the same item twice. The
+// chain is read from the newest block down and the reading stops as soon as a
+// page is full, so a filtered query only reads as far back as it needs to.
+// A filter on an account walks the account index instead of every block, and
+// every query has a budget of block reads shared by all its connections, so
+// nesting connections can't make a single request read the chain many times.
+
+//go:embed schema.graphql
+var schema string
+
+// Set of limits on the work a single query can do, since every level of a
+// query can read more blocks from disk.
+const (
+	maxDepth      = 10
+	maxBlockReads = 1_000
+)
+
+// Handlers manages the GraphQL endpoint.
+type Handlers struct {
+	Log    *zap.SugaredLogger
+	schema *graphql.Schema
+}
+
+// New constructs the handlers with the schema bound to the resolvers.
+func New(log *zap.SugaredLogger, state *state.State, ns *nameservice.NameService) Handlers {
+	res := resolver{
+		state: state,
+		ns:    ns,
+	}
+
+	return Handlers{
+		Log:    log,
+		schema: graphql.MustParseSchema(schema, &res, graphql.MaxDepth(maxDepth)),
+	}
+}
+
+// Query executes a GraphQL query. Errors in the query are returned in the
+// response as the GraphQL specification requires.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var req struct {
+		Query         string          `json:"query"`
+		OperationName string          `json:"operationName"`
+		Variables     map[string]any  `json:"variables"`
+		Extensions    json.RawMessage `json:"extensions"`
+	}
+
+	if err := web.Decode(r, &req); err != nil {
+		return v1.NewRequestError(fmt.Errorf("unable to decode payload: %w", err), http.StatusBadRequest)
+	}
+
+	resp := h.schema.Exec(withBudget(ctx, maxBlockReads), req.Query, req.OperationName, req.Variables)
+	if len(resp.Errors) > 0 {
+		v, err := web.GetValues(ctx)
+		if err != nil {
+			return web.NewShutdownError("web value missing from context")
+		}
+		h.Log.Infow("graphql", "traceid", v.TraceID, "ERROR", resp.Errors)
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
+// =============================================================================
+
+// errTooManyReads is returned when a query reads more blocks than its budget.
+var errTooManyReads = fmt.Errorf("query reads more than %d blocks", maxBlockReads)
+
+// budgetKey is how the read budget for a query is stored in the context.
+type budgetKey struct{}
+
+// withBudget returns a context that allows the number of block reads.
+func withBudget(ctx context.Context, reads int64) context.Context {
+	var left atomic.Int64
+	left.Store(reads)
+	return context.WithValue(ctx, budgetKey{}, &left)
+}
+
+// spend takes a block read from the budget in the context. The resolvers
+// run in parallel, so the budget is shared with atomic operations. A context
+// without a budget allows any number of reads.
+func spend(ctx context.Context) error {
+	left, ok := ctx.Value(budgetKey{}).(*atomic.Int64)
+	if !ok {
+		return nil
+	}
+	if left.Add(-1) < 0 {
+		return errTooManyReads
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package gql
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/genesis"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/storage/memory"
+	"github.com/wtran29/go-blockchain/foundation/nameservice"
+	"go.uber.org/zap"
+)
+
+// beneficiaryID is the account that receives the transfers and the rewards
+// for the blocks mined in the tests.
+const beneficiaryID = database.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+
+// worker implements the state.Worker interface without doing any work.
+type worker struct{}
+
+func (worker) Shutdown()                              {}
+func (worker) Sync()                                  {}
+func (worker) SignalStartMining()                     {}
+func (worker) SignalCancelMining()                    {}
+func (worker) SignalShareTx(blockTx database.BlockTx) {}
+
+func Test_Filters(t *testing.T) {
+	h, a, b := newChain(t)
+
+	tt := []struct {
+		name  string
+		query string
+		exp   []uint64
+	}{
+		{"blocks-account", fmt.Sprintf(`{ list: blocks(filter: {account: "%s"}) %s }`, a, blockConn), []uint64{5, 3, 1}},
+		{"blocks-range", `{ list: blocks(filter: {fromBlock: 2, toBlock: 3}) ` + blockConn + ` }`, []uint64{3, 2}},
+		{"txs-from", fmt.Sprintf(`{ list: transactions(filter: {from: "%s"}) %s }`, b, txConn), []uint64{4, 2}},
+		{"txs-to", fmt.Sprintf(`{ list: transactions(filter: {to: "%s", toBlock: 4}) %s }`, beneficiaryID, txConn), []uint64{4, 3, 2, 1}},
+		{"txs-value", `{ list: transactions(filter: {minValue: 25}) ` + txConn + ` }`, []uint64{5}},
+		{"account-txs", fmt.Sprintf(`{ account(address: "%s") { list: transactions(filter: {fromBlock: 2}) %s } }`, a, txConn), []uint64{5, 3}},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			var data struct {
+				List    connection
+				Account struct{ List connection }
+			}
+			if err := exec(context.Background(), h, tst.query, &data); err != nil {
+				t.Fatalf("Should be able to execute the query: %s", err)
+			}
+
+			list := data.List
+			if data.Account.List.Edges != nil {
+				list = data.Account.List
+			}
+			if got := list.numbers(); fmt.Sprint(got) != fmt.Sprint(tst.exp) {
+				t.Fatalf("Should get the blocks %v, got %v", tst.exp, got)
+			}
+		})
+	}
+}
+
+func Test_Paging(t *testing.T) {
+	h, a, _ := newChain(t)
+
+	tt := []struct {
+		name  string
+		query string
+		exp   [][]uint64
+	}{
+		{"blocks", `{ list: blocks(first: 2, after: %s) ` + blockConn + ` }`, [][]uint64{{5, 4}, {3, 2}, {1}}},
+		{"txs-account", `{ list: transactions(first: 2, after: %s, filter: {account: "` + string(a) + `"}) ` + txConn + ` }`, [][]uint64{{5, 3}, {1}}},
+		{"account-txs", `{ account(address: "` + string(a) + `") { list: transactions(first: 1, after: %s) ` + txConn + ` } }`, [][]uint64{{5}, {3}, {1}}},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			after := "null"
+			for i, exp := range tst.exp {
+				var data struct {
+					List    connection
+					Account struct{ List connection }
+				}
+				if err := exec(context.Background(), h, fmt.Sprintf(tst.query, after), &data); err != nil {
+					t.Fatalf("Should be able to execute the query for page %d: %s", i, err)
+				}
+
+				list := data.List
+				if data.Account.List.Edges != nil {
+					list = data.Account.List
+				}
+				if got := list.numbers(); fmt.Sprint(got) != fmt.Sprint(exp) {
+					t.Fatalf("Should get the blocks %v for page %d, got %v", exp, i, got)
+				}
+
+				last := i == len(tst.exp)-1
+				if list.PageInfo.HasNextPage == last {
+					t.Fatalf("Should report a next page %v for page %d", !last, i)
+				}
+				after = fmt.Sprintf("%q", list.PageInfo.EndCursor)
+			}
+		})
+	}
+}
+
+func Test_Cursors(t *testing.T) {
+	h, _, _ := newChain(t)
+
+	tip := encodeTxCursor(txPosition{block: 5})
+
+	tt := []struct {
+		name  string
+		query string
+		exp   error
+	}{
+		{"tx-above-range", fmt.Sprintf(`{ list: transactions(after: "%s", filter: {toBlock: 3}) %s }`, tip, txConn), errCursorRange},
+		{"block-above-range", fmt.Sprintf(`{ list: blocks(after: "%s") %s }`, encodeCursor(cursorBlock, "9"), blockConn), errCursorRange},
+		{"wrong-kind", fmt.Sprintf(`{ list: blocks(after: "%s") %s }`, tip, blockConn), fmt.Errorf("invalid cursor")},
+		{"garbage", `{ list: transactions(after: "!!") ` + txConn + ` }`, fmt.Errorf("invalid cursor")},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			var data struct{ List connection }
+			err := exec(context.Background(), h, tst.query, &data)
+			if err == nil || err.Error() != tst.exp.Error() {
+				t.Fatalf("Should get the error %q, got %v", tst.exp, err)
+			}
+		})
+	}
+}
+
+func Test_Budget(t *testing.T) {
+	h, a, _ := newChain(t)
+
+	query := fmt.Sprintf(`{ list: transactions(filter: {account: "%s"}) %s }`, a, txConn)
+
+	// The account is in 3 of the 5 blocks and only those are read.
+	var data struct{ List connection }
+	if err := exec(withBudget(context.Background(), 3), h, query, &data); err != nil {
+		t.Fatalf("Should only read the blocks for the account: %s", err)
+	}
+
+	if err := exec(withBudget(context.Background(), 2), h, query, &data); err == nil || err.Error() != errTooManyReads.Error() {
+		t.Fatalf("Should get the error %q, got %v", errTooManyReads, err)
+	}
+}
+
+// =============================================================================
+
+// Set of selections for the connections in the queries. Blocks and
+// transactions are both identified by the number of their block.
+const (
+	blockConn = `{ edges { node { number } } pageInfo { hasNextPage endCursor } }`
+	txConn    = `{ edges { node { block { number } } } pageInfo { hasNextPage endCursor } }`
+)
+
+// connection is the result of a blockConn or txConn selection.
+type connection struct {
+	Edges []struct {
+		Node struct {
+			Number uint64
+			Block  *struct{ Number uint64 }
+		}
+	}
+	PageInfo struct {
+		HasNextPage bool
+		EndCursor   string
+	}
+}
+
+// numbers returns the block numbers of the nodes in the connection.
+func (c connection) numbers() []uint64 {
+	nums := make([]uint64, len(c.Edges))
+	for i, edge := range c.Edges {
+		nums[i] = edge.Node.Number
+		if edge.Node.Block != nil {
+			nums[i] = edge.Node.Block.Number
+		}
+	}
+	return nums
+}
+
+// exec executes the query and decodes the data into the value. The first
+// error in the response is returned.
+func exec(ctx context.Context, h Handlers, query string, v any) error {
+	resp := h.schema.Exec(ctx, query, "", nil)
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("%s", resp.Errors[0].Message)
+	}
+
+	return json.Unmarshal(resp.Data, v)
+}
+
+// newChain constructs the handlers for a PoA chain with one transaction per
+// block. The two returned accounts alternate sending transfers of 10 times
+// the nonce to the beneficiary, so account a is in blocks 1, 3 and 5 and
+// account b is in blocks 2 and 4.
+func newChain(t *testing.T) (Handlers, database.AccountID, database.AccountID) {
+	t.Helper()
+
+	keys := make([]*ecdsa.PrivateKey, 2)
+	balances := make(map[string]uint64, len(keys))
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("Should be able to generate a private key: %s", err)
+		}
+		keys[i] = key
+		balances[string(database.PublicKeyToAccountID(key.PublicKey))] = 1_000_000
+	}
+
+	storage, err := memory.New()
+	if err != nil {
+		t.Fatalf("Should be able to construct the storage: %s", err)
+	}
+
+	st, err := state.New(state.Config{
+		BeneficiaryID: beneficiaryID,
+		Host:          "localhost:9080",
+		Storage:       storage,
+		Genesis: genesis.Genesis{
+			Date:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			ChainID:       1,
+			TransPerBlock: 1,
+			Difficulty:    1,
+			MiningReward:  700,
+			GasPrice:      15,
+			BaseFee:       5,
+			Balances:      balances,
+			Gas:           genesis.GasSchedule{TxBase: 21, Transfer: 9},
+		},
+		SelectStrategy: selector.StrategyFeeDensity,
+		Consensus:      state.ConsensusPOA,
+		MiningWorkers:  1,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the state: %s", err)
+	}
+	st.Worker = worker{}
+
+	nonces := make([]uint64, len(keys))
+	for num := 0; num < 5; num++ {
+		key := keys[num%2]
+		nonces[num%2]++
+		nonce := nonces[num%2]
+
+		tx, err := database.NewTx(1, nonce, database.PublicKeyToAccountID(key.PublicKey), beneficiaryID, 10*nonce, 0, nil, 30, 20)
+		if err != nil {
+			t.Fatalf("Should be able to construct the transaction: %s", err)
+		}
+
+		signedTx, err := tx.Sign(key)
+		if err != nil {
+			t.Fatalf("Should be able to sign the transaction: %s", err)
+		}
+
+		if _, err := st.UpsertWalletTransaction(signedTx); err != nil {
+			t.Fatalf("Should be able to submit the transaction: %s", err)
+		}
+
+		if _, err := st.MineNewBlock(context.Background()); err != nil {
+			t.Fatalf("Should be able to mine block %d: %s", num+1, err)
+		}
+	}
+
+	ns, err := nameservice.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("Should be able to construct the name service: %s", err)
+	}
+
+	a := database.PublicKeyToAccountID(keys[0].PublicKey)
+	b := database.PublicKeyToAccountID(keys[1].PublicKey)
+
+	return New(zap.NewNop().Sugar(), st, ns), a, b
+}
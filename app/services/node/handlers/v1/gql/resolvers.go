@@ -0,0 +1,652 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+	"github.com/wtran29/go-blockchain/foundation/nameservice"
+)
+
+// resolver is the root resolver for the Query type.
+type resolver struct {
+	state *state.State
+	ns    *nameservice.NameService
+}
+
+// Block returns the block with the number or hash, or the latest block.
+func (r *resolver) Block(ctx context.Context, args struct {
+	Number *Long
+	Hash   *string
+}) (*blockResolver, error) {
+	switch {
+	case args.Hash != nil:
+		if err := spend(ctx); err != nil {
+			return nil, err
+		}
+		block, err := r.state.QueryBlockByHash(strings.ToLower(*args.Hash))
+		if err != nil {
+			if errors.Is(err, state.ErrNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return &blockResolver{r: r, block: block}, nil
+
+	case args.Number != nil:
+		block, exists, err := r.blockByNumber(ctx, uint64(*args.Number))
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, nil
+		}
+		return &blockResolver{r: r, block: block}, nil
+	}
+
+	latest := r.state.LatestBlock()
+	if latest.Header.Number == 0 {
+		return nil, nil
+	}
+
+	return &blockResolver{r: r, block: latest}, nil
+}
+
+// Blocks returns a page of blocks from the newest to the oldest.
+func (r *resolver) Blocks(ctx context.Context, args struct {
+	connectionArgs
+	Filter *blockFilter
+}) (*blockConnection, error) {
+	size, err := args.pageSize()
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newBlockFilter(args.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	start := r.state.LatestBlock().Header.Number
+	if filter.toBlock < start {
+		start = filter.toBlock
+	}
+
+	if args.After != nil {
+		position, err := decodeCursor(cursorBlock, *args.After)
+		if err != nil {
+			return nil, err
+		}
+
+		var after uint64
+		if after, err = parseUint(position); err != nil {
+			return nil, err
+		}
+		if after > start+1 {
+			return nil, errCursorRange
+		}
+		if start = 0; after > 0 {
+			start = after - 1
+		}
+	}
+
+	var conn blockConnection
+	err = r.walk(filter, start, func(num uint64) (bool, error) {
+		if len(conn.edges) == size {
+			conn.info.hasNextPage = true
+			return false, nil
+		}
+
+		block, exists, err := r.blockByNumber(ctx, num)
+		if err != nil || !exists {
+			return false, err
+		}
+
+		conn.edges = append(conn.edges, blockEdge{
+			cursor: encodeCursor(cursorBlock, formatUint(num)),
+			node:   &blockResolver{r: r, block: block},
+		})
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conn.edges) > 0 {
+		conn.info.endCursor = &conn.edges[len(conn.edges)-1].cursor
+	}
+
+	return &conn, nil
+}
+
+// Transaction returns the transaction with the hash, mined or still in the
+// mempool.
+func (r *resolver) Transaction(ctx context.Context, args struct{ Hash string }) (*txResolver, error) {
+	if err := spend(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, block, mined, err := r.state.QueryTransactionByHash(strings.ToLower(args.Hash))
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !mined {
+		return &txResolver{r: r, tx: tx}, nil
+	}
+
+	for i, btx := range block.MerkleTree.Values() {
+		if btx.Equals(tx) {
+			return &txResolver{r: r, tx: tx, block: &block, index: i}, nil
+		}
+	}
+
+	return &txResolver{r: r, tx: tx, block: &block}, nil
+}
+
+// Transactions returns a page of mined transactions from the newest block to
+// the oldest.
+func (r *resolver) Transactions(ctx context.Context, args struct {
+	connectionArgs
+	Filter *transactionFilter
+}) (*txConnection, error) {
+	filter, err := newTxFilter(args.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.minedTxs(ctx, args.connectionArgs, filter)
+}
+
+// Account returns the account with the address. An account that isn't in
+// the database returns null.
+func (r *resolver) Account(args struct{ Address string }) (*accountResolver, error) {
+	accountID, err := toAccountID(&args.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.state.QueryAccount(accountID); err != nil {
+		return nil, nil
+	}
+
+	return &accountResolver{r: r, accountID: accountID}, nil
+}
+
+// Accounts returns a page of accounts ordered by address.
+func (r *resolver) Accounts(args connectionArgs) (*accountConnection, error) {
+	size, err := args.pageSize()
+	if err != nil {
+		return nil, err
+	}
+
+	var after string
+	if args.After != nil {
+		if after, err = decodeCursor(cursorAccount, *args.After); err != nil {
+			return nil, err
+		}
+	}
+
+	accounts := r.state.Accounts()
+	ids := make([]string, 0, len(accounts))
+	for accountID := range accounts {
+		ids = append(ids, string(accountID))
+	}
+	sort.Strings(ids)
+
+	var conn accountConnection
+	for _, id := range ids {
+		if id <= after {
+			continue
+		}
+
+		if len(conn.edges) == size {
+			conn.info.hasNextPage = true
+			break
+		}
+
+		conn.edges = append(conn.edges, accountEdge{
+			cursor: encodeCursor(cursorAccount, id),
+			node:   &accountResolver{r: r, accountID: database.AccountID(id)},
+		})
+	}
+
+	if len(conn.edges) > 0 {
+		conn.info.endCursor = &conn.edges[len(conn.edges)-1].cursor
+	}
+
+	return &conn, nil
+}
+
+// Mempool returns a page of the uncommitted transactions in the order they
+// would be mined.
+func (r *resolver) Mempool(args struct {
+	connectionArgs
+	Filter *transactionFilter
+}) (*txConnection, error) {
+	size, err := args.pageSize()
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newTxFilter(args.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var after string
+	if args.After != nil {
+		if after, err = decodeCursor(cursorMempool, *args.After); err != nil {
+			return nil, err
+		}
+	}
+
+	trans := r.state.Mempool()
+
+	// Start after the transaction in the cursor. If it has been mined since,
+	// start from the beginning.
+	start := 0
+	if after != "" {
+		for i, tx := range trans {
+			if signature.Hash(tx) == after {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	var conn txConnection
+	for _, tx := range trans[start:] {
+		if !filter.match(tx) {
+			continue
+		}
+
+		if len(conn.edges) == size {
+			conn.info.hasNextPage = true
+			break
+		}
+
+		conn.edges = append(conn.edges, txEdge{
+			cursor: encodeCursor(cursorMempool, signature.Hash(tx)),
+			node:   &txResolver{r: r, tx: tx},
+		})
+	}
+
+	if len(conn.edges) > 0 {
+		conn.info.endCursor = &conn.edges[len(conn.edges)-1].cursor
+	}
+
+	return &conn, nil
+}
+
+// =============================================================================
+
+// minedTxs returns a page of the transactions in the chain that match the
+// filter, reading the blocks from the newest to the oldest. A cursor past the
+// top of the block range is an error, since paging from the top again would
+// return the items the client already has.
+func (r *resolver) minedTxs(ctx context.Context, args connectionArgs, filter txFilter) (*txConnection, error) {
+	size, err := args.pageSize()
+	if err != nil {
+		return nil, err
+	}
+
+	start := txPosition{block: r.state.LatestBlock().Header.Number}
+	if filter.toBlock < start.block {
+		start.block = filter.toBlock
+	}
+
+	if args.After != nil {
+		after, err := decodeTxCursor(*args.After)
+		if err != nil {
+			return nil, err
+		}
+		if after.block > start.block {
+			return nil, errCursorRange
+		}
+		start = txPosition{block: after.block, index: after.index + 1}
+	}
+
+	var conn txConnection
+	err = r.walk(filter, start.block, func(num uint64) (bool, error) {
+		block, exists, err := r.blockByNumber(ctx, num)
+		if err != nil || !exists {
+			return false, err
+		}
+
+		index := 0
+		if num == start.block {
+			index = start.index
+		}
+
+		return r.appendTxs(&conn, block, index, size, filter), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conn.edges) > 0 {
+		conn.info.endCursor = &conn.edges[len(conn.edges)-1].cursor
+	}
+
+	return &conn, nil
+}
+
+// appendTxs adds the transactions in the block from the index that match the
+// filter to the page. False is returned when the page is full.
+func (r *resolver) appendTxs(conn *txConnection, block database.Block, index int, size int, filter txFilter) bool {
+	trans := block.MerkleTree.Values()
+
+	for i := index; i < len(trans); i++ {
+		if !filter.match(trans[i]) {
+			continue
+		}
+
+		if len(conn.edges) == size {
+			conn.info.hasNextPage = true
+			return false
+		}
+
+		blk := block
+		conn.edges = append(conn.edges, txEdge{
+			cursor: encodeTxCursor(txPosition{block: block.Header.Number, index: i}),
+			node:   &txResolver{r: r, tx: trans[i], block: &blk, index: i},
+		})
+	}
+
+	return true
+}
+
+// walk calls the function with the numbers of the blocks that can hold a
+// match for the filter, from the start down to the bottom of the block range,
+// until it returns false or an error. When the filter names an account, only
+// the blocks in the index for the account are visited.
+func (r *resolver) walk(filter txFilter, start uint64, fn func(num uint64) (bool, error)) error {
+	from := filter.fromBlock
+	if from == 0 {
+		from = 1
+	}
+	if start < from {
+		return nil
+	}
+
+	if accountID := filter.indexed(); accountID != "" {
+		nums := r.state.QueryBlockNumbersByAccount(accountID, from, start)
+		for i := len(nums) - 1; i >= 0; i-- {
+			if more, err := fn(nums[i]); !more || err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for num := start; num >= from; num-- {
+		if more, err := fn(num); !more || err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// blockByNumber reads the block with the number from the chain, taking the
+// read from the budget of the query.
+func (r *resolver) blockByNumber(ctx context.Context, num uint64) (database.Block, bool, error) {
+	if num == 0 || num > r.state.LatestBlock().Header.Number {
+		return database.Block{}, false, nil
+	}
+
+	if err := spend(ctx); err != nil {
+		return database.Block{}, false, err
+	}
+
+	blocks := r.state.QueryBlocksByNumber(num, num)
+	if len(blocks) == 0 {
+		return database.Block{}, false, nil
+	}
+
+	return blocks[0], true, nil
+}
+
+// =============================================================================
+
+// blockResolver resolves the Block type.
+type blockResolver struct {
+	r     *resolver
+	block database.Block
+}
+
+func (b *blockResolver) Number() Long          { return Long(b.block.Header.Number) }
+func (b *blockResolver) Hash() string          { return b.block.Hash() }
+func (b *blockResolver) PrevBlockHash() string { return b.block.Header.PrevBlockHash }
+func (b *blockResolver) Timestamp() Long       { return Long(b.block.Header.TimeStamp) }
+func (b *blockResolver) Difficulty() Long      { return Long(b.block.Header.Difficulty) }
+func (b *blockResolver) MiningReward() Long    { return Long(b.block.Header.MiningReward) }
+func (b *blockResolver) BaseFee() Long         { return Long(b.block.Header.BaseFee) }
+func (b *blockResolver) GasUsed() Long         { return Long(b.block.GasUsed()) }
+func (b *blockResolver) StateRoot() string     { return b.block.Header.StateRoot }
+func (b *blockResolver) TransRoot() string     { return b.block.Header.TransRoot }
+func (b *blockResolver) Nonce() Long           { return Long(b.block.Header.Nonce) }
+
+// Beneficiary returns the account that mined the block.
+func (b *blockResolver) Beneficiary() *accountResolver {
+	return &accountResolver{r: b.r, accountID: b.block.Header.BeneficiaryID}
+}
+
+// TransactionCount returns the number of transactions in the block.
+func (b *blockResolver) TransactionCount() int32 {
+	return int32(len(b.block.MerkleTree.Values()))
+}
+
+// Transactions returns a page of the transactions in the block.
+func (b *blockResolver) Transactions(args struct {
+	connectionArgs
+	Filter *transactionFilter
+}) (*txConnection, error) {
+	size, err := args.pageSize()
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newTxFilter(args.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	index := 0
+	if args.After != nil {
+		after, err := decodeTxCursor(*args.After)
+		if err != nil {
+			return nil, err
+		}
+		if after.block != b.block.Header.Number {
+			return nil, errors.New("cursor is for a different block")
+		}
+		index = after.index + 1
+	}
+
+	var conn txConnection
+	b.r.appendTxs(&conn, b.block, index, size, filter)
+
+	if len(conn.edges) > 0 {
+		conn.info.endCursor = &conn.edges[len(conn.edges)-1].cursor
+	}
+
+	return &conn, nil
+}
+
+// =============================================================================
+
+// Set of values for the TransactionStatus enum.
+const (
+	statusMined   = "MINED"
+	statusPending = "PENDING"
+)
+
+// txResolver resolves the Transaction type. The block is nil while the
+// transaction is in the mempool.
+type txResolver struct {
+	r     *resolver
+	tx    database.BlockTx
+	block *database.Block
+	index int
+}
+
+func (t *txResolver) Hash() string      { return signature.Hash(t.tx) }
+func (t *txResolver) ChainID() int32    { return int32(t.tx.ChainID) }
+func (t *txResolver) Nonce() Long       { return Long(t.tx.Nonce) }
+func (t *txResolver) Value() Long       { return Long(t.tx.Value) }
+func (t *txResolver) Tip() Long         { return Long(t.tx.Tip) }
+func (t *txResolver) Data() string      { return hexutil.Encode(t.tx.Data) }
+func (t *txResolver) GasLimit() Long    { return Long(t.tx.GasLimit) }
+func (t *txResolver) MaxGasPrice() Long { return Long(t.tx.MaxGasPrice) }
+func (t *txResolver) ValidUntil() Long  { return Long(t.tx.ValidUntil) }
+func (t *txResolver) Timestamp() Long   { return Long(t.tx.TimeStamp) }
+func (t *txResolver) GasPrice() Long    { return Long(t.tx.GasPrice) }
+func (t *txResolver) GasUnits() Long    { return Long(t.tx.GasUnits) }
+func (t *txResolver) Fee() Long         { return Long(t.tx.Fee()) }
+
+// Status returns if the transaction is mined or pending.
+func (t *txResolver) Status() string {
+	if t.block == nil {
+		return statusPending
+	}
+	return statusMined
+}
+
+// From returns the account that sent the transaction.
+func (t *txResolver) From() *accountResolver {
+	return &accountResolver{r: t.r, accountID: t.tx.FromID}
+}
+
+// To returns the account that received the transaction.
+func (t *txResolver) To() *accountResolver {
+	return &accountResolver{r: t.r, accountID: t.tx.ToID}
+}
+
+// Block returns the block the transaction is mined in.
+func (t *txResolver) Block() *blockResolver {
+	if t.block == nil {
+		return nil
+	}
+	return &blockResolver{r: t.r, block: *t.block}
+}
+
+// Index returns the position of the transaction in the block.
+func (t *txResolver) Index() *int32 {
+	if t.block == nil {
+		return nil
+	}
+	index := int32(t.index)
+	return &index
+}
+
+// =============================================================================
+
+// accountResolver resolves the Account type. The balance and nonce are
+// only read from the database when they are asked for.
+type accountResolver struct {
+	r         *resolver
+	accountID database.AccountID
+}
+
+// Address returns the account id.
+func (a *accountResolver) Address() string {
+	return string(a.accountID)
+}
+
+// Name returns the name of the account from the name service.
+func (a *accountResolver) Name() string {
+	return a.r.ns.Lookup(a.accountID)
+}
+
+// Balance returns the current balance of the account.
+func (a *accountResolver) Balance() Long {
+	account, _ := a.r.state.QueryAccount(a.accountID)
+	return Long(account.Balance)
+}
+
+// Nonce returns the nonce of the last transaction mined for the account.
+func (a *accountResolver) Nonce() Long {
+	account, _ := a.r.state.QueryAccount(a.accountID)
+	return Long(account.Nonce)
+}
+
+// Transactions returns a page of the mined transactions sent from or to the
+// account.
+func (a *accountResolver) Transactions(ctx context.Context, args struct {
+	connectionArgs
+	Filter *transactionFilter
+}) (*txConnection, error) {
+	filter, err := newTxFilter(args.Filter)
+	if err != nil {
+		return nil, err
+	}
+	filter.account = a.accountID
+
+	return a.r.minedTxs(ctx, args.connectionArgs, filter)
+}
+
+// =============================================================================
+
+// blockEdge resolves the BlockEdge type.
+type blockEdge struct {
+	cursor string
+	node   *blockResolver
+}
+
+func (e blockEdge) Cursor() string       { return e.cursor }
+func (e blockEdge) Node() *blockResolver { return e.node }
+
+// blockConnection resolves the BlockConnection type.
+type blockConnection struct {
+	edges []blockEdge
+	info  pageInfo
+}
+
+func (c *blockConnection) Edges() []blockEdge { return c.edges }
+func (c *blockConnection) PageInfo() pageInfo { return c.info }
+
+// txEdge resolves the TransactionEdge type.
+type txEdge struct {
+	cursor string
+	node   *txResolver
+}
+
+func (e txEdge) Cursor() string    { return e.cursor }
+func (e txEdge) Node() *txResolver { return e.node }
+
+// txConnection resolves the TransactionConnection type.
+type txConnection struct {
+	edges []txEdge
+	info  pageInfo
+}
+
+func (c *txConnection) Edges() []txEdge    { return c.edges }
+func (c *txConnection) PageInfo() pageInfo { return c.info }
+
+// accountEdge resolves the AccountEdge type.
+type accountEdge struct {
+	cursor string
+	node   *accountResolver
+}
+
+func (e accountEdge) Cursor() string         { return e.cursor }
+func (e accountEdge) Node() *accountResolver { return e.node }
+
+// accountConnection resolves the AccountConnection type.
+type accountConnection struct {
+	edges []accountEdge
+	info  pageInfo
+}
+
+func (c *accountConnection) Edges() []accountEdge { return c.edges }
+func (c *accountConnection) PageInfo() pageInfo   { return c.info }
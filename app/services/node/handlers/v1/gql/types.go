@@ -0,0 +1,300 @@
+package gql
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+)
+
+// Set of limits on the size of a page.
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// Long represents the Long scalar for the unsigned 64 bit values in the
+// chain, which don't fit in a GraphQL Int.
+type Long uint64
+
+// ImplementsGraphQLType maps this type to the Long scalar in the schema.
+func (Long) ImplementsGraphQLType(name string) bool {
+	return name == "Long"
+}
+
+// UnmarshalGraphQL accepts a number or a decimal or hex string.
+func (l *Long) UnmarshalGraphQL(input any) error {
+	switch v := input.(type) {
+	case int32:
+		if v < 0 {
+			return fmt.Errorf("negative value %d", v)
+		}
+		*l = Long(v)
+
+	case float64:
+		if v < 0 || v != float64(uint64(v)) {
+			return fmt.Errorf("invalid value %v", v)
+		}
+		*l = Long(v)
+
+	case string:
+		n, err := strconv.ParseUint(v, 0, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value %q", v)
+		}
+		*l = Long(n)
+
+	default:
+		return fmt.Errorf("unexpected type %T for Long", input)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// connectionArgs are the arguments every connection takes.
+type connectionArgs struct {
+	First *int32
+	After *string
+}
+
+// pageSize returns the number of items to return in a page.
+func (a connectionArgs) pageSize() (int, error) {
+	if a.First == nil {
+		return defaultPageSize, nil
+	}
+
+	switch first := int(*a.First); {
+	case first < 1:
+		return 0, errors.New("first must be positive")
+	case first > maxPageSize:
+		return 0, fmt.Errorf("first can't be more than %d", maxPageSize)
+	default:
+		return first, nil
+	}
+}
+
+// pageInfo represents the PageInfo type.
+type pageInfo struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+// HasNextPage reports if there are more items after this page.
+func (p pageInfo) HasNextPage() bool {
+	return p.hasNextPage
+}
+
+// EndCursor is the cursor of the last item in the page.
+func (p pageInfo) EndCursor() *string {
+	return p.endCursor
+}
+
+// =============================================================================
+
+// Set of the kinds of cursors, so a cursor from one list can't be used to
+// page through another.
+const (
+	cursorBlock   = "block"
+	cursorTx      = "tx"
+	cursorMempool = "mempool"
+	cursorAccount = "account"
+)
+
+// errCursorRange is returned for a cursor above the blocks a list can
+// return, such as a cursor from a query with a higher toBlock.
+var errCursorRange = errors.New("cursor is outside the block range")
+
+// encodeCursor constructs an opaque cursor for the position in a list.
+func encodeCursor(kind string, position string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(kind + ":" + position))
+}
+
+// decodeCursor returns the position in the list from the cursor.
+func decodeCursor(kind string, cursor string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", errors.New("invalid cursor")
+	}
+
+	position := strings.TrimPrefix(string(raw), kind+":")
+	if len(position) == len(raw) {
+		return "", errors.New("invalid cursor")
+	}
+
+	return position, nil
+}
+
+// txPosition is the position of a transaction in the chain.
+type txPosition struct {
+	block uint64
+	index int
+}
+
+// encodeTxCursor constructs the cursor for a mined transaction.
+func encodeTxCursor(pos txPosition) string {
+	return encodeCursor(cursorTx, fmt.Sprintf("%d:%d", pos.block, pos.index))
+}
+
+// decodeTxCursor returns the position of a mined transaction from the cursor.
+func decodeTxCursor(cursor string) (txPosition, error) {
+	position, err := decodeCursor(cursorTx, cursor)
+	if err != nil {
+		return txPosition{}, err
+	}
+
+	var pos txPosition
+	if _, err := fmt.Sscanf(position, "%d:%d", &pos.block, &pos.index); err != nil {
+		return txPosition{}, errors.New("invalid cursor")
+	}
+
+	return pos, nil
+}
+
+// parseUint parses the position in a cursor.
+func parseUint(position string) (uint64, error) {
+	n, err := strconv.ParseUint(position, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+	return n, nil
+}
+
+// formatUint formats the position for a cursor.
+func formatUint(n uint64) string {
+	return strconv.FormatUint(n, 10)
+}
+
+// =============================================================================
+
+// blockFilter represents the BlockFilter input.
+type blockFilter struct {
+	FromBlock *Long
+	ToBlock   *Long
+	Account   *string
+}
+
+// transactionFilter represents the TransactionFilter input.
+type transactionFilter struct {
+	Account   *string
+	From      *string
+	To        *string
+	FromBlock *Long
+	ToBlock   *Long
+	MinValue  *Long
+	MaxValue  *Long
+}
+
+// txFilter is the validated form of the filters used to select the
+// transactions and blocks.
+type txFilter struct {
+	account   database.AccountID
+	from      database.AccountID
+	to        database.AccountID
+	fromBlock uint64
+	toBlock   uint64
+	minValue  uint64
+	maxValue  uint64
+}
+
+// newTxFilter validates the transaction filter. A nil filter matches every
+// transaction.
+func newTxFilter(f *transactionFilter) (txFilter, error) {
+	filter := txFilter{
+		toBlock:  ^uint64(0),
+		maxValue: ^uint64(0),
+	}
+
+	if f == nil {
+		return filter, nil
+	}
+
+	var err error
+	if filter.account, err = toAccountID(f.Account); err != nil {
+		return txFilter{}, err
+	}
+	if filter.from, err = toAccountID(f.From); err != nil {
+		return txFilter{}, err
+	}
+	if filter.to, err = toAccountID(f.To); err != nil {
+		return txFilter{}, err
+	}
+
+	if f.FromBlock != nil {
+		filter.fromBlock = uint64(*f.FromBlock)
+	}
+	if f.ToBlock != nil {
+		filter.toBlock = uint64(*f.ToBlock)
+	}
+	if f.MinValue != nil {
+		filter.minValue = uint64(*f.MinValue)
+	}
+	if f.MaxValue != nil {
+		filter.maxValue = uint64(*f.MaxValue)
+	}
+
+	return filter, nil
+}
+
+// newBlockFilter validates the block filter. A block matches when it has a
+// transaction for the account.
+func newBlockFilter(f *blockFilter) (txFilter, error) {
+	if f == nil {
+		return newTxFilter(nil)
+	}
+
+	return newTxFilter(&transactionFilter{
+		Account:   f.Account,
+		FromBlock: f.FromBlock,
+		ToBlock:   f.ToBlock,
+	})
+}
+
+// match reports if the transaction is selected by the filter. The block
+// range is applied by the caller.
+func (f txFilter) match(tx database.BlockTx) bool {
+	switch {
+	case f.account != "" && tx.FromID != f.account && tx.ToID != f.account:
+		return false
+	case f.from != "" && tx.FromID != f.from:
+		return false
+	case f.to != "" && tx.ToID != f.to:
+		return false
+	case tx.Value < f.minValue || tx.Value > f.maxValue:
+		return false
+	}
+
+	return true
+}
+
+// indexed returns the account the filter requires every transaction to
+// send or receive, so the blocks can be found in the account index. An empty
+// account id is returned when the filter matches any account.
+func (f txFilter) indexed() database.AccountID {
+	switch {
+	case f.account != "":
+		return f.account
+	case f.from != "":
+		return f.from
+	}
+	return f.to
+}
+
+// toAccountID validates the account and converts it to the checksummed
+// account id the database uses. A nil account is an empty account id.
+func toAccountID(account *string) (database.AccountID, error) {
+	if account == nil || *account == "" {
+		return "", nil
+	}
+
+	if _, err := database.ToAccountID(*account); err != nil {
+		return "", fmt.Errorf("%s: %w", *account, err)
+	}
+
+	return database.AccountID(common.HexToAddress(*account).Hex()), nil
+}
@@ -0,0 +1,435 @@
+// Package grpcapi maintains the gRPC version of the public handlers.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/wtran29/go-blockchain/business/grpc/mid"
+	"github.com/wtran29/go-blockchain/business/grpc/nodepb"
+	"github.com/wtran29/go-blockchain/business/web/v1/paging"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+	"github.com/wtran29/go-blockchain/foundation/events"
+	"github.com/wtran29/go-blockchain/foundation/nameservice"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ActivityMined is the activity type sent on an account subscription when a
+// transaction is mined. The other types are the mempool event types.
+const ActivityMined = "mined"
+
+// backfillPage represents the number of blocks read from storage at a time
+// when backfilling a block subscription.
+const backfillPage = 100
+
+// Handlers manages the set of gRPC endpoints.
+type Handlers struct {
+	nodepb.UnimplementedNodeServer
+
+	Log   *zap.SugaredLogger
+	State *state.State
+	NS    *nameservice.NameService
+	Evts  *events.Events
+}
+
+// ListAccounts returns the current balances for all users sorted by account.
+func (h Handlers) ListAccounts(ctx context.Context, req *nodepb.ListAccountsRequest) (*nodepb.ListAccountsResponse, error) {
+	var accounts map[database.AccountID]database.Account
+	switch req.Account {
+	case "":
+		accounts = h.State.Accounts()
+
+	default:
+		accountID, err := toAccountID(req.Account)
+		if err != nil {
+			return nil, err
+		}
+		account, err := h.State.QueryAccount(accountID)
+		if err != nil {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		accounts = map[database.AccountID]database.Account{accountID: account}
+	}
+
+	resp := nodepb.ListAccountsResponse{
+		LatestBlock: h.State.LatestBlock().Hash(),
+		Uncommitted: int64(h.State.MempoolLength()),
+		Accounts:    make([]*nodepb.Account, 0, len(accounts)),
+	}
+
+	for account, info := range accounts {
+		resp.Accounts = append(resp.Accounts, &nodepb.Account{
+			Account: string(account),
+			Name:    h.NS.Lookup(account),
+			Balance: info.Balance,
+			Nonce:   info.Nonce,
+		})
+	}
+	sort.Slice(resp.Accounts, func(i, j int) bool { return resp.Accounts[i].Account < resp.Accounts[j].Account })
+
+	return &resp, nil
+}
+
+// ListBlocks returns a page of blocks and their details in number order.
+// The blocks are read by number starting after the block in the next token,
+// so only the blocks needed for the page are read from disk. With an account,
+// the numbers of its blocks come from the index of accounts kept by the node.
+func (h Handlers) ListBlocks(ctx context.Context, req *nodepb.ListBlocksRequest) (*nodepb.ListBlocksResponse, error) {
+	var accountID database.AccountID
+	if req.Account != "" {
+		var err error
+		if accountID, err = toAccountID(req.Account); err != nil {
+			return nil, err
+		}
+	}
+
+	limit := int(req.Limit)
+	switch {
+	case limit == 0:
+		limit = paging.DefaultLimit
+	case limit > paging.MaxLimit:
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d", paging.MaxLimit)
+	}
+
+	var after uint64
+	if req.Next != "" {
+		position, err := paging.ParseToken(req.Next)
+		if err == nil {
+			after, err = strconv.ParseUint(position, 10, 64)
+		}
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid next token")
+		}
+	}
+
+	resp := nodepb.ListBlocksResponse{
+		Blocks: []*nodepb.Block{},
+	}
+
+	// The blocks to read are either every number after the position or the
+	// numbers of the account's blocks after the position.
+	latest := h.State.LatestBlock().Header.Number
+
+	var total uint64
+	if after < latest {
+		total = latest - after
+	}
+	at := func(i uint64) uint64 {
+		return after + 1 + i
+	}
+
+	if accountID != "" {
+		numbers := h.State.QueryBlockNumbersByAccount(accountID, after+1, latest)
+		total = uint64(len(numbers))
+		at = func(i uint64) uint64 {
+			return numbers[i]
+		}
+	}
+
+	for i := uint64(0); i < total; i++ {
+		number := at(i)
+
+		dbBlocks := h.State.QueryBlocksByNumber(number, number)
+		if len(dbBlocks) == 0 {
+			break
+		}
+		blk := dbBlocks[0]
+
+		if len(resp.Blocks) == limit {
+			last := resp.Blocks[len(resp.Blocks)-1].Number
+			resp.Next = paging.Token(strconv.FormatUint(last, 10))
+			break
+		}
+
+		pb, err := h.toBlock(blk)
+		if err != nil {
+			return nil, err
+		}
+		resp.Blocks = append(resp.Blocks, pb)
+	}
+
+	return &resp, nil
+}
+
+// GetSupply returns the total supply of coins along with the reward and base
+// fee for the next block.
+func (h Handlers) GetSupply(ctx context.Context, req *nodepb.GetSupplyRequest) (*nodepb.GetSupplyResponse, error) {
+	rules, err := h.State.NextBlockRules()
+	if err != nil {
+		return nil, err
+	}
+
+	sup := h.State.Supply()
+
+	resp := nodepb.GetSupplyResponse{
+		TotalSupply:      sup.Total,
+		Minted:           sup.Minted,
+		Burned:           sup.Burned,
+		NextMiningReward: rules.MiningReward,
+		NextBaseFee:      rules.BaseFee,
+	}
+
+	return &resp, nil
+}
+
+// SubmitTransaction adds new transactions to the mempool. A rejected
+// transaction comes back with the reason in the error details so the
+// client can act on it.
+func (h Handlers) SubmitTransaction(ctx context.Context, req *nodepb.SubmitTransactionRequest) (*nodepb.SubmitTransactionResponse, error) {
+	if req.Tx == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing transaction")
+	}
+
+	signedTx := database.SignedTx{
+		Tx: database.Tx{
+			ChainID:     uint16(req.Tx.ChainId),
+			Nonce:       req.Tx.Nonce,
+			FromID:      database.AccountID(req.Tx.From),
+			ToID:        database.AccountID(req.Tx.To),
+			Value:       req.Tx.Value,
+			Tip:         req.Tx.Tip,
+			Data:        req.Tx.Data,
+			GasLimit:    req.Tx.GasLimit,
+			MaxGasPrice: req.Tx.MaxGasPrice,
+			ValidUntil:  req.Tx.ValidUntil,
+		},
+		V: new(big.Int).SetBytes(req.Tx.V),
+		R: new(big.Int).SetBytes(req.Tx.R),
+		S: new(big.Int).SetBytes(req.Tx.S),
+	}
+
+	h.Log.Infow("add tran", "traceid", mid.GetValues(ctx).TraceID, "sig:nonce", signedTx, "from", signedTx.FromID, "to", signedTx.ToID, "value", signedTx.Value, "tip", signedTx.Tip)
+
+	tx, err := h.State.UpsertWalletTransaction(signedTx)
+	if err != nil {
+		if re := mempool.GetRejectError(err); re != nil {
+			code := codes.FailedPrecondition
+			if mempool.IsInvalid(re.Reason) {
+				code = codes.InvalidArgument
+			}
+
+			st, err := status.New(code, re.Error()).WithDetails(&errdetails.ErrorInfo{Reason: re.Reason, Domain: "mempool"})
+			if err != nil {
+				return nil, status.Error(code, re.Error())
+			}
+			return nil, st.Err()
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &nodepb.SubmitTransactionResponse{Hash: signature.Hash(tx)}, nil
+}
+
+// ListMempool returns the set of uncommitted transactions.
+func (h Handlers) ListMempool(ctx context.Context, req *nodepb.ListMempoolRequest) (*nodepb.ListMempoolResponse, error) {
+	var resp nodepb.ListMempoolResponse
+	for _, tran := range h.State.Mempool() {
+		if req.Account != "" && ((req.Account != string(tran.FromID)) && (req.Account != string(tran.ToID))) {
+			continue
+		}
+
+		resp.Txs = append(resp.Txs, h.toTransaction(tran))
+	}
+
+	return &resp, nil
+}
+
+// GetTransaction returns the transaction with the hash, whether it's mined
+// or still in the mempool.
+func (h Handlers) GetTransaction(ctx context.Context, req *nodepb.GetTransactionRequest) (*nodepb.GetTransactionResponse, error) {
+	tran, blk, mined, err := h.State.QueryTransactionByHash(req.Hash)
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "transaction %s not found", req.Hash)
+		}
+		return nil, err
+	}
+
+	resp := nodepb.GetTransactionResponse{
+		Tx:    h.toTransaction(tran),
+		Mined: mined,
+	}
+
+	if mined {
+		resp.BlockNumber = blk.Header.Number
+		resp.BlockHash = blk.Hash()
+	}
+
+	return &resp, nil
+}
+
+// SubscribeBlocks streams the blocks added to the chain. When a starting
+// block is provided, the blocks from that height are sent first.
+func (h Handlers) SubscribeBlocks(req *nodepb.SubscribeBlocksRequest, stream nodepb.Node_SubscribeBlocksServer) error {
+	id := mid.GetValues(stream.Context()).TraceID
+
+	var ch <-chan events.Event
+	var replay []events.Event
+	var backfill uint64
+	switch req.FromBlock {
+	case 0:
+		ch = h.Evts.Acquire(id, state.TopicBlockMined, state.TopicBlockAccepted)
+
+	default:
+		ch, replay, backfill = h.Evts.AcquireFromBlock(id, req.FromBlock, state.TopicBlockMined, state.TopicBlockAccepted)
+	}
+	defer h.Evts.Release(id)
+
+	// The blocks older than the event history are read from the chain a page
+	// at a time.
+	if backfill > 0 {
+		for start := req.FromBlock; start <= backfill; start += backfillPage {
+			end := start + backfillPage - 1
+			if end > backfill {
+				end = backfill
+			}
+
+			for _, blk := range h.State.QueryBlocksByNumber(start, end) {
+				pb, err := h.toBlock(blk)
+				if err != nil {
+					return err
+				}
+				if err := stream.Send(pb); err != nil {
+					return nil
+				}
+			}
+		}
+
+		// A block event can be both backfilled and still be in the
+		// history, so don't send it twice.
+		list := replay[:0]
+		for _, ev := range replay {
+			if be, ok := ev.Payload.(state.BlockEvent); ok && be.Block.Header.Number <= backfill {
+				continue
+			}
+			list = append(list, ev)
+		}
+		replay = list
+	}
+
+	send := func(ev events.Event) error {
+		be, ok := ev.Payload.(state.BlockEvent)
+		if !ok {
+			return nil
+		}
+
+		blk, err := database.ToBlock(be.Block)
+		if err != nil {
+			return err
+		}
+
+		pb, err := h.toBlock(blk)
+		if err != nil {
+			return err
+		}
+
+		return stream.Send(pb)
+	}
+
+	for _, ev := range replay {
+		if err := send(ev); err != nil {
+			return nil
+		}
+	}
+
+	for {
+		select {
+		case ev, wd := <-ch:
+			if !wd {
+				return nil
+			}
+			if err := send(ev); err != nil {
+				return nil
+			}
+
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// SubscribeAccount streams the changes to the mempool and the mined
+// transactions sent from or to the account.
+func (h Handlers) SubscribeAccount(req *nodepb.SubscribeAccountRequest, stream nodepb.Node_SubscribeAccountServer) error {
+	accountID, err := toAccountID(req.Account)
+	if err != nil {
+		return err
+	}
+
+	id := mid.GetValues(stream.Context()).TraceID
+
+	mch := h.State.SubscribeMempool(id, accountID)
+	defer h.State.UnsubscribeMempool(id)
+
+	bch := h.Evts.Acquire(id, state.TopicBlockMined, state.TopicBlockAccepted)
+	defer h.Evts.Release(id)
+
+	for {
+		select {
+		case mev, wd := <-mch:
+			if !wd {
+				return nil
+			}
+
+			act := nodepb.AccountActivity{
+				Type:   mev.Type,
+				Reason: mev.Reason,
+				Tx:     h.toTransaction(mev.Tx),
+			}
+
+			if err := stream.Send(&act); err != nil {
+				return nil
+			}
+
+		case ev, wd := <-bch:
+			if !wd {
+				return nil
+			}
+
+			be, ok := ev.Payload.(state.BlockEvent)
+			if !ok {
+				continue
+			}
+
+			for _, tran := range be.Block.Trans {
+				if tran.FromID != accountID && tran.ToID != accountID {
+					continue
+				}
+
+				act := nodepb.AccountActivity{
+					Type:        ActivityMined,
+					Tx:          h.toTransaction(tran),
+					BlockNumber: be.Block.Header.Number,
+				}
+
+				if err := stream.Send(&act); err != nil {
+					return nil
+				}
+			}
+
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// =============================================================================
+
+// toAccountID validates the account and returns an invalid argument error
+// the client can see when it's not valid.
+func toAccountID(account string) (database.AccountID, error) {
+	accountID, err := database.ToAccountID(account)
+	if err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	return accountID, nil
+}
@@ -0,0 +1,213 @@
+package grpcapi_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/wtran29/go-blockchain/app/services/node/handlers/v1/grpcapi"
+	"github.com/wtran29/go-blockchain/business/grpc/nodepb"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/genesis"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/storage/memory"
+	"github.com/wtran29/go-blockchain/foundation/events"
+	"github.com/wtran29/go-blockchain/foundation/nameservice"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// beneficiaryID is the account that receives the transfers and the rewards
+// for the blocks mined in the tests.
+const beneficiaryID = database.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+
+// worker implements the state.Worker interface without doing any work.
+type worker struct{}
+
+func (worker) Shutdown()                              {}
+func (worker) Sync()                                  {}
+func (worker) SignalStartMining()                     {}
+func (worker) SignalCancelMining()                    {}
+func (worker) SignalShareTx(blockTx database.BlockTx) {}
+
+func Test_ListAccounts(t *testing.T) {
+	client, _, _ := newClient(t)
+
+	resp, err := client.ListAccounts(context.Background(), &nodepb.ListAccountsRequest{})
+	if err != nil {
+		t.Fatalf("Should be able to list the accounts: %s", err)
+	}
+
+	if len(resp.Accounts) < 2 {
+		t.Fatalf("Should list the genesis accounts, got %d", len(resp.Accounts))
+	}
+	for i := 1; i < len(resp.Accounts); i++ {
+		if resp.Accounts[i-1].Account >= resp.Accounts[i].Account {
+			t.Fatalf("Should sort the accounts, got %s before %s", resp.Accounts[i-1].Account, resp.Accounts[i].Account)
+		}
+	}
+}
+
+func Test_ListBlocks(t *testing.T) {
+	client, st, keys := newClient(t)
+
+	// Block 1 and 3 are sent by the first account, block 2 by the second.
+	mine(t, st, keys[0], 1)
+	mine(t, st, keys[1], 1)
+	mine(t, st, keys[0], 2)
+
+	tt := []struct {
+		name    string
+		account string
+		limit   uint32
+		pages   [][]uint64
+	}{
+		{name: "all", limit: 2, pages: [][]uint64{{1, 2}, {3}}},
+		{name: "default-limit", pages: [][]uint64{{1, 2, 3}}},
+		{name: "account", account: string(database.PublicKeyToAccountID(keys[0].PublicKey)), limit: 1, pages: [][]uint64{{1}, {3}}},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			req := nodepb.ListBlocksRequest{Account: tst.account, Limit: tst.limit}
+
+			for i, exp := range tst.pages {
+				resp, err := client.ListBlocks(context.Background(), &req)
+				if err != nil {
+					t.Fatalf("page[%d]: Should be able to list the blocks: %s", i, err)
+				}
+
+				if len(resp.Blocks) != len(exp) {
+					t.Fatalf("page[%d]: Should get %d blocks, got %d", i, len(exp), len(resp.Blocks))
+				}
+				for j, blk := range resp.Blocks {
+					if blk.Number != exp[j] {
+						t.Fatalf("page[%d]: Should get block %d in position %d, got %d", i, exp[j], j, blk.Number)
+					}
+				}
+
+				last := i == len(tst.pages)-1
+				if last != (resp.Next == "") {
+					t.Fatalf("page[%d]: Should only get a next token before the last page, got %q", i, resp.Next)
+				}
+				req.Next = resp.Next
+			}
+		})
+	}
+
+	for _, req := range []*nodepb.ListBlocksRequest{{Limit: 101}, {Next: "!"}} {
+		_, err := client.ListBlocks(context.Background(), req)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("Should reject %v with an invalid argument, got %v", req, err)
+		}
+	}
+}
+
+// =============================================================================
+
+// newClient starts the gRPC handlers over an in-memory connection for a node
+// on a PoA chain that funds the accounts for the returned keys.
+func newClient(t *testing.T) (nodepb.NodeClient, *state.State, []*ecdsa.PrivateKey) {
+	t.Helper()
+
+	keys := make([]*ecdsa.PrivateKey, 2)
+	balances := make(map[string]uint64, len(keys))
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("Should be able to generate a private key: %s", err)
+		}
+		keys[i] = key
+		balances[string(database.PublicKeyToAccountID(key.PublicKey))] = 1_000_000
+	}
+
+	storage, err := memory.New()
+	if err != nil {
+		t.Fatalf("Should be able to construct the storage: %s", err)
+	}
+
+	st, err := state.New(state.Config{
+		BeneficiaryID: beneficiaryID,
+		Host:          "localhost:9080",
+		Storage:       storage,
+		Genesis: genesis.Genesis{
+			Date:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			ChainID:       1,
+			TransPerBlock: 1,
+			Difficulty:    1,
+			MiningReward:  700,
+			GasPrice:      15,
+			BaseFee:       5,
+			Balances:      balances,
+			Gas:           genesis.GasSchedule{TxBase: 21, Transfer: 9},
+		},
+		SelectStrategy: selector.StrategyFeeDensity,
+		Consensus:      state.ConsensusPOA,
+		MiningWorkers:  1,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the state: %s", err)
+	}
+	st.Worker = worker{}
+
+	ns, err := nameservice.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("Should be able to construct the name service: %s", err)
+	}
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	nodepb.RegisterNodeServer(srv, grpcapi.Handlers{
+		Log:   zap.NewNop().Sugar(),
+		State: st,
+		NS:    ns,
+		Evts:  events.New(),
+	})
+
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	dialer := func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}
+
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Should be able to dial the server: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return nodepb.NewNodeClient(conn), st, keys
+}
+
+// mine submits a transfer from the account to the beneficiary and mines it
+// into a block.
+func mine(t *testing.T, st *state.State, key *ecdsa.PrivateKey, nonce uint64) {
+	t.Helper()
+
+	tx, err := database.NewTx(1, nonce, database.PublicKeyToAccountID(key.PublicKey), beneficiaryID, 10, 0, nil, 30, 20)
+	if err != nil {
+		t.Fatalf("Should be able to construct the transaction: %s", err)
+	}
+
+	signedTx, err := tx.Sign(key)
+	if err != nil {
+		t.Fatalf("Should be able to sign the transaction: %s", err)
+	}
+
+	if _, err := st.UpsertWalletTransaction(signedTx); err != nil {
+		t.Fatalf("Should be able to submit the transaction: %s", err)
+	}
+
+	if _, err := st.MineNewBlock(context.Background()); err != nil {
+		t.Fatalf("Should be able to mine a block: %s", err)
+	}
+}
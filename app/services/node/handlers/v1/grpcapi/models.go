@@ -0,0 +1,70 @@
+package grpcapi
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/wtran29/go-blockchain/business/grpc/nodepb"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
+)
+
+// toTransaction converts a transaction to the message sent to the client.
+func (h Handlers) toTransaction(tran database.BlockTx) *nodepb.Transaction {
+	return &nodepb.Transaction{
+		Hash:        signature.Hash(tran),
+		From:        string(tran.FromID),
+		FromName:    h.NS.Lookup(tran.FromID),
+		To:          string(tran.ToID),
+		ToName:      h.NS.Lookup(tran.ToID),
+		ChainId:     uint32(tran.ChainID),
+		Nonce:       tran.Nonce,
+		Value:       tran.Value,
+		Tip:         tran.Tip,
+		Data:        tran.Data,
+		GasLimit:    tran.GasLimit,
+		MaxGasPrice: tran.MaxGasPrice,
+		ValidUntil:  tran.ValidUntil,
+		Timestamp:   tran.TimeStamp,
+		GasPrice:    tran.GasPrice,
+		GasUnits:    tran.GasUnits,
+		Sig:         tran.SignatureString(),
+	}
+}
+
+// toBlock converts a block to the message sent to the client. Each
+// transaction carries its merkle proof so the client can verify it.
+func (h Handlers) toBlock(blk database.Block) (*nodepb.Block, error) {
+	values := blk.MerkleTree.Values()
+
+	trans := make([]*nodepb.Transaction, len(values))
+	for i, tran := range values {
+		rawProof, order, err := blk.MerkleTree.Proof(tran)
+		if err != nil {
+			return nil, err
+		}
+		proof := make([]string, len(rawProof))
+		for i, rp := range rawProof {
+			proof[i] = hexutil.Encode(rp)
+		}
+
+		trans[i] = h.toTransaction(tran)
+		trans[i].Proof = proof
+		trans[i].ProofOrder = order
+	}
+
+	b := nodepb.Block{
+		Number:        blk.Header.Number,
+		Hash:          blk.Hash(),
+		PrevBlockHash: blk.Header.PrevBlockHash,
+		Timestamp:     blk.Header.TimeStamp,
+		Beneficiary:   string(blk.Header.BeneficiaryID),
+		Difficulty:    blk.Header.Difficulty,
+		MiningReward:  blk.Header.MiningReward,
+		BaseFee:       blk.Header.BaseFee,
+		StateRoot:     blk.Header.StateRoot,
+		TransRoot:     blk.Header.TransRoot,
+		Nonce:         blk.Header.Nonce,
+		Txs:           trans,
+	}
+
+	return &b, nil
+}
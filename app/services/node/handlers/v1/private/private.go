@@ -12,6 +12,7 @@ import (
 	"strconv"
 
 	v1 "github.com/wtran29/go-blockchain/business/web/v1"
+	"github.com/wtran29/go-blockchain/business/webhook"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/peer"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
@@ -26,6 +27,7 @@ type Handlers struct {
 	Log   *zap.SugaredLogger
 	State *state.State
 	NS    *nameservice.NameService
+	Hooks *webhook.Webhooks
 }
 
 // SubmitNodeTransaction adds new node transactions to the mempool.
@@ -169,6 +171,68 @@ func (h Handlers) Mempool(ctx context.Context, w http.ResponseWriter, r *http.Re
 	return web.Respond(ctx, w, txs, http.StatusOK)
 }
 
+// MempoolHashes returns a reference to each uncommitted transaction so a
+// peer can work out which transactions it's missing.
+func (h Handlers) MempoolHashes(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	refs := h.State.MempoolRefs()
+	return web.Respond(ctx, w, refs, http.StatusOK)
+}
+
+// MempoolFetch returns the uncommitted transactions for the specified hashes.
+func (h Handlers) MempoolFetch(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var hashes []string
+	if err := web.Decode(r, &hashes); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	txs := h.State.MempoolLookup(hashes)
+	return web.Respond(ctx, w, txs, http.StatusOK)
+}
+
+// RegisterWebhook registers a URL to receive chain events. The response
+// includes the secret used to sign the payloads, which is not given out
+// again.
+func (h Handlers) RegisterWebhook(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var hook webhook.Hook
+	if err := web.Decode(r, &hook); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	// A hook registered through the API is always saved.
+	hook.Config = false
+
+	hook, err := h.Hooks.Register(hook)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	return web.Respond(ctx, w, hook, http.StatusCreated)
+}
+
+// Webhooks returns the registered webhooks.
+func (h Handlers) Webhooks(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return web.Respond(ctx, w, h.Hooks.Hooks(), http.StatusOK)
+}
+
+// RemoveWebhook removes the specified webhook.
+func (h Handlers) RemoveWebhook(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	if err := h.Hooks.Remove(web.Param(r, "id")); err != nil {
+		return v1.NewRequestError(err, http.StatusNotFound)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// WebhookDeliveries returns the log of webhook deliveries.
+func (h Handlers) WebhookDeliveries(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return web.Respond(ctx, w, h.Hooks.Deliveries(), http.StatusOK)
+}
+
+// WebhookDeadLetters returns the webhook deliveries that failed every attempt.
+func (h Handlers) WebhookDeadLetters(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return web.Respond(ctx, w, h.Hooks.DeadLetters(), http.StatusOK)
+}
+
 // =============================================================================================
 // DO NOT USE IN PRODUCTION - for testing purposes only
 func GeneratePrivateKey() (string, error) {
@@ -2,6 +2,7 @@ package public
 
 import (
 	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/genesis"
 )
 
 type act struct {
@@ -15,6 +16,7 @@ type actInfo struct {
 	LastestBlock string `json:"lastest_block"`
 	Uncommitted  int    `json:"uncommitted"`
 	Accounts     []act  `json:"accounts"`
+	Next         string `json:"next,omitempty"`
 }
 
 type tx struct {
@@ -27,6 +29,9 @@ type tx struct {
 	Value       uint64             `json:"value"`
 	Tip         uint64             `json:"tip"`
 	Data        []byte             `json:"data"`
+	GasLimit    uint64             `json:"gas_limit"`
+	MaxGasPrice uint64             `json:"max_gas_price"`
+	ValidUntil  uint64             `json:"valid_until"`
 	TimeStamp   uint64             `json:"timestamp"`
 	GasPrice    uint64             `json:"gas_price"`
 	GasUnits    uint64             `json:"gas_units"`
@@ -35,15 +40,98 @@ type tx struct {
 	ProofOrder  []int64            `json:"proof_order"`
 }
 
+type txPage struct {
+	Txs  []tx   `json:"txs"`
+	Next string `json:"next,omitempty"`
+}
+
+type mempoolEvent struct {
+	Type    string `json:"type"`
+	Reason  string `json:"reason,omitempty"`
+	Dropped uint64 `json:"dropped,omitempty"`
+	Tx      tx     `json:"tx"`
+}
+
 type block struct {
 	Number        uint64             `json:"number"`
+	Hash          string             `json:"hash"`
 	PrevBlockHash string             `json:"prev_block_hash"`
 	TimeStamp     uint64             `json:"timestamp"`
 	BeneficiaryID database.AccountID `json:"beneficiary"`
-	Difficulty    uint16             `json:"difficulty"`
+	Difficulty    uint64             `json:"difficulty"`
 	MiningReward  uint64             `json:"mining_reward"`
+	BaseFee       uint64             `json:"base_fee"`
 	StateRoot     string             `json:"state_root"`
 	TransRoot     string             `json:"trans_root"`
 	Nonce         uint64             `json:"nonce"`
 	Transactions  []tx               `json:"txs"`
 }
+
+type richAct struct {
+	Rank int `json:"rank"`
+	act
+	Share float64 `json:"share"`
+}
+
+type richList struct {
+	TotalSupply uint64    `json:"total_supply"`
+	Accounts    []richAct `json:"accounts"`
+	Next        string    `json:"next,omitempty"`
+}
+
+type statsTotals struct {
+	Blocks       uint64 `json:"blocks"`
+	Transactions uint64 `json:"transactions"`
+	Fees         uint64 `json:"fees"`
+	Burned       uint64 `json:"burned"`
+}
+
+type statsPeriod struct {
+	Period          string  `json:"period"`
+	Blocks          int     `json:"blocks"`
+	Transactions    int     `json:"transactions"`
+	AvgBlockTimeMS  int64   `json:"avg_block_time_ms"`
+	TxPerSecond     float64 `json:"tx_per_second"`
+	Fees            uint64  `json:"fees"`
+	AvgFeesPerBlock uint64  `json:"avg_fees_per_block"`
+	Burned          uint64  `json:"burned"`
+	HashRate        uint64  `json:"hash_rate"`
+	ActiveAccounts  int     `json:"active_accounts"`
+}
+
+type chainStats struct {
+	LatestBlock uint64        `json:"latest_block"`
+	Totals      statsTotals   `json:"totals"`
+	Periods     []statsPeriod `json:"periods"`
+}
+
+type feeEstimate struct {
+	Slow          uint64 `json:"slow"`
+	Normal        uint64 `json:"normal"`
+	Fast          uint64 `json:"fast"`
+	BaseFee       uint64 `json:"base_fee"`
+	Strategy      string `json:"strategy"`
+	Pending       int    `json:"pending"`
+	BlockCapacity int    `json:"block_capacity"`
+	BlocksRead    int    `json:"blocks_read"`
+	Size          uint64 `json:"size"`
+	GasUnits      uint64 `json:"gas_units"`
+}
+
+type genesisInfo struct {
+	Hash    string          `json:"hash"`
+	Genesis genesis.Genesis `json:"genesis"`
+}
+
+type blockPage struct {
+	Blocks []block `json:"blocks"`
+	Next   string  `json:"next,omitempty"`
+}
+
+type supply struct {
+	TotalSupply      uint64 `json:"total_supply"`
+	Minted           uint64 `json:"minted"`
+	Burned           uint64 `json:"burned"`
+	NextMiningReward uint64 `json:"next_mining_reward"`
+	NextBaseFee      uint64 `json:"next_base_fee"`
+}
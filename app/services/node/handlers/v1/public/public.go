@@ -3,14 +3,23 @@ package public
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/gorilla/websocket"
 	v1 "github.com/wtran29/go-blockchain/business/web/v1"
+	"github.com/wtran29/go-blockchain/business/web/v1/paging"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
 	"github.com/wtran29/go-blockchain/foundation/events"
 	"github.com/wtran29/go-blockchain/foundation/nameservice"
@@ -18,6 +27,14 @@ import (
 	"go.uber.org/zap"
 )
 
+// keepAliveInterval represents how often an idle event stream is sent a
+// message to keep the connection from being closed by a proxy.
+const keepAliveInterval = 15 * time.Second
+
+// backfillPage represents the number of blocks read from storage at a time
+// when backfilling an event stream.
+const backfillPage = 100
+
 // Handlers manages the set of bar ledger endpoints.
 type Handlers struct {
 	Log   *zap.SugaredLogger
@@ -49,15 +66,32 @@ func (h Handlers) StartMining(ctx context.Context, w http.ResponseWriter, r *htt
 	return web.Respond(ctx, w, status, http.StatusOK)
 }
 
-// Events handles a web socket to provide events to a client.
+// Events handles a web socket to provide events to a client. The topics
+// query parameter takes a comma separated list of topics to receive, otherwise
+// events for every topic are sent. A client can resume with the since query
+// parameter set to the id of the last event it saw or the from_block query
+// parameter set to a block height. Blocks that are no longer in the event
+// history are backfilled from storage as block.accepted events with a
+// sequence number of 0. The upgrader only accepts the configured origins.
 func (h Handlers) Events(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	v, err := web.GetValues(ctx)
 	if err != nil {
 		return web.NewShutdownError("web value missing from context")
 	}
 
-	// Need this to handle CORS on the websocket.
-	h.WS.CheckOrigin = func(r *http.Request) bool { return true }
+	var topics []string
+	if list := r.URL.Query().Get("topics"); list != "" {
+		topics = strings.Split(list, ",")
+	}
+
+	// This provides a channel for receiving events from the blockchain and
+	// the events to replay. This happens before the upgrade so an error can
+	// be returned to the client.
+	sub, err := h.acquireEvents(v.TraceID, r, topics)
+	if err != nil {
+		return err
+	}
+	defer h.Evts.Release(v.TraceID)
 
 	// This upgrades the HTTP connection to a websocket connection.
 	c, err := h.WS.Upgrade(w, r, nil)
@@ -66,24 +100,29 @@ func (h Handlers) Events(ctx context.Context, w http.ResponseWriter, r *http.Req
 	}
 	defer c.Close()
 
-	// This provides a channel for receiving events from the blockchain.
-	ch := h.Evts.Acquire(v.TraceID)
-	defer h.Evts.Release(v.TraceID)
+	send := func(ev events.Event) error {
+		return c.WriteJSON(ev)
+	}
+	if err := h.replay(sub, send, func() {}); err != nil {
+		return err
+	}
+	ch := sub.ch
 
 	// Starting a ticker to send a ping message over the websocket.
-	ticker := time.NewTicker(time.Second)
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
 
 	// Block waiting for events from the blockchain or ticker.
 	for {
 		select {
-		case msg, wd := <-ch:
+		case ev, wd := <-ch:
 
 			// If the channel is closed, release the websocket.
 			if !wd {
 				return nil
 			}
 
-			if err := c.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			if err := c.WriteJSON(ev); err != nil {
 				return err
 			}
 
@@ -95,6 +134,208 @@ func (h Handlers) Events(ctx context.Context, w http.ResponseWriter, r *http.Req
 	}
 }
 
+// EventStream provides the same events as the websocket using Server-Sent
+// Events for clients and proxies that don't support websockets. It takes the
+// same query parameters, and the Last-Event-ID header the browser sends when
+// reconnecting resumes from that event.
+func (h Handlers) EventStream(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errors.New("streaming is not supported")
+	}
+
+	var topics []string
+	if list := r.URL.Query().Get("topics"); list != "" {
+		topics = strings.Split(list, ",")
+	}
+
+	sub, err := h.acquireEvents(v.TraceID, r, topics)
+	if err != nil {
+		return err
+	}
+	defer h.Evts.Release(v.TraceID)
+
+	// The stream stays open well past the server's write timeout, so clear
+	// the deadline when the response writer supports it.
+	if d, ok := w.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		d.SetWriteDeadline(time.Time{})
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(ev events.Event) error {
+		return writeEvent(w, ev)
+	}
+	if err := h.replay(sub, send, flusher.Flush); err != nil {
+		return nil
+	}
+	flusher.Flush()
+	ch := sub.ch
+
+	// Starting a ticker to send a comment to keep the stream open.
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+
+	// Block waiting for events from the blockchain, the ticker or the
+	// client to go away.
+	for {
+		select {
+		case ev, wd := <-ch:
+
+			// If the channel is closed, end the stream.
+			if !wd {
+				return nil
+			}
+
+			if err := writeEvent(w, ev); err != nil {
+				return nil
+			}
+			flusher.Flush()
+
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			flusher.Flush()
+
+		case <-r.Context().Done():
+			return nil
+		}
+	}
+}
+
+// writeEvent writes the event in the Server-Sent Events format. Backfilled
+// events don't have an id, so they are written without one to not move the
+// client's Last-Event-ID backwards.
+func writeEvent(w io.Writer, ev events.Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	if ev.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", ev.ID); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Topic, data)
+	return err
+}
+
+// subscription represents the events a client registered for. The blocks
+// from and to are backfilled from storage before the events are replayed.
+// There is nothing to backfill when to is 0.
+type subscription struct {
+	ch     <-chan events.Event
+	replay []events.Event
+	from   uint64
+	to     uint64
+}
+
+// acquireEvents registers for events and returns the events the client
+// asked to replay along with the blocks to backfill from storage. The
+// Last-Event-ID header takes the place of the since query parameter.
+func (h Handlers) acquireEvents(id string, r *http.Request, topics []string) (subscription, error) {
+	query := r.URL.Query()
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		query.Set("since", lastID)
+	}
+
+	switch {
+	case query.Get("since") != "":
+		if _, _, err := events.ParseToken(query.Get("since")); err != nil {
+			return subscription{}, v1.NewRequestError(fmt.Errorf("invalid since: %w", err), http.StatusBadRequest)
+		}
+
+		ch, replay, err := h.Evts.AcquireSince(id, query.Get("since"), topics...)
+		if err != nil {
+			return subscription{}, v1.NewRequestError(fmt.Errorf("%w: resume from a block height", err), http.StatusGone)
+		}
+		return subscription{ch: ch, replay: replay}, nil
+
+	case query.Get("from_block") != "":
+		from, err := strconv.ParseUint(query.Get("from_block"), 10, 64)
+		if err != nil {
+			return subscription{}, v1.NewRequestError(fmt.Errorf("invalid from_block: %w", err), http.StatusBadRequest)
+		}
+		if from == 0 {
+			from = 1
+		}
+
+		ch, replay, backfill := h.Evts.AcquireFromBlock(id, from, topics...)
+		if !wantsTopic(topics, state.TopicBlockAccepted) {
+			backfill = 0
+		}
+		return subscription{ch: ch, replay: replay, from: from, to: backfill}, nil
+	}
+
+	return subscription{ch: h.Evts.Acquire(id, topics...)}, nil
+}
+
+// replay sends the blocks to backfill as block.accepted events, reading a
+// page of blocks from storage at a time, and then the events to replay. The
+// flush function is called after each page is sent.
+func (h Handlers) replay(sub subscription, send func(events.Event) error, flush func()) error {
+	for start := sub.from; sub.to > 0 && start <= sub.to; start += backfillPage {
+		end := start + backfillPage - 1
+		if end > sub.to {
+			end = sub.to
+		}
+
+		for _, block := range h.State.QueryBlocksByNumber(start, end) {
+			ev := events.Event{
+				Block:   block.Header.Number,
+				Topic:   state.TopicBlockAccepted,
+				Payload: state.BlockEvent{Block: database.NewBlockData(block)},
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+		}
+		flush()
+	}
+
+	// A block event can be both backfilled and still be in the history, so
+	// don't send it twice.
+	for _, ev := range sub.replay {
+		if be, ok := ev.Payload.(state.BlockEvent); ok && be.Block.Header.Number <= sub.to {
+			continue
+		}
+		if err := send(ev); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// wantsTopic reports if the topic is in the list of topics. An empty list
+// means every topic is wanted.
+func wantsTopic(topics []string, topic string) bool {
+	if len(topics) == 0 {
+		return true
+	}
+
+	for _, t := range topics {
+		if t == topic {
+			return true
+		}
+	}
+
+	return false
+}
+
 // SubmitWalletTransaction adds new transactions to the mempool.
 func (h Handlers) SubmitWalletTransaction(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	v, err := web.GetValues(ctx)
@@ -110,11 +351,14 @@ func (h Handlers) SubmitWalletTransaction(ctx context.Context, w http.ResponseWr
 
 	h.Log.Infow("add tran", "traceid", v.TraceID, "sig:nonce", signedTx, "from", signedTx.FromID, "to", signedTx.ToID, "value", signedTx.Value, "tip", signedTx.Tip)
 
-	// Ask the state package to add this transaction to the mempool. Only the
-	// checks are the transaction signature and the recipient account format.
-	// It's up to the wallet to make sure the account has a proper balance and
-	// nonce. Fees will be taken if this transaction is mined into a block.
-	if err := h.State.UpsertWalletTransaction(signedTx); err != nil {
+	// Ask the state package to add this transaction to the mempool. The
+	// transaction is checked for a proper signature, gas, nonce and that the
+	// account can pay for it. A rejected transaction comes back with the
+	// reason so the wallet can show it.
+	if _, err := h.State.UpsertWalletTransaction(signedTx); err != nil {
+		if re := mempool.GetRejectError(err); re != nil {
+			return v1.NewReasonError(re, re.Reason, http.StatusBadRequest)
+		}
 		return v1.NewRequestError(err, http.StatusBadRequest)
 	}
 
@@ -127,42 +371,122 @@ func (h Handlers) SubmitWalletTransaction(ctx context.Context, w http.ResponseWr
 	return web.Respond(ctx, w, resp, http.StatusOK)
 }
 
-// Mempool returns the set of uncommitted transactions.
+// Mempool returns a page of the uncommitted transactions. By default they are
+// in the order they would be mined.
 func (h Handlers) Mempool(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	acct := web.Param(r, "account")
 
-	mempool := h.State.Mempool()
+	q, err := paging.Parse(r, sortPriority, "timestamp", "value", "tip")
+	if err != nil {
+		return err
+	}
 
-	trans := []tx{}
-	for _, tran := range mempool {
+	var list []database.BlockTx
+	for _, tran := range h.State.Mempool() {
 		if acct != "" && ((acct != string(tran.FromID)) && (acct != string(tran.ToID))) {
 			continue
 		}
 
-		trans = append(trans, tx{
-			FromAccount: tran.FromID,
-			FromName:    h.NS.Lookup(tran.FromID),
-			To:          tran.ToID,
-			ToName:      h.NS.Lookup(tran.ToID),
-			ChainID:     tran.ChainID,
-			Nonce:       tran.Nonce,
-			Value:       tran.Value,
-			Tip:         tran.Tip,
-			Data:        tran.Data,
-			TimeStamp:   tran.TimeStamp,
-			GasPrice:    tran.GasPrice,
-			GasUnits:    tran.GasUnits,
-			Sig:         tran.SignatureString(),
-		})
+		if !q.InTime(tran.TimeStamp) {
+			continue
+		}
+
+		list = append(list, tran)
+	}
+
+	sortTxs(list, q.Sort, q.Desc)
+
+	start, end, next, err := paging.Offset(q, len(list))
+	if err != nil {
+		return err
+	}
+
+	resp := txPage{
+		Txs:  make([]tx, 0, end-start),
+		Next: next,
+	}
+
+	for _, tran := range list[start:end] {
+		resp.Txs = append(resp.Txs, h.mempoolTx(tran))
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
+// MempoolEvents streams the changes to the mempool over a websocket. When an
+// account is provided, only the changes for transactions sent from or to
+// that account are sent.
+func (h Handlers) MempoolEvents(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var accounts []database.AccountID
+	if acct := web.Param(r, "account"); acct != "" {
+		accountID, err := database.ToAccountID(acct)
+		if err != nil {
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		}
+		accounts = append(accounts, accountID)
 	}
 
-	return web.Respond(ctx, w, trans, http.StatusOK)
+	// This provides a channel for receiving changes to the mempool. It's
+	// acquired before the upgrade so no change is missed once the client
+	// is connected.
+	ch := h.State.SubscribeMempool(v.TraceID, accounts...)
+	defer h.State.UnsubscribeMempool(v.TraceID)
+
+	// This upgrades the HTTP connection to a websocket connection.
+	c, err := h.WS.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	// Starting a ticker to send a ping message over the websocket.
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+
+	// Block waiting for changes to the mempool or ticker.
+	for {
+		select {
+		case mev, wd := <-ch:
+
+			// If the channel is closed, release the websocket.
+			if !wd {
+				return nil
+			}
+
+			msg := mempoolEvent{
+				Type:    mev.Type,
+				Reason:  mev.Reason,
+				Dropped: mev.Dropped,
+				Tx:      h.mempoolTx(mev.Tx),
+			}
+
+			if err := c.WriteJSON(msg); err != nil {
+				return nil
+			}
+
+		case <-ticker.C:
+			if err := c.WriteMessage(websocket.PingMessage, []byte("ping")); err != nil {
+				return nil
+			}
+		}
+	}
 }
 
-// Accounts returns the current balances for all users.
+// Accounts returns a page of the current balances for all users, or the
+// balance for a single user.
 func (h Handlers) Accounts(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	accountStr := web.Param(r, "account")
 
+	q, err := paging.Parse(r, "account", "balance", "nonce")
+	if err != nil {
+		return err
+	}
+
 	var accounts map[database.AccountID]database.Account
 	switch accountStr {
 	case "":
@@ -191,16 +515,177 @@ func (h Handlers) Accounts(ctx context.Context, w http.ResponseWriter, r *http.R
 		resp = append(resp, act)
 	}
 
+	sortAccounts(resp, q.Sort, q.Desc)
+
+	start, end, next, err := paging.Offset(q, len(resp))
+	if err != nil {
+		return err
+	}
+
 	ai := actInfo{
 		LastestBlock: h.State.LatestBlock().Hash(),
-		Uncommitted:  len(h.State.Mempool()),
-		Accounts:     resp,
+		Uncommitted:  h.State.MempoolCount(),
+		Accounts:     resp[start:end],
+		Next:         next,
 	}
 
 	return web.Respond(ctx, w, ai, http.StatusOK)
 }
 
-// BlocksByAccount returns all the blocks and their details.
+// Supply returns the total supply of coins along with the reward and base
+// fee for the next block.
+func (h Handlers) Supply(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	rules, err := h.State.NextBlockRules()
+	if err != nil {
+		return err
+	}
+
+	sup := h.State.Supply()
+
+	resp := supply{
+		TotalSupply:      sup.Total,
+		Minted:           sup.Minted,
+		Burned:           sup.Burned,
+		NextMiningReward: rules.MiningReward,
+		NextBaseFee:      rules.BaseFee,
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
+// FeeEstimate returns the suggested tips for a transaction to be mined
+// slowly, normally or fast. The size and gas query parameters describe the
+// transaction, otherwise the tips are for a typical recent transaction.
+func (h Handlers) FeeEstimate(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var size, gasUnits uint64
+	for name, v := range map[string]*uint64{"size": &size, "gas": &gasUnits} {
+		if s := r.URL.Query().Get(name); s != "" {
+			n, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				return v1.NewRequestError(fmt.Errorf("invalid %s: %w", name, err), http.StatusBadRequest)
+			}
+			*v = n
+		}
+	}
+
+	est, err := h.State.EstimateFees(size, gasUnits)
+	if err != nil {
+		return err
+	}
+
+	resp := feeEstimate{
+		Slow:          est.Slow,
+		Normal:        est.Normal,
+		Fast:          est.Fast,
+		BaseFee:       est.BaseFee,
+		Strategy:      est.Strategy,
+		Pending:       est.Pending,
+		BlockCapacity: est.BlockCapacity,
+		BlocksRead:    est.BlocksRead,
+		Size:          est.Size,
+		GasUnits:      est.GasUnits,
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
+// RichList returns the accounts with the highest balances along with their
+// share of the total supply.
+func (h Handlers) RichList(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	q, err := paging.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	accounts := h.State.RichList()
+
+	start, end, next, err := paging.Offset(q, len(accounts))
+	if err != nil {
+		return err
+	}
+
+	supply := h.State.Supply().Total
+
+	resp := richList{
+		TotalSupply: supply,
+		Accounts:    make([]richAct, 0, end-start),
+		Next:        next,
+	}
+
+	for i, account := range accounts[start:end] {
+		ra := richAct{
+			Rank: start + i + 1,
+			act: act{
+				Account: account.AccountID,
+				Name:    h.NS.Lookup(account.AccountID),
+				Balance: account.Balance,
+				Nonce:   account.Nonce,
+			},
+		}
+		if supply > 0 {
+			ra.Share = float64(account.Balance) / float64(supply)
+		}
+		resp.Accounts = append(resp.Accounts, ra)
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
+// statsPeriods represents the periods of time the chain stats are reported
+// for.
+var statsPeriods = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+// Stats returns the counts since genesis along with the block times,
+// throughput, fees, estimated hash rate and active accounts for each of
+// the recent periods.
+func (h Handlers) Stats(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	durations := make([]time.Duration, len(statsPeriods))
+	for i, sp := range statsPeriods {
+		durations[i] = sp.duration
+	}
+
+	totals, periods := h.State.ChainStats(durations...)
+
+	resp := chainStats{
+		LatestBlock: h.State.LatestBlock().Header.Number,
+		Totals: statsTotals{
+			Blocks:       totals.Blocks,
+			Transactions: totals.Transactions,
+			Fees:         totals.Fees,
+			Burned:       totals.Burned,
+		},
+		Periods: make([]statsPeriod, len(periods)),
+	}
+
+	for i, p := range periods {
+		resp.Periods[i] = statsPeriod{
+			Period:          statsPeriods[i].name,
+			Blocks:          p.Blocks,
+			Transactions:    p.Transactions,
+			AvgBlockTimeMS:  p.AvgBlockTime.Milliseconds(),
+			TxPerSecond:     p.TxPerSecond,
+			Fees:            p.Fees,
+			AvgFeesPerBlock: p.AvgFeesPerBlock,
+			Burned:          p.Burned,
+			HashRate:        p.HashRate,
+			ActiveAccounts:  p.ActiveAccounts,
+		}
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
+// BlocksByAccount returns a page of blocks and their details. The blocks are
+// read by number inside the block and time range, so only the blocks needed
+// for the page are read from disk. With an account, the numbers of its
+// blocks come from the index of accounts kept by the node.
 func (h Handlers) BlocksByAccount(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	var accountID database.AccountID
 	accountStr := web.Param(r, "account")
@@ -212,64 +697,298 @@ func (h Handlers) BlocksByAccount(ctx context.Context, w http.ResponseWriter, r
 		}
 	}
 
-	dbBlocks, err := h.State.QueryBlocksByAccount(accountID)
+	q, err := paging.Parse(r, "number")
 	if err != nil {
 		return err
 	}
 
-	if len(dbBlocks) == 0 {
-		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	from, to, err := h.blockRange(q)
+	if err != nil {
+		return err
 	}
 
-	blocks := make([]block, len(dbBlocks))
-	for j, blk := range dbBlocks {
-		values := blk.MerkleTree.Values()
+	// The blocks to read are either every number in the range or the
+	// numbers of the account's blocks inside the range.
+	var total uint64
+	if from <= to {
+		total = to - from + 1
+	}
+	at := func(i uint64) uint64 {
+		if q.Desc {
+			return to - i
+		}
+		return from + i
+	}
 
-		trans := make([]tx, len(values))
-		for i, tran := range values {
-			rawProof, order, err := blk.MerkleTree.Proof(tran)
-			if err != nil {
-				return err
+	if accountID != "" {
+		numbers := h.State.QueryBlockNumbersByAccount(accountID, from, to)
+		total = uint64(len(numbers))
+		at = func(i uint64) uint64 {
+			if q.Desc {
+				return numbers[total-1-i]
 			}
-			proof := make([]string, len(rawProof))
-			for i, rp := range rawProof {
-				proof[i] = hexutil.Encode(rp)
+			return numbers[i]
+		}
+	}
+
+	resp := blockPage{
+		Blocks: []block{},
+	}
+
+	for i := uint64(0); i < total; i++ {
+		number := at(i)
+
+		dbBlocks := h.State.QueryBlocksByNumber(number, number)
+		if len(dbBlocks) == 0 {
+			break
+		}
+		blk := dbBlocks[0]
+
+		if len(resp.Blocks) == q.Limit {
+			last := resp.Blocks[len(resp.Blocks)-1].Number
+			resp.Next = paging.Token(strconv.FormatUint(last, 10))
+			break
+		}
+
+		b, err := h.toBlock(blk)
+		if err != nil {
+			return err
+		}
+		resp.Blocks = append(resp.Blocks, b)
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
+// Block returns the block with the specified number or hash. A hash is
+// found through the index of block hashes kept by the node.
+func (h Handlers) Block(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	numberOrHash := web.Param(r, "numberOrHash")
+
+	errFormat := v1.NewRequestError(errors.New("block must be a number or a 0x prefixed hash"), http.StatusBadRequest)
+
+	var blk database.Block
+	switch {
+	case strings.HasPrefix(numberOrHash, "0x"):
+		if b, err := hexutil.Decode(numberOrHash); err != nil || len(b) != 32 {
+			return errFormat
+		}
+
+		var err error
+		blk, err = h.State.QueryBlockByHash(numberOrHash)
+		if err != nil {
+			if errors.Is(err, state.ErrNotFound) {
+				return v1.NewRequestError(fmt.Errorf("block %s not found", numberOrHash), http.StatusNotFound)
 			}
+			return err
+		}
+
+	default:
+		number, err := strconv.ParseUint(numberOrHash, 10, 64)
+		if err != nil {
+			return errFormat
+		}
+
+		if number == 0 || number > h.State.LatestBlock().Header.Number {
+			return v1.NewRequestError(fmt.Errorf("block %d not found", number), http.StatusNotFound)
+		}
+
+		dbBlocks := h.State.QueryBlocksByNumber(number, number)
+		if len(dbBlocks) == 0 {
+			return fmt.Errorf("unable to read block %d", number)
+		}
+		blk = dbBlocks[0]
+	}
+
+	b, err := h.toBlock(blk)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, b, http.StatusOK)
+}
+
+// Genesis returns the genesis settings the node was started with along
+// with their hash, so nodes can check they are on the same chain.
+func (h Handlers) Genesis(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	gen := h.State.Genesis()
+
+	resp := genesisInfo{
+		Hash:    signature.Hash(gen),
+		Genesis: gen,
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
+// =============================================================================
+
+// sortPriority sorts the mempool in the order the transactions would be
+// mined.
+const sortPriority = "priority"
+
+// sortTxs sorts the transactions by the field. The priority order is the
+// order the mempool returned them in.
+func sortTxs(trans []database.BlockTx, field string, desc bool) {
+	less := func(i, j int) bool {
+		switch field {
+		case "timestamp":
+			return trans[i].TimeStamp < trans[j].TimeStamp
+		case "value":
+			return trans[i].Value < trans[j].Value
+		case "tip":
+			return trans[i].Tip < trans[j].Tip
+		}
+		return i < j
+	}
+
+	switch {
+	case field == sortPriority && desc:
+		for i, j := 0, len(trans)-1; i < j; i, j = i+1, j-1 {
+			trans[i], trans[j] = trans[j], trans[i]
+		}
 
-			trans[i] = tx{
-				FromAccount: tran.FromID,
-				FromName:    h.NS.Lookup(tran.FromID),
-				To:          tran.ToID,
-				ToName:      h.NS.Lookup(tran.ToID),
-				ChainID:     tran.ChainID,
-				Nonce:       tran.Nonce,
-				Value:       tran.Value,
-				Tip:         tran.Tip,
-				Data:        tran.Data,
-				TimeStamp:   tran.TimeStamp,
-				GasPrice:    tran.GasPrice,
-				GasUnits:    tran.GasUnits,
-				Sig:         tran.SignatureString(),
-				Proof:       proof,
-				ProofOrder:  order,
+	case field != sortPriority:
+		sort.SliceStable(trans, func(i, j int) bool {
+			if desc {
+				return less(j, i)
 			}
+			return less(i, j)
+		})
+	}
+}
+
+// sortAccounts sorts the accounts by the field. Accounts with the same value
+// are ordered by account so the pages are stable.
+func sortAccounts(accounts []act, field string, desc bool) {
+	sort.Slice(accounts, func(i, j int) bool {
+		if desc {
+			i, j = j, i
+		}
+
+		switch {
+		case field == "balance" && accounts[i].Balance != accounts[j].Balance:
+			return accounts[i].Balance < accounts[j].Balance
+		case field == "nonce" && accounts[i].Nonce != accounts[j].Nonce:
+			return accounts[i].Nonce < accounts[j].Nonce
+		}
+		return accounts[i].Account < accounts[j].Account
+	})
+}
+
+// blockRange converts the block and time range along with the position of
+// the previous page into the range of block numbers to read.
+func (h Handlers) blockRange(q paging.Query) (uint64, uint64, error) {
+	from := q.FromBlock
+	if from == 0 {
+		from = 1
+	}
+
+	to := h.State.LatestBlock().Header.Number
+	if q.ToBlock < to {
+		to = q.ToBlock
+	}
+
+	if q.FromTime > 0 {
+		number, err := h.State.QueryBlockNumberByTime(q.FromTime)
+		if err != nil {
+			return 0, 0, err
+		}
+		if number > from {
+			from = number
+		}
+	}
+
+	if q.ToTime < ^uint64(0) {
+		number, err := h.State.QueryBlockNumberByTime(q.ToTime + 1)
+		if err != nil {
+			return 0, 0, err
 		}
+		if number-1 < to {
+			to = number - 1
+		}
+	}
+
+	after, ok, err := q.PositionUint()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case ok && q.Desc:
+		if after == 0 {
+			return from, 0, nil
+		}
+		if after <= to {
+			to = after - 1
+		}
+	case ok:
+		if after >= from {
+			from = after + 1
+		}
+	}
 
-		b := block{
-			Number:        blk.Header.Number,
-			PrevBlockHash: blk.Header.PrevBlockHash,
-			TimeStamp:     blk.Header.TimeStamp,
-			BeneficiaryID: blk.Header.BeneficiaryID,
-			Difficulty:    blk.Header.Difficulty,
-			MiningReward:  blk.Header.MiningReward,
-			Nonce:         blk.Header.Nonce,
-			StateRoot:     blk.Header.StateRoot,
-			TransRoot:     blk.Header.TransRoot,
-			Transactions:  trans,
+	return from, to, nil
+}
+
+// toBlock converts a block to the model sent to the client. Each transaction
+// carries its merkle proof so the client can verify it.
+func (h Handlers) toBlock(blk database.Block) (block, error) {
+	values := blk.MerkleTree.Values()
+
+	trans := make([]tx, len(values))
+	for i, tran := range values {
+		rawProof, order, err := blk.MerkleTree.Proof(tran)
+		if err != nil {
+			return block{}, err
+		}
+		proof := make([]string, len(rawProof))
+		for i, rp := range rawProof {
+			proof[i] = hexutil.Encode(rp)
 		}
 
-		blocks[j] = b
+		trans[i] = h.mempoolTx(tran)
+		trans[i].Proof = proof
+		trans[i].ProofOrder = order
 	}
 
-	return web.Respond(ctx, w, blocks, http.StatusOK)
+	b := block{
+		Number:        blk.Header.Number,
+		Hash:          blk.Hash(),
+		PrevBlockHash: blk.Header.PrevBlockHash,
+		TimeStamp:     blk.Header.TimeStamp,
+		BeneficiaryID: blk.Header.BeneficiaryID,
+		Difficulty:    blk.Header.Difficulty,
+		MiningReward:  blk.Header.MiningReward,
+		BaseFee:       blk.Header.BaseFee,
+		Nonce:         blk.Header.Nonce,
+		StateRoot:     blk.Header.StateRoot,
+		TransRoot:     blk.Header.TransRoot,
+		Transactions:  trans,
+	}
+
+	return b, nil
+}
+
+// mempoolTx converts a transaction in the mempool to the model sent to
+// the client.
+func (h Handlers) mempoolTx(tran database.BlockTx) tx {
+	return tx{
+		FromAccount: tran.FromID,
+		FromName:    h.NS.Lookup(tran.FromID),
+		To:          tran.ToID,
+		ToName:      h.NS.Lookup(tran.ToID),
+		ChainID:     tran.ChainID,
+		Nonce:       tran.Nonce,
+		Value:       tran.Value,
+		Tip:         tran.Tip,
+		Data:        tran.Data,
+		GasLimit:    tran.GasLimit,
+		MaxGasPrice: tran.MaxGasPrice,
+		ValidUntil:  tran.ValidUntil,
+		TimeStamp:   tran.TimeStamp,
+		GasPrice:    tran.GasPrice,
+		GasUnits:    tran.GasUnits,
+		Sig:         tran.SignatureString(),
+	}
 }
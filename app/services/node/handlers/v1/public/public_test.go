@@ -0,0 +1,418 @@
+package public_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/websocket"
+	"github.com/wtran29/go-blockchain/app/services/node/handlers"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/genesis"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/storage/memory"
+	"github.com/wtran29/go-blockchain/foundation/events"
+	"github.com/wtran29/go-blockchain/foundation/nameservice"
+	"go.uber.org/zap"
+)
+
+// beneficiaryID is the account that receives the transfers and the rewards
+// for the blocks mined in the tests.
+const beneficiaryID = database.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+
+// worker implements the state.Worker interface without doing any work.
+type worker struct{}
+
+func (worker) Shutdown()                              {}
+func (worker) Sync()                                  {}
+func (worker) SignalStartMining()                     {}
+func (worker) SignalCancelMining()                    {}
+func (worker) SignalShareTx(blockTx database.BlockTx) {}
+
+func Test_MempoolEvents(t *testing.T) {
+	srv, st, keys := newServer(t)
+
+	// Only the changes for the first account are wanted.
+	accountID := database.PublicKeyToAccountID(keys[0].PublicKey)
+
+	c, _, err := websocket.DefaultDialer.Dial(wsURL(srv, "/v1/tx/uncommitted/events/"+string(accountID)), nil)
+	if err != nil {
+		t.Fatalf("Should be able to connect to the websocket: %s", err)
+	}
+	defer c.Close()
+
+	submit(t, st, keys[1], 1)
+	submit(t, st, keys[0], 1)
+
+	var msg struct {
+		Type string `json:"type"`
+		Tx   struct {
+			From  database.AccountID `json:"from"`
+			Nonce uint64             `json:"nonce"`
+		} `json:"tx"`
+	}
+
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := c.ReadJSON(&msg); err != nil {
+		t.Fatalf("Should be able to read the event: %s", err)
+	}
+
+	if msg.Type != "add" || msg.Tx.From != accountID || msg.Tx.Nonce != 1 {
+		t.Fatalf("Should only receive the event for %s, got %+v", accountID, msg)
+	}
+}
+
+func Test_Origin(t *testing.T) {
+	srv, _, _ := newServer(t)
+
+	tt := []struct {
+		name   string
+		origin string
+		fail   bool
+	}{
+		{"no-origin", "", false},
+		{"allowed", "http://wallet.example", false},
+		{"allowed-case", "HTTP://Wallet.Example", false},
+		{"not-allowed", "http://evil.example", true},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			header := http.Header{}
+			if tst.origin != "" {
+				header.Set("Origin", tst.origin)
+			}
+
+			c, resp, err := websocket.DefaultDialer.Dial(wsURL(srv, "/v1/events"), header)
+			if tst.fail {
+				if err == nil {
+					c.Close()
+					t.Fatalf("Should not accept the origin %q", tst.origin)
+				}
+				if resp == nil || resp.StatusCode != http.StatusForbidden {
+					t.Fatalf("Should get a %d status for the origin %q, got %v", http.StatusForbidden, tst.origin, resp)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Should accept the origin %q: %s", tst.origin, err)
+			}
+			c.Close()
+		})
+	}
+}
+
+func Test_EventStream(t *testing.T) {
+	srv, st, keys := newServer(t)
+
+	// Only the added transactions are wanted, not the mined block.
+	const path = "/v1/events/stream?topics=tx.added"
+	r := stream(t, srv, path, "", http.StatusOK)
+
+	submit(t, st, keys[0], 1)
+	if _, err := st.MineNewBlock(context.Background()); err != nil {
+		t.Fatalf("Should be able to mine a block: %s", err)
+	}
+	submit(t, st, keys[1], 1)
+
+	first := readEvent(t, r)
+	second := readEvent(t, r)
+
+	for i, ev := range []sseEvent{first, second} {
+		exp := database.PublicKeyToAccountID(keys[i].PublicKey)
+		if ev.topic != state.TopicTxAdded || ev.from != exp {
+			t.Fatalf("Should receive the tx.added event from %s at %d, got %+v", exp, i, ev)
+		}
+	}
+
+	// Resuming after the first event replays the second.
+	r = stream(t, srv, path, first.id, http.StatusOK)
+	if ev := readEvent(t, r); ev.id != second.id {
+		t.Fatalf("Should resume with the event %s, got %+v", second.id, ev)
+	}
+
+	// An id that can't be parsed is a bad request and an id from another run
+	// of the node can't be resumed from.
+	stream(t, srv, path, "10", http.StatusBadRequest)
+	stream(t, srv, path, "expired-1", http.StatusGone)
+}
+
+func Test_Block(t *testing.T) {
+	srv, st, keys := newServer(t)
+
+	for i, key := range keys {
+		submit(t, st, key, 1)
+		if _, err := st.MineNewBlock(context.Background()); err != nil {
+			t.Fatalf("Should be able to mine block %d: %s", i+1, err)
+		}
+	}
+	hash := st.LatestBlock().Hash()
+
+	tt := []struct {
+		name   string
+		param  string
+		status int
+		exp    uint64
+	}{
+		{"number", "1", http.StatusOK, 1},
+		{"latest", "2", http.StatusOK, 2},
+		{"hash", hash, http.StatusOK, 2},
+		{"hash-case", "0x" + strings.ToUpper(hash[2:]), http.StatusOK, 2},
+		{"number-zero", "0", http.StatusNotFound, 0},
+		{"number-unknown", "3", http.StatusNotFound, 0},
+		{"hash-unknown", "0x" + strings.Repeat("11", 32), http.StatusNotFound, 0},
+		{"number-malformed", "abc", http.StatusBadRequest, 0},
+		{"number-negative", "-1", http.StatusBadRequest, 0},
+		{"hash-short", "0x1234", http.StatusBadRequest, 0},
+		{"hash-not-hex", "0x" + strings.Repeat("zz", 32), http.StatusBadRequest, 0},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			var blk struct {
+				Number uint64 `json:"number"`
+				Hash   string `json:"hash"`
+				Txs    []struct {
+					From database.AccountID `json:"from"`
+				} `json:"txs"`
+			}
+			get(t, srv, "/v1/blocks/"+tst.param, tst.status, &blk)
+
+			if tst.status != http.StatusOK {
+				return
+			}
+
+			exp := database.PublicKeyToAccountID(keys[tst.exp-1].PublicKey)
+			if blk.Number != tst.exp || len(blk.Txs) != 1 || blk.Txs[0].From != exp {
+				t.Fatalf("Should get block %d with the transaction from %s, got %+v", tst.exp, exp, blk)
+			}
+			if tst.exp == 2 && blk.Hash != hash {
+				t.Fatalf("Should get the block with the hash %s, got %s", hash, blk.Hash)
+			}
+		})
+	}
+}
+
+func Test_Genesis(t *testing.T) {
+	srv, st, keys := newServer(t)
+
+	var resp struct {
+		Hash    string          `json:"hash"`
+		Genesis genesis.Genesis `json:"genesis"`
+	}
+	get(t, srv, "/v1/genesis", http.StatusOK, &resp)
+
+	if exp := signature.Hash(st.Genesis()); resp.Hash != exp {
+		t.Fatalf("Should get the hash of the genesis %s, got %s", exp, resp.Hash)
+	}
+	if resp.Genesis.ChainID != 1 || len(resp.Genesis.Balances) != len(keys) {
+		t.Fatalf("Should get the genesis the node was started with, got %+v", resp.Genesis)
+	}
+}
+
+// =============================================================================
+
+// newServer starts the public routes for a node on a PoA chain that funds
+// the accounts for the returned keys.
+func newServer(t *testing.T) (*httptest.Server, *state.State, []*ecdsa.PrivateKey) {
+	t.Helper()
+
+	keys := make([]*ecdsa.PrivateKey, 2)
+	balances := make(map[string]uint64, len(keys))
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("Should be able to generate a private key: %s", err)
+		}
+		keys[i] = key
+		balances[string(database.PublicKeyToAccountID(key.PublicKey))] = 1_000_000
+	}
+
+	storage, err := memory.New()
+	if err != nil {
+		t.Fatalf("Should be able to construct the storage: %s", err)
+	}
+
+	evts := events.New(state.TopicMiningProgress)
+
+	st, err := state.New(state.Config{
+		BeneficiaryID: beneficiaryID,
+		Host:          "localhost:9080",
+		Storage:       storage,
+		Genesis: genesis.Genesis{
+			Date:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			ChainID:       1,
+			TransPerBlock: 1,
+			Difficulty:    1,
+			MiningReward:  700,
+			GasPrice:      15,
+			BaseFee:       5,
+			Balances:      balances,
+			Gas:           genesis.GasSchedule{TxBase: 21, Transfer: 9},
+		},
+		SelectStrategy: selector.StrategyFeeDensity,
+		Publisher:      evts.Send,
+		Consensus:      state.ConsensusPOA,
+		MiningWorkers:  1,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the state: %s", err)
+	}
+	st.Worker = worker{}
+
+	ns, err := nameservice.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("Should be able to construct the name service: %s", err)
+	}
+
+	srv := httptest.NewServer(handlers.PublicMux(handlers.MuxConfig{
+		Shutdown: make(chan os.Signal, 1),
+		Log:      zap.NewNop().Sugar(),
+		State:    st,
+		NS:       ns,
+		Evts:     evts,
+		Origins:  []string{"http://wallet.example"},
+	}))
+	t.Cleanup(func() {
+		srv.Close()
+		evts.Shutdown()
+	})
+
+	return srv, st, keys
+}
+
+// submit sends a transfer from the account to the beneficiary.
+func submit(t *testing.T, st *state.State, key *ecdsa.PrivateKey, nonce uint64) database.BlockTx {
+	t.Helper()
+
+	tx, err := database.NewTx(1, nonce, database.PublicKeyToAccountID(key.PublicKey), beneficiaryID, 10, 0, nil, 30, 20)
+	if err != nil {
+		t.Fatalf("Should be able to construct the transaction: %s", err)
+	}
+
+	signedTx, err := tx.Sign(key)
+	if err != nil {
+		t.Fatalf("Should be able to sign the transaction: %s", err)
+	}
+
+	blockTx, err := st.UpsertWalletTransaction(signedTx)
+	if err != nil {
+		t.Fatalf("Should be able to submit the transaction: %s", err)
+	}
+
+	return blockTx
+}
+
+// get requests the path and checks the status of the response. The body of
+// a successful response is decoded into the value.
+func get(t *testing.T, srv *httptest.Server, path string, status int, v any) {
+	t.Helper()
+
+	resp, err := http.Get(srv.URL + path)
+	if err != nil {
+		t.Fatalf("Should be able to request %s: %s", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != status {
+		t.Fatalf("Should get a %d status for %s, got %d", status, path, resp.StatusCode)
+	}
+
+	if status != http.StatusOK {
+		return
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("Should be able to decode the response: %s", err)
+	}
+}
+
+// wsURL returns the websocket url for the path on the server.
+func wsURL(srv *httptest.Server, path string) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + path
+}
+
+// sseEvent represents the parts of a server-sent event the tests check.
+type sseEvent struct {
+	id    string
+	topic string
+	from  database.AccountID
+}
+
+// stream opens the event stream at the path, resuming after the event with
+// the last id when one is provided, and checks the status of the response.
+func stream(t *testing.T, srv *httptest.Server, path string, lastID string, status int) *bufio.Reader {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+	if err != nil {
+		t.Fatalf("Should be able to construct the request: %s", err)
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Should be able to open the stream: %s", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	if resp.StatusCode != status {
+		t.Fatalf("Should get a %d status, got %d", status, resp.StatusCode)
+	}
+
+	return bufio.NewReader(resp.Body)
+}
+
+// readEvent reads the next event from the stream, skipping any comments.
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Should be able to read the stream: %s", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "" && ev.topic != "":
+			return ev
+
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+
+		case strings.HasPrefix(line, "event: "):
+			ev.topic = strings.TrimPrefix(line, "event: ")
+
+		case strings.HasPrefix(line, "data: "):
+			var data struct {
+				Payload struct {
+					Tx struct {
+						From database.AccountID `json:"from"`
+					} `json:"tx"`
+				} `json:"payload"`
+			}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data); err != nil {
+				t.Fatalf("Should be able to decode the event: %s", err)
+			}
+			ev.from = data.Payload.Tx.From
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+)
+
+// methods maps the name of a JSON-RPC method to its implementation.
+var methods = map[string]func(h Handlers, params json.RawMessage) (any, error){
+	"eth_chainId":               chainID,
+	"eth_blockNumber":           blockNumber,
+	"eth_getBalance":            getBalance,
+	"eth_getTransactionCount":   getTransactionCount,
+	"eth_getBlockByNumber":      getBlockByNumber,
+	"eth_getBlockByHash":        getBlockByHash,
+	"eth_getTransactionByHash":  getTransactionByHash,
+	"eth_getTransactionReceipt": getTransactionReceipt,
+	"eth_sendRawTransaction":    sendRawTransaction,
+}
+
+// chainID returns the chain id from the genesis file.
+func chainID(h Handlers, params json.RawMessage) (any, error) {
+	return hexutil.EncodeUint64(uint64(h.State.Genesis().ChainID)), nil
+}
+
+// blockNumber returns the number of the latest block.
+func blockNumber(h Handlers, params json.RawMessage) (any, error) {
+	return hexutil.EncodeUint64(h.State.LatestBlock().Header.Number), nil
+}
+
+// getBalance returns the balance of the account. An unknown account has a
+// zero balance.
+func getBalance(h Handlers, params json.RawMessage) (any, error) {
+	var address, tag string
+	if err := decodeParams(params, 1, &address, &tag); err != nil {
+		return nil, err
+	}
+
+	accountID, err := toAccountID(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := latestOnly(h, tag); err != nil {
+		return nil, err
+	}
+
+	account, err := h.State.QueryAccount(accountID)
+	if err != nil {
+		return "0x0", nil
+	}
+
+	return hexutil.EncodeUint64(account.Balance), nil
+}
+
+// getTransactionCount returns the nonce the account's next transaction needs
+// to use, which is what Ethereum tools expect. Nonces on this chain start at
+// 1, so this is one more than the number of transactions the account has
+// sent. For the pending tag, the transactions in the mempool that can be
+// mined next are included.
+func getTransactionCount(h Handlers, params json.RawMessage) (any, error) {
+	var address, tag string
+	if err := decodeParams(params, 1, &address, &tag); err != nil {
+		return nil, err
+	}
+
+	accountID, err := toAccountID(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := latestOnly(h, tag); err != nil {
+		return nil, err
+	}
+
+	if tag == "pending" {
+		return hexutil.EncodeUint64(h.State.MempoolNextNonce(accountID)), nil
+	}
+
+	var nonce uint64
+	if account, err := h.State.QueryAccount(accountID); err == nil {
+		nonce = account.Nonce
+	}
+
+	return hexutil.EncodeUint64(nonce + 1), nil
+}
+
+// getBlockByNumber returns the block with the number or tag. A block that
+// doesn't exist returns null.
+func getBlockByNumber(h Handlers, params json.RawMessage) (any, error) {
+	var tag string
+	var fullTx bool
+	if err := decodeParams(params, 1, &tag, &fullTx); err != nil {
+		return nil, err
+	}
+
+	number, err := toBlockNumber(h, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	// Block 0 is the genesis and is not stored as a block.
+	if number == 0 || number > h.State.LatestBlock().Header.Number {
+		return nil, nil
+	}
+
+	blocks := h.State.QueryBlocksByNumber(number, number)
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+
+	return toRPCBlock(blocks[0], h.State.Genesis().MaxBlockGas, fullTx), nil
+}
+
+// getBlockByHash returns the block with the hash. A block that doesn't exist
+// returns null.
+func getBlockByHash(h Handlers, params json.RawMessage) (any, error) {
+	var hash string
+	var fullTx bool
+	if err := decodeParams(params, 1, &hash, &fullTx); err != nil {
+		return nil, err
+	}
+
+	block, err := h.State.QueryBlockByHash(strings.ToLower(hash))
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toRPCBlock(block, h.State.Genesis().MaxBlockGas, fullTx), nil
+}
+
+// getTransactionByHash returns the transaction with the hash, whether it's
+// mined or still in the mempool. A transaction that doesn't exist returns
+// null.
+func getTransactionByHash(h Handlers, params json.RawMessage) (any, error) {
+	var hash string
+	if err := decodeParams(params, 1, &hash); err != nil {
+		return nil, err
+	}
+
+	tx, block, mined, err := h.State.QueryTransactionByHash(strings.ToLower(hash))
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !mined {
+		return toRPCTx(tx, nil, "", 0), nil
+	}
+
+	blockHash := block.Hash()
+	for i, btx := range block.MerkleTree.Values() {
+		if btx.Equals(tx) {
+			return toRPCTx(tx, &block, blockHash, i), nil
+		}
+	}
+
+	return toRPCTx(tx, &block, blockHash, 0), nil
+}
+
+// getTransactionReceipt returns the receipt for a mined transaction. A
+// transaction that isn't mined yet returns null.
+func getTransactionReceipt(h Handlers, params json.RawMessage) (any, error) {
+	var hash string
+	if err := decodeParams(params, 1, &hash); err != nil {
+		return nil, err
+	}
+
+	tx, block, mined, err := h.State.QueryTransactionByHash(strings.ToLower(hash))
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !mined {
+		return nil, nil
+	}
+
+	return toRPCReceipt(tx, block), nil
+}
+
+// sendRawTransaction submits a signed transaction to the mempool and returns
+// its hash. This blockchain doesn't use RLP, so the raw transaction is the
+// hex encoded JSON of a signed transaction and wallets that sign RLP can't
+// use this method.
+func sendRawTransaction(h Handlers, params json.RawMessage) (any, error) {
+	var raw string
+	if err := decodeParams(params, 1, &raw); err != nil {
+		return nil, err
+	}
+
+	data, err := hexutil.Decode(raw)
+	if err != nil {
+		return nil, newError(CodeInvalidParams, "invalid raw transaction: %s", err)
+	}
+
+	var signedTx database.SignedTx
+	if err := json.Unmarshal(data, &signedTx); err != nil {
+		return nil, newError(CodeInvalidParams, "invalid raw transaction: %s", err)
+	}
+
+	// A rejected transaction carries its reason. Anything else went wrong
+	// checking the transaction against the chain, which is still about the
+	// input and not a fault of the node.
+	tx, err := h.State.UpsertWalletTransaction(signedTx)
+	if err != nil {
+		if mempool.IsRejectError(err) {
+			return nil, err
+		}
+		return nil, newError(CodeInvalidInput, "transaction not accepted: %s", err)
+	}
+
+	return signature.Hash(tx), nil
+}
+
+// =============================================================================
+
+// decodeParams decodes the positional params into the args. The first
+// required args must be present and the rest are optional.
+func decodeParams(params json.RawMessage, required int, args ...any) error {
+	var list []json.RawMessage
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &list); err != nil {
+			return newError(CodeInvalidParams, "params must be an array")
+		}
+	}
+
+	if len(list) < required || len(list) > len(args) {
+		return newError(CodeInvalidParams, "expected %d to %d params, got %d", required, len(args), len(list))
+	}
+
+	for i, raw := range list {
+		if err := json.Unmarshal(raw, args[i]); err != nil {
+			return newError(CodeInvalidParams, "invalid param %d: %s", i, err)
+		}
+	}
+
+	return nil
+}
+
+// toAccountID validates the address and converts it to the checksummed
+// account id the database uses.
+func toAccountID(address string) (database.AccountID, error) {
+	if !database.AccountID(address).IsAccountID() {
+		return "", newError(CodeInvalidParams, "invalid address %q", address)
+	}
+
+	return database.AccountID(common.HexToAddress(address).Hex()), nil
+}
+
+// toBlockNumber converts a block tag or hex number into a block number. There
+// is no finality, so the safe and finalized tags are the latest block.
+func toBlockNumber(h Handlers, tag string) (uint64, error) {
+	switch tag {
+	case "", "latest", "pending", "safe", "finalized":
+		return h.State.LatestBlock().Header.Number, nil
+
+	case "earliest":
+		return 0, nil
+	}
+
+	number, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return 0, newError(CodeInvalidParams, "invalid block number %q", tag)
+	}
+
+	return number, nil
+}
+
+// latestOnly makes sure the tag refers to the latest state since the node
+// doesn't keep the account state for older blocks.
+func latestOnly(h Handlers, tag string) error {
+	number, err := toBlockNumber(h, tag)
+	if err != nil {
+		return err
+	}
+
+	if number != h.State.LatestBlock().Header.Number {
+		return newError(CodeInvalidInput, "historical state is not available")
+	}
+
+	return nil
+}
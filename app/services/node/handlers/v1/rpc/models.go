@@ -0,0 +1,188 @@
+package rpc
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
+)
+
+// emptyBloom is the logs bloom for a block or receipt without any logs. This
+// blockchain doesn't produce logs, but tools expect the field.
+var emptyBloom = hexutil.Encode(make([]byte, 256))
+
+// rpcBlock represents a block in the form Ethereum tools expect. The
+// transactions are either the hashes or the full transactions.
+type rpcBlock struct {
+	Number           string `json:"number"`
+	Hash             string `json:"hash"`
+	ParentHash       string `json:"parentHash"`
+	Nonce            string `json:"nonce"`
+	Miner            string `json:"miner"`
+	Difficulty       string `json:"difficulty"`
+	Timestamp        string `json:"timestamp"`
+	StateRoot        string `json:"stateRoot"`
+	TransactionsRoot string `json:"transactionsRoot"`
+	BaseFeePerGas    string `json:"baseFeePerGas"`
+	GasLimit         string `json:"gasLimit"`
+	GasUsed          string `json:"gasUsed"`
+	Size             string `json:"size"`
+	LogsBloom        string `json:"logsBloom"`
+	Transactions     []any  `json:"transactions"`
+}
+
+// toRPCBlock converts a block into the form Ethereum tools expect.
+func toRPCBlock(block database.Block, gasLimit uint64, fullTx bool) rpcBlock {
+	hash := block.Hash()
+	trans := block.MerkleTree.Values()
+
+	list := make([]any, len(trans))
+	for i, tx := range trans {
+		if fullTx {
+			list[i] = toRPCTx(tx, &block, hash, i)
+			continue
+		}
+		list[i] = signature.Hash(tx)
+	}
+
+	return rpcBlock{
+		Number:           hexutil.EncodeUint64(block.Header.Number),
+		Hash:             hash,
+		ParentHash:       with0x(block.Header.PrevBlockHash),
+		Nonce:            hexutil.EncodeUint64(block.Header.Nonce),
+		Miner:            string(block.Header.BeneficiaryID),
+		Difficulty:       hexutil.EncodeUint64(block.Header.Difficulty),
+		Timestamp:        hexutil.EncodeUint64(block.Header.TimeStamp / 1000),
+		StateRoot:        with0x(block.Header.StateRoot),
+		TransactionsRoot: with0x(block.Header.TransRoot),
+		BaseFeePerGas:    hexutil.EncodeUint64(block.Header.BaseFee),
+		GasLimit:         hexutil.EncodeUint64(gasLimit),
+		GasUsed:          hexutil.EncodeUint64(block.GasUsed()),
+		Size:             hexutil.EncodeUint64(block.Size()),
+		LogsBloom:        emptyBloom,
+		Transactions:     list,
+	}
+}
+
+// rpcTx represents a transaction in the form Ethereum tools expect. The block
+// fields are null while the transaction is in the mempool.
+type rpcTx struct {
+	Hash             string  `json:"hash"`
+	Nonce            string  `json:"nonce"`
+	From             string  `json:"from"`
+	To               string  `json:"to"`
+	Value            string  `json:"value"`
+	Gas              string  `json:"gas"`
+	GasPrice         string  `json:"gasPrice"`
+	MaxFeePerGas     string  `json:"maxFeePerGas"`
+	Input            string  `json:"input"`
+	BlockHash        *string `json:"blockHash"`
+	BlockNumber      *string `json:"blockNumber"`
+	TransactionIndex *string `json:"transactionIndex"`
+	ChainID          string  `json:"chainId"`
+	Type             string  `json:"type"`
+	V                string  `json:"v"`
+	R                string  `json:"r"`
+	S                string  `json:"s"`
+}
+
+// toRPCTx converts a transaction into the form Ethereum tools expect. A nil
+// block means the transaction has not been mined.
+func toRPCTx(tx database.BlockTx, block *database.Block, blockHash string, index int) rpcTx {
+	rtx := rpcTx{
+		Hash:         signature.Hash(tx),
+		Nonce:        hexutil.EncodeUint64(tx.Nonce),
+		From:         string(tx.FromID),
+		To:           string(tx.ToID),
+		Value:        hexutil.EncodeUint64(tx.Value),
+		Gas:          hexutil.EncodeUint64(tx.GasLimit),
+		GasPrice:     hexutil.EncodeUint64(tx.GasPrice),
+		MaxFeePerGas: hexutil.EncodeUint64(tx.MaxGasPrice),
+		Input:        hexutil.Encode(tx.Data),
+		ChainID:      hexutil.EncodeUint64(uint64(tx.ChainID)),
+		Type:         "0x0",
+	}
+
+	if tx.V != nil {
+		rtx.V = hexutil.EncodeBig(tx.V)
+	}
+	if tx.R != nil {
+		rtx.R = hexutil.EncodeBig(tx.R)
+	}
+	if tx.S != nil {
+		rtx.S = hexutil.EncodeBig(tx.S)
+	}
+
+	if block != nil {
+		number := hexutil.EncodeUint64(block.Header.Number)
+		idx := hexutil.EncodeUint64(uint64(index))
+
+		rtx.BlockHash = &blockHash
+		rtx.BlockNumber = &number
+		rtx.TransactionIndex = &idx
+
+		// The price paid per unit of gas includes the base fee.
+		rtx.GasPrice = hexutil.EncodeUint64(tx.GasPrice + block.Header.BaseFee)
+	}
+
+	return rtx
+}
+
+// rpcReceipt represents the outcome of a mined transaction in the form
+// Ethereum tools expect.
+type rpcReceipt struct {
+	TransactionHash   string  `json:"transactionHash"`
+	TransactionIndex  string  `json:"transactionIndex"`
+	BlockHash         string  `json:"blockHash"`
+	BlockNumber       string  `json:"blockNumber"`
+	From              string  `json:"from"`
+	To                string  `json:"to"`
+	CumulativeGasUsed string  `json:"cumulativeGasUsed"`
+	GasUsed           string  `json:"gasUsed"`
+	EffectiveGasPrice string  `json:"effectiveGasPrice"`
+	ContractAddress   *string `json:"contractAddress"`
+	Logs              []any   `json:"logs"`
+	LogsBloom         string  `json:"logsBloom"`
+	Type              string  `json:"type"`
+	Status            string  `json:"status"`
+}
+
+// toRPCReceipt constructs the receipt for the transaction in the block.
+func toRPCReceipt(tx database.BlockTx, block database.Block) rpcReceipt {
+	hash := signature.Hash(tx)
+
+	var index int
+	var cumulative uint64
+	for i, btx := range block.MerkleTree.Values() {
+		cumulative += btx.GasUnits
+		if signature.Hash(btx) == hash {
+			index = i
+			break
+		}
+	}
+
+	return rpcReceipt{
+		TransactionHash:   hash,
+		TransactionIndex:  hexutil.EncodeUint64(uint64(index)),
+		BlockHash:         block.Hash(),
+		BlockNumber:       hexutil.EncodeUint64(block.Header.Number),
+		From:              string(tx.FromID),
+		To:                string(tx.ToID),
+		CumulativeGasUsed: hexutil.EncodeUint64(cumulative),
+		GasUsed:           hexutil.EncodeUint64(tx.GasUnits),
+		EffectiveGasPrice: hexutil.EncodeUint64(tx.GasPrice + block.Header.BaseFee),
+		Logs:              []any{},
+		LogsBloom:         emptyBloom,
+		Type:              "0x0",
+		Status:            "0x1",
+	}
+}
+
+// with0x makes sure the hex string has a 0x prefix.
+func with0x(s string) string {
+	if strings.HasPrefix(s, "0x") {
+		return s
+	}
+	return "0x" + s
+}
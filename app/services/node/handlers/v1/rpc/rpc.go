@@ -0,0 +1,223 @@
+// Package rpc maintains the Ethereum compatible JSON-RPC 2.0 handler so
+// existing Ethereum tools can talk to the node.
+//
+// The methods follow the Ethereum JSON-RPC specification with two
+// differences. Nonces start at 1, and eth_getTransactionCount returns the
+// nonce to sign the next transaction with. Transactions are not RLP encoded,
+// so eth_sendRawTransaction takes the hex encoded JSON of a signed
+// transaction and standard wallets can't submit transactions with it.
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+	"github.com/wtran29/go-blockchain/foundation/web"
+	"go.uber.org/zap"
+)
+
+// CORE NOTE: JSON-RPC 2.0 wraps every call in an envelope with an id the
+// response echoes back. A batch is an array of these envelopes and gets an
+// array of responses. A request without an id is a notification and gets no
+// response at all. Errors use the codes from the JSON-RPC specification and
+// EIP-1474 so tools can tell a bad request from a rejected transaction.
+
+// Set of error codes defined by JSON-RPC 2.0 and EIP-1474.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeInvalidInput   = -32000
+	CodeTxRejected     = -32003
+	CodeLimitExceeded  = -32005
+)
+
+// Set of limits on what a client can send.
+const (
+	jsonrpcVersion       = "2.0"
+	maxBatchSize         = 100
+	maxRequestBodyLength = 5 * 1024 * 1024
+)
+
+// Error represents a JSON-RPC error. Data carries the reason a transaction
+// was rejected so tools can act on it.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// newError constructs a JSON-RPC error with the code and message.
+func newError(code int, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// =============================================================================
+
+// request represents a single JSON-RPC call.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// response represents the reply to a single JSON-RPC call. Only one of
+// result or error is written.
+type response struct {
+	ID     json.RawMessage
+	Result any
+	Error  *Error
+}
+
+// MarshalJSON implements the json.Marshaler interface so a successful
+// response always has a result, even when it's null, and a failed one never
+// does.
+func (r response) MarshalJSON() ([]byte, error) {
+	id := r.ID
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+
+	if r.Error != nil {
+		return json.Marshal(struct {
+			JSONRPC string          `json:"jsonrpc"`
+			ID      json.RawMessage `json:"id"`
+			Error   *Error          `json:"error"`
+		}{jsonrpcVersion, id, r.Error})
+	}
+
+	return json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  any             `json:"result"`
+	}{jsonrpcVersion, id, r.Result})
+}
+
+// =============================================================================
+
+// Handlers manages the JSON-RPC endpoint.
+type Handlers struct {
+	Log   *zap.SugaredLogger
+	State *state.State
+}
+
+// RPC handles a single JSON-RPC request or a batch of requests.
+func (h Handlers) RPC(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodyLength))
+	if err != nil {
+		return fmt.Errorf("unable to read payload: %w", err)
+	}
+	body = bytes.TrimSpace(body)
+
+	// A single request is answered with a single response.
+	if len(body) == 0 || body[0] != '[' {
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if json.Valid(body) {
+				return web.Respond(ctx, w, response{Error: newError(CodeInvalidRequest, "invalid request")}, http.StatusOK)
+			}
+			return web.Respond(ctx, w, response{Error: newError(CodeParseError, "parse error")}, http.StatusOK)
+		}
+
+		resp, ok := h.call(req)
+		if !ok {
+			return web.Respond(ctx, w, nil, http.StatusNoContent)
+		}
+		return web.Respond(ctx, w, resp, http.StatusOK)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return web.Respond(ctx, w, response{Error: newError(CodeParseError, "parse error")}, http.StatusOK)
+	}
+
+	switch {
+	case len(batch) == 0:
+		return web.Respond(ctx, w, response{Error: newError(CodeInvalidRequest, "empty batch")}, http.StatusOK)
+
+	case len(batch) > maxBatchSize:
+		return web.Respond(ctx, w, response{Error: newError(CodeLimitExceeded, "batch of %d exceeds the limit of %d", len(batch), maxBatchSize)}, http.StatusOK)
+	}
+
+	resps := make([]response, 0, len(batch))
+	for _, raw := range batch {
+		var req request
+		if err := json.Unmarshal(raw, &req); err != nil {
+			resps = append(resps, response{Error: newError(CodeInvalidRequest, "invalid request")})
+			continue
+		}
+
+		if resp, ok := h.call(req); ok {
+			resps = append(resps, resp)
+		}
+	}
+
+	// A batch of only notifications gets nothing back.
+	if len(resps) == 0 {
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+
+	return web.Respond(ctx, w, resps, http.StatusOK)
+}
+
+// call executes the method for the request. False is returned when the
+// request is a notification and no response should be sent. An invalid
+// request is always answered since it can't be known to be a notification.
+func (h Handlers) call(req request) (response, bool) {
+	if req.JSONRPC != jsonrpcVersion || req.Method == "" {
+		return response{ID: req.ID, Error: newError(CodeInvalidRequest, "invalid request")}, true
+	}
+
+	notification := len(req.ID) == 0
+
+	fn, exists := methods[req.Method]
+	if !exists {
+		return response{ID: req.ID, Error: newError(CodeMethodNotFound, "the method %s does not exist/is not available", req.Method)}, !notification
+	}
+
+	result, err := fn(h, req.Params)
+	if err != nil {
+		h.Log.Infow("rpc", "method", req.Method, "ERROR", err)
+		return response{ID: req.ID, Error: toError(err)}, !notification
+	}
+
+	return response{ID: req.ID, Result: result}, !notification
+}
+
+// toError maps the error returned by a method onto a JSON-RPC error.
+func toError(err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	if re := mempool.GetRejectError(err); re != nil {
+		code := CodeTxRejected
+		if mempool.IsInvalid(re.Reason) {
+			code = CodeInvalidParams
+		}
+
+		return &Error{
+			Code:    code,
+			Message: re.Error(),
+			Data:    map[string]string{"reason": re.Reason},
+		}
+	}
+
+	return &Error{Code: CodeInternalError, Message: err.Error()}
+}
@@ -0,0 +1,326 @@
+package rpc_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/wtran29/go-blockchain/app/services/node/handlers/v1/rpc"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/genesis"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/storage/memory"
+	"go.uber.org/zap"
+)
+
+// beneficiaryID is the account that receives the transfers and the rewards
+// for the blocks mined in the tests.
+const beneficiaryID = database.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+
+// worker implements the state.Worker interface without doing any work.
+type worker struct{}
+
+func (worker) Shutdown()                              {}
+func (worker) Sync()                                  {}
+func (worker) SignalStartMining()                     {}
+func (worker) SignalCancelMining()                    {}
+func (worker) SignalShareTx(blockTx database.BlockTx) {}
+
+// reply represents a JSON-RPC response as a client sees it.
+type reply struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpc.Error      `json:"error"`
+}
+
+func Test_Errors(t *testing.T) {
+	h, key := newHandlers(t)
+
+	invalid := signTx(t, key, 1)
+	invalid.Value = 1_000
+
+	tt := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"parse-error", `{"jsonrpc":`, rpc.CodeParseError},
+		{"invalid-request", `{"id":1,"method":"eth_chainId"}`, rpc.CodeInvalidRequest},
+		{"method-not-found", `{"jsonrpc":"2.0","id":1,"method":"eth_unknown"}`, rpc.CodeMethodNotFound},
+		{"invalid-params", `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":[]}`, rpc.CodeInvalidParams},
+		{"historical-state", `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["` + string(beneficiaryID) + `","0x5"]}`, rpc.CodeInvalidInput},
+		{"invalid-signature", call("eth_sendRawTransaction", rawTx(t, invalid)), rpc.CodeInvalidParams},
+		{"tx-rejected", call("eth_sendRawTransaction", rawTx(t, signTx(t, key, 5_000_000_000))), rpc.CodeTxRejected},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			status, body := post(t, h, tst.body)
+			if status != http.StatusOK {
+				t.Fatalf("Should get a %d status, got %d", http.StatusOK, status)
+			}
+
+			var resp reply
+			if err := json.Unmarshal(body, &resp); err != nil {
+				t.Fatalf("Should be able to decode the response: %s", err)
+			}
+
+			if resp.Error == nil || resp.Error.Code != tst.code {
+				t.Fatalf("Should get the error code %d, got %s", tst.code, body)
+			}
+		})
+	}
+}
+
+func Test_Batch(t *testing.T) {
+	h, _ := newHandlers(t)
+
+	tt := []struct {
+		name   string
+		body   string
+		status int
+		ids    []string
+	}{
+		{"mixed", `[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},{"jsonrpc":"2.0","method":"eth_chainId"},{"jsonrpc":"2.0","id":"two","method":"eth_unknown"}]`, http.StatusOK, []string{"1", `"two"`}},
+		{"notifications", `[{"jsonrpc":"2.0","method":"eth_chainId"},{"jsonrpc":"2.0","method":"eth_blockNumber"}]`, http.StatusNoContent, nil},
+		{"notification", `{"jsonrpc":"2.0","method":"eth_chainId"}`, http.StatusNoContent, nil},
+		{"invalid-entry", `[1]`, http.StatusOK, []string{"null"}},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			status, body := post(t, h, tst.body)
+			if status != tst.status {
+				t.Fatalf("Should get a %d status, got %d", tst.status, status)
+			}
+
+			if tst.ids == nil {
+				if len(body) != 0 {
+					t.Fatalf("Should not get a response, got %s", body)
+				}
+				return
+			}
+
+			var resps []reply
+			if err := json.Unmarshal(body, &resps); err != nil {
+				t.Fatalf("Should be able to decode the responses: %s", err)
+			}
+
+			if len(resps) != len(tst.ids) {
+				t.Fatalf("Should get %d responses, got %s", len(tst.ids), body)
+			}
+			for i, resp := range resps {
+				if string(resp.ID) != tst.ids[i] {
+					t.Fatalf("Should get the id %s in position %d, got %s", tst.ids[i], i, resp.ID)
+				}
+			}
+		})
+	}
+
+	// An empty batch is an invalid request.
+	_, body := post(t, h, `[]`)
+
+	var resp reply
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error == nil || resp.Error.Code != rpc.CodeInvalidRequest {
+		t.Fatalf("Should get the error code %d for an empty batch, got %s", rpc.CodeInvalidRequest, body)
+	}
+}
+
+func Test_TransactionByHash(t *testing.T) {
+	h, key := newHandlers(t)
+
+	signedTx := signTx(t, key, 10)
+
+	_, body := post(t, h, call("eth_sendRawTransaction", rawTx(t, signedTx)))
+
+	var resp reply
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error != nil {
+		t.Fatalf("Should be able to send the transaction, got %s", body)
+	}
+
+	var hash string
+	json.Unmarshal(resp.Result, &hash)
+
+	if _, err := h.State.MineNewBlock(context.Background()); err != nil {
+		t.Fatalf("Should be able to mine a block: %s", err)
+	}
+
+	// The hash is looked up with upper case hex like some tools send.
+	lookup := "0x" + strings.ToUpper(hash[2:])
+
+	for _, method := range []string{"eth_getTransactionByHash", "eth_getTransactionReceipt"} {
+		_, body := post(t, h, call(method, lookup))
+
+		var resp reply
+		if err := json.Unmarshal(body, &resp); err != nil || resp.Error != nil {
+			t.Fatalf("%s: Should be able to find the transaction, got %s", method, body)
+		}
+
+		var tx struct {
+			Hash        string `json:"hash"`
+			TxHash      string `json:"transactionHash"`
+			BlockNumber string `json:"blockNumber"`
+		}
+		json.Unmarshal(resp.Result, &tx)
+
+		if tx.BlockNumber != "0x1" || (tx.Hash != hash && tx.TxHash != hash) {
+			t.Fatalf("%s: Should find the transaction mined in block 1, got %s", method, resp.Result)
+		}
+	}
+
+	_, body = post(t, h, call("eth_getTransactionByHash", signature.ZeroHash))
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error != nil || string(resp.Result) != "null" {
+		t.Fatalf("Should get null for an unknown transaction, got %s", body)
+	}
+}
+
+func Test_TransactionCount(t *testing.T) {
+	h, key := newHandlers(t)
+	accountID := database.PublicKeyToAccountID(key.PublicKey)
+
+	count := func(tag string) string {
+		t.Helper()
+
+		_, body := post(t, h, `{"jsonrpc":"2.0","id":1,"method":"eth_getTransactionCount","params":["`+string(accountID)+`","`+tag+`"]}`)
+
+		var resp reply
+		var nonce string
+		if err := json.Unmarshal(body, &resp); err != nil || resp.Error != nil || json.Unmarshal(resp.Result, &nonce) != nil {
+			t.Fatalf("%s: Should be able to get the transaction count, got %s", tag, body)
+		}
+		return nonce
+	}
+
+	// The count is the nonce to sign the next transaction with, and nonces
+	// start at 1.
+	tt := []struct {
+		name    string
+		action  func()
+		latest  string
+		pending string
+	}{
+		{"new-account", func() {}, "0x1", "0x1"},
+		{"in-mempool", func() { post(t, h, call("eth_sendRawTransaction", rawTx(t, signTx(t, key, 10)))) }, "0x1", "0x2"},
+		{"mined", func() { h.State.MineNewBlock(context.Background()) }, "0x2", "0x2"},
+	}
+
+	for _, tst := range tt {
+		tst.action()
+
+		if got := count("latest"); got != tst.latest {
+			t.Fatalf("%s: Should get the latest count %s, got %s", tst.name, tst.latest, got)
+		}
+		if got := count("pending"); got != tst.pending {
+			t.Fatalf("%s: Should get the pending count %s, got %s", tst.name, tst.pending, got)
+		}
+	}
+}
+
+// =============================================================================
+
+// newHandlers constructs the handlers for a node on a PoA chain that funds
+// the account for the returned key.
+func newHandlers(t *testing.T) (rpc.Handlers, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Should be able to generate a private key: %s", err)
+	}
+
+	storage, err := memory.New()
+	if err != nil {
+		t.Fatalf("Should be able to construct the storage: %s", err)
+	}
+
+	gen := genesis.Genesis{
+		Date:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ChainID:       1,
+		TransPerBlock: 1,
+		Difficulty:    1,
+		MiningReward:  700,
+		GasPrice:      15,
+		BaseFee:       5,
+		Balances:      map[string]uint64{string(database.PublicKeyToAccountID(key.PublicKey)): 1_000_000},
+		Gas: genesis.GasSchedule{
+			TxBase:   21,
+			Transfer: 9,
+		},
+	}
+
+	st, err := state.New(state.Config{
+		BeneficiaryID:  beneficiaryID,
+		Host:           "localhost:9080",
+		Storage:        storage,
+		Genesis:        gen,
+		SelectStrategy: selector.StrategyFeeDensity,
+		Consensus:      state.ConsensusPOA,
+		MiningWorkers:  1,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the state: %s", err)
+	}
+	st.Worker = worker{}
+
+	return rpc.Handlers{Log: zap.NewNop().Sugar(), State: st}, key
+}
+
+// signTx constructs and signs the first transfer of the value from the
+// account to the beneficiary.
+func signTx(t *testing.T, key *ecdsa.PrivateKey, value uint64) database.SignedTx {
+	t.Helper()
+
+	tx, err := database.NewTx(1, 1, database.PublicKeyToAccountID(key.PublicKey), beneficiaryID, value, 0, nil, 30, 20)
+	if err != nil {
+		t.Fatalf("Should be able to construct the transaction: %s", err)
+	}
+
+	signedTx, err := tx.Sign(key)
+	if err != nil {
+		t.Fatalf("Should be able to sign the transaction: %s", err)
+	}
+
+	return signedTx
+}
+
+// rawTx encodes the signed transaction the way eth_sendRawTransaction
+// expects it.
+func rawTx(t *testing.T, signedTx database.SignedTx) string {
+	t.Helper()
+
+	data, err := json.Marshal(signedTx)
+	if err != nil {
+		t.Fatalf("Should be able to encode the transaction: %s", err)
+	}
+
+	return hexutil.Encode(data)
+}
+
+// call constructs the body for a call to the method with a single param.
+func call(method string, param string) string {
+	return `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":["` + param + `"]}`
+}
+
+// post sends the body to the handler and returns the status and body of the
+// response.
+func post(t *testing.T, h rpc.Handlers, body string) (int, []byte) {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	if err := h.RPC(context.Background(), w, r); err != nil {
+		t.Fatalf("Should be able to handle the request: %s", err)
+	}
+
+	return w.Code, w.Body.Bytes()
+}
@@ -4,10 +4,14 @@ package v1
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/websocket"
+	"github.com/wtran29/go-blockchain/app/services/node/handlers/v1/gql"
 	"github.com/wtran29/go-blockchain/app/services/node/handlers/v1/private"
 	"github.com/wtran29/go-blockchain/app/services/node/handlers/v1/public"
+	"github.com/wtran29/go-blockchain/app/services/node/handlers/v1/rpc"
+	"github.com/wtran29/go-blockchain/business/webhook"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
 	"github.com/wtran29/go-blockchain/foundation/events"
 	"github.com/wtran29/go-blockchain/foundation/nameservice"
@@ -20,10 +24,12 @@ const version = "v1"
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log   *zap.SugaredLogger
-	State *state.State
-	NS    *nameservice.NameService
-	Evts  *events.Events
+	Log     *zap.SugaredLogger
+	State   *state.State
+	NS      *nameservice.NameService
+	Evts    *events.Events
+	Hooks   *webhook.Webhooks
+	Origins []string
 }
 
 // PublicRoutes binds all the version 1 public routes.
@@ -32,18 +38,38 @@ func PublicRoutes(app *web.App, cfg Config) {
 		Log:   cfg.Log,
 		State: cfg.State,
 		NS:    cfg.NS,
-		WS:    websocket.Upgrader{},
+		WS:    websocket.Upgrader{CheckOrigin: checkOrigin(cfg.Origins)},
 		Evts:  cfg.Evts,
 	}
 
+	app.Handle(http.MethodGet, version, "/events", pbl.Events)
+	app.Handle(http.MethodGet, version, "/events/stream", pbl.EventStream)
 	app.Handle(http.MethodPost, version, "/start/mining", pbl.StartMining)
 	app.Handle(http.MethodPost, version, "/tx/submit", pbl.SubmitWalletTransaction)
 	app.Handle(http.MethodGet, version, "/tx/uncommitted/list", pbl.Mempool)
 	app.Handle(http.MethodGet, version, "/tx/uncommitted/list/:account", pbl.Mempool)
+	app.Handle(http.MethodGet, version, "/tx/uncommitted/events", pbl.MempoolEvents)
+	app.Handle(http.MethodGet, version, "/tx/uncommitted/events/:account", pbl.MempoolEvents)
 	app.Handle(http.MethodGet, version, "/accounts/list", pbl.Accounts)
 	app.Handle(http.MethodGet, version, "/accounts/list/:account", pbl.Accounts)
+	app.Handle(http.MethodGet, version, "/accounts/rich", pbl.RichList)
 	app.Handle(http.MethodGet, version, "/blocks/list", pbl.BlocksByAccount)
 	app.Handle(http.MethodGet, version, "/blocks/list/:account", pbl.BlocksByAccount)
+	app.Handle(http.MethodGet, version, "/blocks/:numberOrHash", pbl.Block)
+	app.Handle(http.MethodGet, version, "/genesis", pbl.Genesis)
+	app.Handle(http.MethodGet, version, "/supply", pbl.Supply)
+	app.Handle(http.MethodGet, version, "/fees/estimate", pbl.FeeEstimate)
+	app.Handle(http.MethodGet, version, "/stats", pbl.Stats)
+
+	jrpc := rpc.Handlers{
+		Log:   cfg.Log,
+		State: cfg.State,
+	}
+
+	app.Handle(http.MethodPost, version, "/rpc", jrpc.RPC)
+
+	gqh := gql.New(cfg.Log, cfg.State, cfg.NS)
+	app.Handle(http.MethodPost, version, "/graphql", gqh.Query)
 }
 
 // PrivateRoutes binds all the version 1 private routes.
@@ -52,6 +78,7 @@ func PrivateRoutes(app *web.App, cfg Config) {
 		Log:   cfg.Log,
 		State: cfg.State,
 		NS:    cfg.NS,
+		Hooks: cfg.Hooks,
 	}
 
 	app.Handle(http.MethodPost, version, "/node/peers", prv.SubmitPeer)
@@ -60,4 +87,43 @@ func PrivateRoutes(app *web.App, cfg Config) {
 	app.Handle(http.MethodPost, version, "/node/block/propose", prv.ProposeBlock)
 	app.Handle(http.MethodPost, version, "/node/tx/submit", prv.SubmitNodeTransaction)
 	app.Handle(http.MethodGet, version, "/node/tx/list", prv.Mempool)
+	app.Handle(http.MethodGet, version, "/node/tx/hashes", prv.MempoolHashes)
+	app.Handle(http.MethodPost, version, "/node/tx/fetch", prv.MempoolFetch)
+	app.Handle(http.MethodPost, version, "/node/webhooks", prv.RegisterWebhook)
+	app.Handle(http.MethodGet, version, "/node/webhooks", prv.Webhooks)
+	app.Handle(http.MethodDelete, version, "/node/webhooks/:id", prv.RemoveWebhook)
+	app.Handle(http.MethodGet, version, "/node/webhooks/deliveries", prv.WebhookDeliveries)
+	app.Handle(http.MethodGet, version, "/node/webhooks/deadletters", prv.WebhookDeadLetters)
+}
+
+// =============================================================================
+
+// checkOrigin returns the function the websocket upgrader uses to accept the
+// origin of a request. Without any configured origins, only requests from
+// the same host are accepted. An origin of * accepts every origin.
+func checkOrigin(origins []string) func(r *http.Request) bool {
+	if len(origins) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]struct{}, len(origins))
+	for _, origin := range origins {
+		allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = struct{}{}
+	}
+
+	return func(r *http.Request) bool {
+
+		// Clients outside of a browser don't send an origin.
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		if _, exists := allowed["*"]; exists {
+			return true
+		}
+
+		_, exists := allowed[strings.ToLower(origin)]
+		return exists
+	}
 }
@@ -4,18 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/ardanlabs/conf/v3"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/wtran29/go-blockchain/app/services/node/handlers"
+	"github.com/wtran29/go-blockchain/business/web/metrics"
+	"github.com/wtran29/go-blockchain/business/webhook"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/genesis"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/peer"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/storage/disk"
@@ -65,6 +69,8 @@ func run(log *zap.SugaredLogger) error {
 			DebugHost       string        `conf:"default:0.0.0.0:7080"`
 			PublicHost      string        `conf:"default:0.0.0.0:8080"`
 			PrivateHost     string        `conf:"default:0.0.0.0:9080"`
+			GRPCHost        string        `conf:"default:0.0.0.0:6080"`
+			AllowedOrigins  []string      // Origins allowed to open a websocket, * allows all.
 		}
 		State struct {
 			Beneficiary    string   `conf:"default:miner1"`
@@ -72,6 +78,23 @@ func run(log *zap.SugaredLogger) error {
 			SelectStrategy string   `conf:"default:Tip"`
 			OriginPeers    []string `conf:"default:0.0.0.0:9080"` //
 			Consensus      string   `conf:"default:POW"`          // Change to POA to run Proof of Authority
+			MiningWorkers  int      `conf:"default:0"`            // Number of mining goroutines, 0 uses every core.
+		}
+		Mempool struct {
+			MaxTrans      uint64        `conf:"default:5000"`
+			MaxBytes      uint64        `conf:"default:4194304"`
+			MaxPerAccount uint64        `conf:"default:64"`
+			Eviction      string        `conf:"default:fee_density"` // Change to oldest to evict by age.
+			TTL           time.Duration `conf:"default:3h"`          // How long a transaction can wait to be mined.
+		}
+		Webhooks struct {
+			Endpoints   []string      // Receive the blocks, mined transactions and transfers.
+			Secret      string        `conf:"mask"`      // Signs the payloads for the configured endpoints.
+			MaxAttempts int           `conf:"default:5"` // Attempts before a delivery is dead lettered.
+			Backoff     time.Duration `conf:"default:1s"`
+			Timeout     time.Duration `conf:"default:5s"`
+			Workers     int           `conf:"default:4"`    // Number of deliveries posted at the same time.
+			QueueSize   int           `conf:"default:1000"` // Deliveries waiting before they are dead lettered.
 		}
 		NameService struct {
 			Folder string `conf:"default:block/accounts/"`
@@ -150,19 +173,18 @@ func run(log *zap.SugaredLogger) error {
 	peerSet.Add(peer.New(cfg.Web.PrivateHost))
 
 	// The blockchain packages accept a function of this signature to allow the
-	// application to log. For now, these raw messages are sent to any websocket
-	// client that is connected into the system through the events package.
-	evts := events.New()
+	// application to log.
 	ev := func(v string, args ...any) {
-		const websocketPrefix = "viewer:"
-
 		s := fmt.Sprintf(v, args...)
 		log.Infow(s, "traceid", "00000000-0000-0000-0000-000000000000")
-		if strings.HasPrefix(s, websocketPrefix) {
-			evts.Send(s)
-		}
 	}
 
+	// The state publishes structured events by topic. These are sent to any
+	// websocket client that is connected into the system through the events
+	// package. Mining progress is sent every second, so it isn't kept in the
+	// history clients resume from.
+	evts := events.New(state.TopicMiningProgress)
+
 	// Construct the use of disk storage.
 	storage, err := disk.New(cfg.State.DBPath)
 	if err != nil {
@@ -185,13 +207,51 @@ func run(log *zap.SugaredLogger) error {
 		SelectStrategy: cfg.State.SelectStrategy,
 		KnownPeers:     peerSet,
 		Consensus:      cfg.State.Consensus,
+		MiningWorkers:  cfg.State.MiningWorkers,
 		EvHandler:      ev,
+		Publisher:      evts.Send,
+		MempoolCapacity: mempool.Capacity{
+			MaxTrans:      cfg.Mempool.MaxTrans,
+			MaxBytes:      cfg.Mempool.MaxBytes,
+			MaxPerAccount: cfg.Mempool.MaxPerAccount,
+			Eviction:      cfg.Mempool.Eviction,
+			TTL:           cfg.Mempool.TTL,
+		},
+		MempoolJournal: filepath.Join(cfg.State.DBPath, "mempool.journal"),
 	})
 	if err != nil {
 		return err
 	}
 	defer state.Shutdown()
 
+	// Webhooks deliver the events to services that can't keep a websocket
+	// open. The hooks registered through the API are restored from disk and
+	// the endpoints from the configuration receive the default topics.
+	hooks := webhook.New(webhook.Config{
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		Backoff:     cfg.Webhooks.Backoff,
+		Timeout:     cfg.Webhooks.Timeout,
+		Workers:     cfg.Webhooks.Workers,
+		QueueSize:   cfg.Webhooks.QueueSize,
+		Path:        filepath.Join(cfg.State.DBPath, "webhooks.json"),
+		EvHandler:   ev,
+	})
+	defer hooks.Shutdown()
+
+	if err := hooks.Load(); err != nil {
+		return fmt.Errorf("unable to load webhooks: %w", err)
+	}
+
+	for _, url := range cfg.Webhooks.Endpoints {
+		if _, err := hooks.Register(webhook.Hook{URL: url, Secret: cfg.Webhooks.Secret, Config: true}); err != nil {
+			return fmt.Errorf("unable to register webhook: %w", err)
+		}
+	}
+	hooks.Run(evts.Acquire("webhooks"))
+
+	// Report the mining hash rate with the rest of the metrics.
+	metrics.PublishHashRate(state.HashRate)
+
 	// The worker package implements the different workflows such as mining,
 	// transaction peer sharing, and peer updates. The worker will register
 	// itself with the state.
@@ -240,6 +300,7 @@ func run(log *zap.SugaredLogger) error {
 		State:    state,
 		NS:       ns,
 		Evts:     evts,
+		Origins:  cfg.Web.AllowedOrigins,
 	})
 
 	// Construct a server to service the requests against the mux.
@@ -269,6 +330,7 @@ func run(log *zap.SugaredLogger) error {
 		Log:      log,
 		State:    state,
 		NS:       ns,
+		Hooks:    hooks,
 	})
 
 	// Construct a server to service the requests against the mux.
@@ -287,6 +349,31 @@ func run(log *zap.SugaredLogger) error {
 		serverErrors <- private.ListenAndServe()
 	}()
 
+	// =========================================================================
+	// Start gRPC Service
+
+	log.Infow("startup", "status", "initializing gRPC API support")
+
+	// Construct the gRPC server for the public API calls.
+	grpcServer := handlers.GRPCServer(handlers.MuxConfig{
+		Shutdown: shutdown,
+		Log:      log,
+		State:    state,
+		NS:       ns,
+		Evts:     evts,
+	})
+
+	grpcListener, err := net.Listen("tcp", cfg.Web.GRPCHost)
+	if err != nil {
+		return fmt.Errorf("listening for grpc: %w", err)
+	}
+
+	// Start the service listening for api requests.
+	go func() {
+		log.Infow("startup", "status", "grpc api started", "host", grpcListener.Addr().String())
+		serverErrors <- grpcServer.Serve(grpcListener)
+	}()
+
 	// =========================================================================
 	// Shutdown
 
@@ -299,6 +386,21 @@ func run(log *zap.SugaredLogger) error {
 		log.Infow("shutdown", "status", "shutdown started", "signal", sig)
 		defer log.Infow("shutdown", "status", "shutdown complete", "signal", sig)
 
+		// Give outstanding requests a deadline for completion. Streams stay
+		// open until the client leaves, so they are closed at the deadline.
+		log.Infow("shutdown", "status", "shutdown gRPC API started")
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(cfg.Web.ShutdownTimeout):
+			grpcServer.Stop()
+		}
+
 		// Give outstanding requests a deadline for completion.
 		ctx, cancelPub := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
 		defer cancelPub()
@@ -14,13 +14,16 @@ import (
 )
 
 var (
-	url   string
-	nonce uint64
-	from  string
-	to    string
-	value uint64
-	tip   uint64
-	data  []byte
+	url         string
+	nonce       uint64
+	from        string
+	to          string
+	value       uint64
+	tip         uint64
+	data        []byte
+	gasLimit    uint64
+	maxGasPrice uint64
+	validUntil  uint64
 )
 
 var sendCmd = &cobra.Command{
@@ -36,8 +39,11 @@ func init() {
 	sendCmd.Flags().StringVarP(&from, "from", "f", "", "Who is sending the transaction.")
 	sendCmd.Flags().StringVarP(&to, "to", "t", "", "Who is receiving the transaction.")
 	sendCmd.Flags().Uint64VarP(&value, "value", "v", 0, "Value to send.")
-	sendCmd.Flags().Uint64VarP(&tip, "tip", "c", 0, "Tip to send.")
+	sendCmd.Flags().Uint64VarP(&tip, "tip", "c", 0, "Tip to send, the node's normal estimate when not set.")
 	sendCmd.Flags().BytesHexVarP(&data, "data", "d", nil, "Data to send.")
+	sendCmd.Flags().Uint64VarP(&gasLimit, "gas-limit", "g", 400, "Most units of gas to buy up to the block gas limit, unused gas is refunded.")
+	sendCmd.Flags().Uint64VarP(&maxGasPrice, "max-gas-price", "m", 50, "Most to pay per unit of gas.")
+	sendCmd.Flags().Uint64VarP(&validUntil, "valid-until", "e", 0, "Last block number the transaction can be mined in, 0 never expires.")
 }
 
 func sendRun(cmd *cobra.Command, args []string) {
@@ -46,6 +52,14 @@ func sendRun(cmd *cobra.Command, args []string) {
 		log.Fatal(err)
 	}
 
+	if !cmd.Flags().Changed("tip") {
+		tip, err = estimateTip()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Using Estimated Tip:", tip)
+	}
+
 	sendWithDetails(privateKey)
 }
 
@@ -61,10 +75,11 @@ func sendWithDetails(privateKey *ecdsa.PrivateKey) {
 	}
 
 	const chainID = 1
-	tx, err := database.NewTx(chainID, nonce, fromAccount, toAccount, value, tip, data)
+	tx, err := database.NewTx(chainID, nonce, fromAccount, toAccount, value, tip, data, gasLimit, maxGasPrice)
 	if err != nil {
 		log.Fatal(err)
 	}
+	tx.ValidUntil = validUntil
 
 	signedTx, err := tx.Sign(privateKey)
 	if err != nil {
@@ -82,3 +97,26 @@ func sendWithDetails(privateKey *ecdsa.PrivateKey) {
 	}
 	defer resp.Body.Close()
 }
+
+// estimateTip asks the node for the tip that should get the transaction
+// mined within a couple of blocks.
+func estimateTip() (uint64, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/v1/fees/estimate", url))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("estimating the tip: status %d", resp.StatusCode)
+	}
+
+	var est struct {
+		Normal uint64 `json:"normal"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&est); err != nil {
+		return 0, err
+	}
+
+	return est.Normal, nil
+}
@@ -109,7 +109,7 @@ func writeBlock() error {
 			PrevBlockHash: signature.ZeroHash,
 			TimeStamp:     uint64(time.Now().UTC().UnixMilli()),
 			BeneficiaryID: beneficiaryID,
-			Difficulty:    6,
+			Difficulty:    16777216,
 			MiningReward:  700,
 			StateRoot:     "undefined for now",
 			TransRoot:     tree.RootHex(), //
@@ -1,6 +1,12 @@
 var nonce = 0;
 var chainID = 1;
 
+// The most gas a transaction will buy and the most it will pay per unit.
+// Unused gas is refunded by the node. The gas limit can't be more than the
+// block gas limit in genesis.
+const gasLimit = 400;
+const maxGasPrice = 50;
+
 // Things to run when the wallet is opened.
 window.onload = function () {
     wireEvents();
@@ -109,7 +115,7 @@ function wireEvents() {
 // 8080. If this is successful then screen data can be loaded. Events are also
 // provided to help keep the wallet up to date realtime.
 function connect() {
-    var socket = new WebSocket('ws://localhost:8080/v1/events');
+    var socket = new WebSocket('ws://localhost:8080/v1/events?topics=mining.progress,block.accepted');
 
     socket.addEventListener('open', function (event) {
         const conn = document.getElementById("connected");
@@ -126,18 +132,23 @@ function connect() {
 
     socket.addEventListener('message', function (event) {
         const conn = document.getElementById("connected");
-
-        if (event.data.includes("MINING: completed")) {
-            conn.className = "connected";
-            conn.innerHTML = "CONNECTED";
-            load();
-            return;
-        }
-
-        if (event.data.includes("MINING: running")) {
+        const ev = JSON.parse(event.data);
+
+        switch (ev.topic) {
+        case "mining.progress":
+            if (ev.payload.status == "completed") {
+                conn.className = "connected";
+                conn.innerHTML = "CONNECTED";
+                load();
+                return;
+            }
             conn.className = "mining";
             conn.innerHTML = "MINING...";
             return;
+
+        case "block.accepted":
+            load();
+            return;
         }
     });
 
@@ -182,6 +193,9 @@ function handleAjaxError(jqXHR, exception) {
         default:
             const o = JSON.parse(jqXHR.responseText);
             msg = o.error;
+            if (o.reason) {
+                msg = o.reason + ": " + o.error;
+            }
         }
     }
 
@@ -263,24 +277,22 @@ function transactions() {
 
     $.ajax({
         type: "get",
-        url: "http://localhost:8080/v1/blocks/list/" + wallet.address,
+        url: "http://localhost:8080/v1/blocks/list/" + wallet.address + "?order=desc&limit=100",
         success: function (resp) {
-            if (resp == null) {
-                return;
-            }
+            const blocks = resp.blocks;
 
             var msg = "";
             var count = 0;
-            for (var i = 0; i < resp.length; i++) {
-                for (var j = 0; j < resp[i].txs.length; j++) {
-                    if ((resp[i].txs[j].from == wallet.address) || (resp[i].txs[j].to == wallet.address)) {
-                        resp[i].txs[j].proved = false;
+            for (var i = 0; i < blocks.length; i++) {
+                for (var j = 0; j < blocks[i].txs.length; j++) {
+                    if ((blocks[i].txs[j].from == wallet.address) || (blocks[i].txs[j].to == wallet.address)) {
+                        blocks[i].txs[j].proved = false;
 
-                        if (validateMerkleProof(resp[i].txs[j], resp[i].trans_root)) {
-                            resp[i].txs[j].proved = true;
+                        if (validateMerkleProof(blocks[i].txs[j], blocks[i].trans_root)) {
+                            blocks[i].txs[j].proved = true;
                         }
 
-                        msg += JSON.stringify(resp[i].txs[j], null, 2);
+                        msg += JSON.stringify(blocks[i].txs[j], null, 2);
                         count++;
                     }
                 }
@@ -345,6 +357,9 @@ function createTxHash(tx) {
         value: tx.value,
         tip: tx.tip,
         data: null,
+        gas_limit: tx.gas_limit,
+        max_gas_price: tx.max_gas_price,
+        valid_until: tx.valid_until,
         v: byt[64],
         r: ethers.BigNumber.from(rSlice).toString(),
         s: ethers.BigNumber.from(sSlice).toString(),
@@ -378,18 +393,20 @@ function mempool() {
 
     $.ajax({
         type: "get",
-        url: "http://localhost:8080/v1/tx/uncommitted/list/" + wallet.address,
+        url: "http://localhost:8080/v1/tx/uncommitted/list/" + wallet.address + "?limit=100",
         success: function (resp) {
+            const txs = resp.txs;
+
             var msg = "";
             var count = 0;
-            for (var i = 0; i < resp.length; i++) {
-                msg += JSON.stringify(resp[i], null, 2);
+            for (var i = 0; i < txs.length; i++) {
+                msg += JSON.stringify(txs[i], null, 2);
                 count++;
 
-                if (resp[i].from == wallet.address) {
+                if (txs[i].from == wallet.address) {
 
                     // Check the mempool for what the next nonce should be for this account.
-                    const txNonce = Number(resp[i].nonce);
+                    const txNonce = Number(txs[i].nonce);
                     if (txNonce >= nonce) {
                         nonce = txNonce + 1
                         document.getElementById("nextnonce").innerHTML = nonce;
@@ -397,7 +414,7 @@ function mempool() {
 
                     // Update the accounts balance.
                     const frombal = document.getElementById("frombal");
-                    const txValue = Number(resp[i].value);
+                    const txValue = Number(txs[i].value);
                     var balance = Number(frombal.innerHTML.replace(/\$|,/g, '').replace(" ARD", ""));
                     balance -= txValue;
                     frombal.innerHTML = formatter.format(balance) + " ARD";
@@ -478,6 +495,9 @@ function createTransaction() {
         value: Number(amountStr),
         tip: Number(tipStr),
         data: null,
+        gas_limit: gasLimit,
+        max_gas_price: maxGasPrice,
+        valid_until: 0,
     };
 
     // Marshal the transaction to a string and convert the string to bytes.
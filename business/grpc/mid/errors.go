@@ -0,0 +1,45 @@
+package mid
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Errors handles errors coming out of the call chain. Handlers return a
+// status error for anything the client should see. Any other error is
+// unexpected, so the details are logged and the client gets an internal
+// error instead.
+func Errors(log *zap.SugaredLogger) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, toStatus(log, GetValues(ctx).TraceID, err)
+		}
+		return resp, nil
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return toStatus(log, GetValues(ss.Context()).TraceID, err)
+		}
+		return nil
+	}
+
+	return unary, stream
+}
+
+// toStatus logs the error and makes sure what is returned to the client is
+// a status error.
+func toStatus(log *zap.SugaredLogger, traceID string, err error) error {
+	log.Errorw("ERROR", "traceid", traceID, "ERROR", err)
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return status.Error(codes.Internal, codes.Internal.String())
+}
@@ -0,0 +1,60 @@
+package mid
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Logger writes some information about the request to the logs in the
+// format: TraceID : (OK) /node.v1.Node/ListAccounts -> IP ADDR (latency)
+// It also assigns the trace id used by the other interceptors, so it must
+// be the first one in the chain.
+func Logger(log *zap.SugaredLogger) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = setValues(ctx)
+		v := GetValues(ctx)
+
+		log.Infow("request started", "traceid", v.TraceID, "method", info.FullMethod, "remoteaddr", remoteAddr(ctx))
+
+		// Call the next handler.
+		resp, err := handler(ctx, req)
+
+		log.Infow("request completed", "traceid", v.TraceID, "method", info.FullMethod, "remoteaddr", remoteAddr(ctx),
+			"statuscode", status.Code(err), "since", time.Since(v.Now))
+
+		// Return the error so it can be handled further up the chain.
+		return resp, err
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := setValues(ss.Context())
+		v := GetValues(ctx)
+
+		log.Infow("stream started", "traceid", v.TraceID, "method", info.FullMethod, "remoteaddr", remoteAddr(ctx))
+
+		// Call the next handler.
+		err := handler(srv, withContext(ss, ctx))
+
+		log.Infow("stream completed", "traceid", v.TraceID, "method", info.FullMethod, "remoteaddr", remoteAddr(ctx),
+			"statuscode", status.Code(err), "since", time.Since(v.Now))
+
+		// Return the error so it can be handled further up the chain.
+		return err
+	}
+
+	return unary, stream
+}
+
+// remoteAddr returns the address of the client making the request.
+func remoteAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return p.Addr.String()
+}
@@ -0,0 +1,50 @@
+package mid
+
+import (
+	"context"
+
+	"github.com/wtran29/go-blockchain/business/web/metrics"
+	"google.golang.org/grpc"
+)
+
+// Metrics updates the same program counters as the web api.
+func Metrics() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+
+		// Add the metrics into the context for metric gathering.
+		ctx = metrics.Set(ctx)
+
+		// Call the next handler.
+		resp, err := handler(ctx, req)
+
+		record(ctx, err)
+
+		return resp, err
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		// Add the metrics into the context for metric gathering.
+		ctx := metrics.Set(ss.Context())
+
+		// Call the next handler.
+		err := handler(srv, withContext(ss, ctx))
+
+		record(ctx, err)
+
+		return err
+	}
+
+	return unary, stream
+}
+
+// record increments the request and goroutines counter, and the error
+// counter if there is an error flowing through the request.
+func record(ctx context.Context, err error) {
+	metrics.AddRequests(ctx)
+	metrics.AddGoroutines(ctx)
+
+	if err != nil {
+		metrics.AddErrors(ctx)
+	}
+}
@@ -0,0 +1,62 @@
+// Package mid contains the set of interceptors for the gRPC server. They
+// mirror the middleware used by the web api so every request is logged and
+// counted the same way regardless of how it came in.
+package mid
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+)
+
+// ctxKey represents the type of value for the context key.
+type ctxKey int
+
+// key is how request values are stored/retrieved.
+const key ctxKey = 1
+
+// Values represent state for each request.
+type Values struct {
+	TraceID string
+	Now     time.Time
+}
+
+// GetValues returns the values from the context. If the values are missing
+// an empty set of values is returned.
+func GetValues(ctx context.Context) Values {
+	v, ok := ctx.Value(key).(*Values)
+	if !ok {
+		return Values{}
+	}
+	return *v
+}
+
+// setValues stores a new set of values for the request in the context.
+func setValues(ctx context.Context) context.Context {
+	v := Values{
+		TraceID: uuid.NewString(),
+		Now:     time.Now().UTC(),
+	}
+	return context.WithValue(ctx, key, &v)
+}
+
+// =============================================================================
+
+// serverStream wraps a grpc.ServerStream so the interceptors can replace the
+// context the stream handler sees.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context for the stream.
+func (s serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// withContext returns the stream using the specified context.
+func withContext(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return serverStream{ServerStream: ss, ctx: ctx}
+}
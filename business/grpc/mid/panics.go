@@ -0,0 +1,44 @@
+package mid
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/wtran29/go-blockchain/business/web/metrics"
+	"google.golang.org/grpc"
+)
+
+// Panics recovers from panics and converts the panic to an error so it is
+// reported in Metrics and handled in Errors.
+func Panics() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer recoverPanic(ctx, &err)
+
+		// Call the next handler and set its return value in the err variable.
+		return handler(ctx, req)
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverPanic(ss.Context(), &err)
+
+		// Call the next handler and set its return value in the err variable.
+		return handler(srv, ss)
+	}
+
+	return unary, stream
+}
+
+// recoverPanic recovers from a panic and sets the err return variable after
+// the fact.
+func recoverPanic(ctx context.Context, err *error) {
+	if rec := recover(); rec != nil {
+
+		// Stack trace will be provided.
+		trace := debug.Stack()
+		*err = fmt.Errorf("PANIC [%v] TRACE[%s]", rec, string(trace))
+
+		// Updates the metrics stored in the context.
+		metrics.AddPanics(ctx)
+	}
+}
@@ -0,0 +1,4 @@
+// Package nodepb contains the protobuf messages and gRPC service definition
+// for the node's public API. The code is generated from node.proto with
+// make grpc-gen.
+package nodepb
@@ -87,3 +87,9 @@ func AddPanics(ctx context.Context) {
 		v.panics.Add(1)
 	}
 }
+
+// PublishHashRate registers the function used to report the current mining
+// hash rate. The value is read each time the metrics are requested.
+func PublishHashRate(fn func() uint64) {
+	expvar.Publish("hashrate", expvar.Func(func() any { return fn() }))
+}
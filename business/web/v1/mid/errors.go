@@ -50,7 +50,8 @@ func Errors(log *zap.SugaredLogger) web.Middleware {
 				case v1Web.IsRequestError(err):
 					reqErr := v1Web.GetRequestError(err)
 					er = v1Web.ErrorResponse{
-						Error: reqErr.Error(),
+						Error:  reqErr.Error(),
+						Reason: reqErr.Reason,
 					}
 					status = reqErr.Status
 
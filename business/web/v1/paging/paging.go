@@ -0,0 +1,212 @@
+// Package paging provides support for paging and filtering the list
+// endpoints with query parameters.
+package paging
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	v1 "github.com/wtran29/go-blockchain/business/web/v1"
+)
+
+// Set of limits on the number of items in a page.
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Set of values for the order query parameter.
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// Query represents the paging, sorting and range filters for a list request.
+// The time range is in milliseconds like the timestamps in the chain. An
+// unset upper bound is the max value so every range check is inclusive.
+type Query struct {
+	Limit     int
+	Position  string
+	Sort      string
+	Desc      bool
+	FromBlock uint64
+	ToBlock   uint64
+	FromTime  uint64
+	ToTime    uint64
+}
+
+// Parse reads the query parameters from the request. The sort field must be
+// one of the specified fields and defaults to the first one.
+//
+//	limit:      number of items in the page, default 20 and at most 100
+//	next:       token from the previous page to get the next page
+//	sort:       field to sort the items by
+//	order:      asc or desc
+//	from_block: only items at or above this block
+//	to_block:   only items at or below this block
+//	from_time:  only items at or after this time, RFC3339 or unix milliseconds
+//	to_time:    only items at or before this time, RFC3339 or unix milliseconds
+func Parse(r *http.Request, sortFields ...string) (Query, error) {
+	values := r.URL.Query()
+
+	q := Query{
+		Limit:   DefaultLimit,
+		ToBlock: ^uint64(0),
+		ToTime:  ^uint64(0),
+	}
+
+	if len(sortFields) > 0 {
+		q.Sort = sortFields[0]
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return Query{}, badRequest("limit must be between 1 and %d", MaxLimit)
+		}
+		q.Limit = limit
+	}
+
+	if v := values.Get("next"); v != "" {
+		position, err := ParseToken(v)
+		if err != nil {
+			return Query{}, badRequest("%s", err)
+		}
+		q.Position = position
+	}
+
+	if v := values.Get("sort"); v != "" {
+		if !contains(sortFields, v) {
+			return Query{}, badRequest("sort must be one of %v", sortFields)
+		}
+		q.Sort = v
+	}
+
+	switch values.Get("order") {
+	case "", OrderAsc:
+	case OrderDesc:
+		q.Desc = true
+	default:
+		return Query{}, badRequest("order must be %s or %s", OrderAsc, OrderDesc)
+	}
+
+	var err error
+	if q.FromBlock, err = parseUint(values.Get("from_block"), q.FromBlock); err != nil {
+		return Query{}, badRequest("invalid from_block")
+	}
+	if q.ToBlock, err = parseUint(values.Get("to_block"), q.ToBlock); err != nil {
+		return Query{}, badRequest("invalid to_block")
+	}
+	if q.FromTime, err = parseTime(values.Get("from_time"), q.FromTime); err != nil {
+		return Query{}, badRequest("invalid from_time")
+	}
+	if q.ToTime, err = parseTime(values.Get("to_time"), q.ToTime); err != nil {
+		return Query{}, badRequest("invalid to_time")
+	}
+
+	return q, nil
+}
+
+// PositionUint returns the position from the next token as a number. The
+// second value is false when there is no token.
+func (q Query) PositionUint() (uint64, bool, error) {
+	if q.Position == "" {
+		return 0, false, nil
+	}
+
+	n, err := strconv.ParseUint(q.Position, 10, 64)
+	if err != nil {
+		return 0, false, badRequest("invalid next token")
+	}
+
+	return n, true, nil
+}
+
+// InTime reports if the timestamp is inside the time range.
+func (q Query) InTime(timestamp uint64) bool {
+	return timestamp >= q.FromTime && timestamp <= q.ToTime
+}
+
+// Token constructs the opaque token a client uses to get the next page
+// starting after the position.
+func Token(position string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(position))
+}
+
+// ParseToken returns the position from the token constructed by Token.
+func ParseToken(token string) (string, error) {
+	position, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(position) == 0 {
+		return "", errors.New("invalid next token")
+	}
+
+	return string(position), nil
+}
+
+// Offset returns the items in the page when the items are paged by offset,
+// along with the token for the next page. An empty token means this is the
+// last page.
+func Offset(q Query, total int) (start int, end int, next string, err error) {
+	offset, _, err := q.PositionUint()
+	if err != nil {
+		return 0, 0, "", err
+	}
+
+	if offset > uint64(total) {
+		offset = uint64(total)
+	}
+
+	start = int(offset)
+	end = start + q.Limit
+	if end >= total {
+		return start, total, "", nil
+	}
+
+	return start, end, Token(strconv.Itoa(end)), nil
+}
+
+// =============================================================================
+
+// badRequest constructs a request error for an invalid query parameter.
+func badRequest(format string, args ...any) error {
+	return v1.NewRequestError(fmt.Errorf(format, args...), http.StatusBadRequest)
+}
+
+// parseUint parses the value or returns the default when it's empty.
+func parseUint(v string, def uint64) (uint64, error) {
+	if v == "" {
+		return def, nil
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
+
+// parseTime parses the value as RFC3339 or unix milliseconds, or returns the
+// default when it's empty.
+func parseTime(v string, def uint64) (uint64, error) {
+	if v == "" {
+		return def, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		if t.UnixMilli() < 0 {
+			return 0, errors.New("time before 1970")
+		}
+		return uint64(t.UnixMilli()), nil
+	}
+
+	return strconv.ParseUint(v, 10, 64)
+}
+
+// contains reports if the value is in the list.
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package paging_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wtran29/go-blockchain/business/web/v1/paging"
+)
+
+func Test_Parse(t *testing.T) {
+	tt := []struct {
+		name  string
+		query string
+		exp   paging.Query
+		fail  bool
+	}{
+		{name: "defaults", query: "", exp: paging.Query{Limit: paging.DefaultLimit, Sort: "number", ToBlock: ^uint64(0), ToTime: ^uint64(0)}},
+		{name: "limit", query: "limit=5", exp: paging.Query{Limit: 5, Sort: "number", ToBlock: ^uint64(0), ToTime: ^uint64(0)}},
+		{name: "sort-order", query: "sort=time&order=desc", exp: paging.Query{Limit: paging.DefaultLimit, Sort: "time", Desc: true, ToBlock: ^uint64(0), ToTime: ^uint64(0)}},
+		{name: "block-range", query: "from_block=3&to_block=9", exp: paging.Query{Limit: paging.DefaultLimit, Sort: "number", FromBlock: 3, ToBlock: 9, ToTime: ^uint64(0)}},
+		{name: "time-range", query: "from_time=2024-01-01T00:00:00Z&to_time=1704067201000", exp: paging.Query{Limit: paging.DefaultLimit, Sort: "number", ToBlock: ^uint64(0), FromTime: 1704067200000, ToTime: 1704067201000}},
+		{name: "next", query: "next=" + paging.Token("42"), exp: paging.Query{Limit: paging.DefaultLimit, Position: "42", Sort: "number", ToBlock: ^uint64(0), ToTime: ^uint64(0)}},
+		{name: "limit-zero", query: "limit=0", fail: true},
+		{name: "limit-max", query: "limit=101", fail: true},
+		{name: "unknown-sort", query: "sort=hash", fail: true},
+		{name: "unknown-order", query: "order=up", fail: true},
+		{name: "invalid-block", query: "from_block=-1", fail: true},
+		{name: "invalid-time", query: "to_time=yesterday", fail: true},
+		{name: "time-before-1970", query: "from_time=1969-12-31T00:00:00Z", fail: true},
+		{name: "invalid-next", query: "next=!", fail: true},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/blocks/list?"+tst.query, nil)
+
+			q, err := paging.Parse(r, "number", "time")
+			if tst.fail {
+				if err == nil {
+					t.Fatalf("Should not be able to parse %q, got %+v", tst.query, q)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Should be able to parse %q: %s", tst.query, err)
+			}
+			if q != tst.exp {
+				t.Fatalf("Should get %+v, got %+v", tst.exp, q)
+			}
+		})
+	}
+}
+
+func Test_Token(t *testing.T) {
+	for _, position := range []string{"0", "18446744073709551615", "0xF01813E4B85e178A83e29B8E7bF26BD830a25f32"} {
+		got, err := paging.ParseToken(paging.Token(position))
+		if err != nil {
+			t.Fatalf("Should be able to parse the token for %q: %s", position, err)
+		}
+		if got != position {
+			t.Fatalf("Should get the position %q back, got %q", position, got)
+		}
+	}
+
+	if _, err := paging.ParseToken(""); err == nil {
+		t.Fatal("Should not be able to parse an empty token")
+	}
+
+	q := paging.Query{Position: "not-a-number"}
+	if _, _, err := q.PositionUint(); err == nil {
+		t.Fatal("Should not be able to use a position that is not a number")
+	}
+}
+
+func Test_Offset(t *testing.T) {
+	const total = 45
+
+	// Walk the pages following the next tokens.
+	var pages [][2]int
+	q := paging.Query{Limit: 20}
+	for {
+		start, end, next, err := paging.Offset(q, total)
+		if err != nil {
+			t.Fatalf("Should be able to get the page: %s", err)
+		}
+		pages = append(pages, [2]int{start, end})
+
+		if next == "" {
+			break
+		}
+		if q.Position, err = paging.ParseToken(next); err != nil {
+			t.Fatalf("Should be able to parse the next token: %s", err)
+		}
+	}
+
+	exp := [][2]int{{0, 20}, {20, 40}, {40, 45}}
+	if len(pages) != len(exp) {
+		t.Fatalf("Should get %d pages, got %v", len(exp), pages)
+	}
+	for i := range exp {
+		if pages[i] != exp[i] {
+			t.Fatalf("Should get page %d as %v, got %v", i, exp[i], pages[i])
+		}
+	}
+
+	// An offset past the end is an empty last page.
+	start, end, next, err := paging.Offset(paging.Query{Limit: 20, Position: "100"}, total)
+	if err != nil || start != total || end != total || next != "" {
+		t.Fatalf("Should get an empty last page past the end, got start[%d] end[%d] next[%q] err[%v]", start, end, next, err)
+	}
+
+	// A page that ends right at the total has no next page.
+	if _, end, next, _ := paging.Offset(paging.Query{Limit: 15, Position: "30"}, total); end != total || next != "" {
+		t.Fatalf("Should not get a next token for the last full page, got end[%d] next[%q]", end, next)
+	}
+}
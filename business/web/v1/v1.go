@@ -6,6 +6,7 @@ import "errors"
 // ErrorResponse is the form used for API responses from failures in the API.
 type ErrorResponse struct {
 	Error  string            `json:"error"`
+	Reason string            `json:"reason,omitempty"`
 	Fields map[string]string `json:"fields,omitempty"`
 }
 
@@ -14,12 +15,19 @@ type ErrorResponse struct {
 type RequestError struct {
 	Err    error
 	Status int
+	Reason string
 }
 
 // NewRequestError wraps a provided error with an HTTP status code. This
 // function should be used when handlers encounter expected errors.
 func NewRequestError(err error, status int) error {
-	return &RequestError{err, status}
+	return &RequestError{Err: err, Status: status}
+}
+
+// NewReasonError wraps a provided error with an HTTP status code and a
+// machine readable reason the client can act on.
+func NewReasonError(err error, reason string, status int) error {
+	return &RequestError{Err: err, Status: status, Reason: reason}
 }
 
 // Error implements the error interface. It uses the default message of the
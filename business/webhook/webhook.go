@@ -0,0 +1,707 @@
+// Package webhook delivers chain events to registered URLs for services that
+// can't keep a websocket open.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+	"github.com/wtran29/go-blockchain/foundation/events"
+)
+
+// CORE NOTE: Every payload is signed with a secret that is shared with the
+// receiver when the hook is registered. The signature is an HMAC-SHA256 of
+// the body sent in the X-Signature header, so the receiver can check the
+// payload came from this node and was not changed. A delivery that fails is
+// retried with an exponential backoff. Once every attempt has failed, the
+// delivery is moved to the dead-letter list so it can be inspected.
+//
+// Deliveries are queued and posted by a fixed number of workers, so a slow
+// receiver can't make the node start an unbounded number of goroutines. When
+// the queue is full the delivery goes straight to the dead-letter list. The
+// hooks registered through the API are saved to disk so they survive a
+// restart. The hooks from the node configuration are registered again on
+// every start, so they are never saved.
+
+// Set of topics derived from the block events, on top of the state topics.
+const (
+	TopicTxMined          = "tx.mined"
+	TopicTransferReceived = "transfer.received"
+)
+
+// Set of headers sent with every delivery.
+const (
+	HeaderSignature = "X-Signature"
+	HeaderDelivery  = "X-Delivery-Id"
+	HeaderTopic     = "X-Topic"
+)
+
+// topics is the set of topics a hook can be registered for. Mining progress
+// is sent every second, which is too often to post to a URL.
+var topics = map[string]bool{
+	state.TopicBlockMined:    true,
+	state.TopicBlockAccepted: true,
+	state.TopicTxAdded:       true,
+	state.TopicReorg:         true,
+	state.TopicPeerAdded:     true,
+	TopicTxMined:             true,
+	TopicTransferReceived:    true,
+}
+
+// defaultTopics is the set of topics for a hook registered without any.
+var defaultTopics = []string{
+	state.TopicBlockMined,
+	state.TopicBlockAccepted,
+	TopicTxMined,
+	TopicTransferReceived,
+}
+
+// Set of limits on the delivery log and the dead-letter list.
+const (
+	deliveryLogSize = 1000
+	deadLetterSize  = 1000
+	maxBackoff      = time.Minute
+)
+
+// Set of defaults for the delivery workers and queue.
+const (
+	defaultWorkers   = 4
+	defaultQueueSize = 1000
+)
+
+// ErrNotFound is returned when a hook is not registered.
+var ErrNotFound = errors.New("webhook not found")
+
+// =============================================================================
+
+// Hook represents a URL that receives the events for a set of topics. When
+// accounts are provided, only the events for those accounts are delivered.
+// Config marks a hook from the node configuration, which is not saved.
+type Hook struct {
+	ID       string               `json:"id"`
+	URL      string               `json:"url"`
+	Secret   string               `json:"secret,omitempty"`
+	Topics   []string             `json:"topics"`
+	Accounts []database.AccountID `json:"accounts,omitempty"`
+	Config   bool                 `json:"config,omitempty"`
+}
+
+// Payload represents the JSON body that is posted to a hook.
+type Payload struct {
+	DeliveryID string `json:"delivery_id"`
+	HookID     string `json:"hook_id"`
+	Topic      string `json:"topic"`
+	Seq        uint64 `json:"seq"`
+	Block      uint64 `json:"block"`
+	Time       int64  `json:"time"`
+	Data       any    `json:"data"`
+}
+
+// Delivery represents the outcome of posting a payload to a hook.
+type Delivery struct {
+	ID         string    `json:"id"`
+	HookID     string    `json:"hook_id"`
+	URL        string    `json:"url"`
+	Topic      string    `json:"topic"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	Delivered  bool      `json:"delivered"`
+	Time       time.Time `json:"time"`
+}
+
+// DeadLetter represents a delivery that failed every attempt along with the
+// body that could not be delivered.
+type DeadLetter struct {
+	Delivery
+	Body json.RawMessage `json:"body"`
+}
+
+// job represents a payload waiting in the queue to be posted to a hook.
+type job struct {
+	hook       Hook
+	topic      string
+	deliveryID string
+	body       []byte
+}
+
+// =============================================================================
+
+// Config represents the configuration required to deliver webhooks. The
+// hooks registered through the API are saved at the path when one is
+// provided.
+type Config struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	Timeout     time.Duration
+	Workers     int
+	QueueSize   int
+	Path        string
+	EvHandler   state.EventHandler
+}
+
+// Webhooks maintains the registered hooks and delivers events to them.
+type Webhooks struct {
+	mu          sync.RWMutex
+	wg          sync.WaitGroup
+	shut        chan struct{}
+	queue       chan job
+	client      http.Client
+	maxAttempts int
+	backoff     time.Duration
+	path        string
+	evHandler   state.EventHandler
+	hooks       map[string]Hook
+	deliveries  []Delivery
+	deadLetters []DeadLetter
+}
+
+// New constructs a webhooks value for registering hooks and delivering events.
+// The workers that post the deliveries are started right away.
+func New(cfg Config) *Webhooks {
+	ev := func(v string, args ...any) {
+		if cfg.EvHandler != nil {
+			cfg.EvHandler(v, args...)
+		}
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultWorkers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+
+	wh := Webhooks{
+		shut:        make(chan struct{}),
+		queue:       make(chan job, cfg.QueueSize),
+		client:      http.Client{Timeout: cfg.Timeout},
+		maxAttempts: cfg.MaxAttempts,
+		backoff:     cfg.Backoff,
+		path:        cfg.Path,
+		evHandler:   ev,
+		hooks:       make(map[string]Hook),
+	}
+
+	wh.wg.Add(cfg.Workers)
+	for i := 0; i < cfg.Workers; i++ {
+		go func() {
+			defer wh.wg.Done()
+
+			for {
+				select {
+				case j := <-wh.queue:
+					wh.deliver(j)
+
+				case <-wh.shut:
+					return
+				}
+			}
+		}()
+	}
+
+	return &wh
+}
+
+// Run starts a goroutine that delivers the events received on the channel
+// until the channel is closed or Shutdown is called.
+func (wh *Webhooks) Run(ch <-chan events.Event) {
+	wh.wg.Add(1)
+	go func() {
+		defer wh.wg.Done()
+
+		for {
+			select {
+			case ev, ok := <-ch:
+				if !ok {
+					return
+				}
+				wh.Send(ev)
+
+			case <-wh.shut:
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown stops accepting events and waits for the deliveries in progress
+// to finish. Deliveries waiting on a retry or still in the queue are moved
+// to the dead-letter list.
+func (wh *Webhooks) Shutdown() {
+	wh.evHandler("webhook: shutdown: started")
+	defer wh.evHandler("webhook: shutdown: completed")
+
+	close(wh.shut)
+	wh.wg.Wait()
+
+	for {
+		select {
+		case j := <-wh.queue:
+			wh.record(j.delivery("shutdown before the delivery was attempted"), j.body)
+
+		default:
+			return
+		}
+	}
+}
+
+// Load registers the hooks saved by an earlier run of the node. There is
+// nothing to load when the webhooks were constructed without a path or the
+// file doesn't exist yet.
+func (wh *Webhooks) Load() error {
+	if wh.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(wh.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var hooks []Hook
+	if err := json.Unmarshal(data, &hooks); err != nil {
+		return fmt.Errorf("unable to decode %s: %w", wh.path, err)
+	}
+
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	for _, hook := range hooks {
+		wh.hooks[hook.ID] = hook
+		wh.evHandler("webhook: load: id[%s]: url[%s]: topics%v", hook.ID, hook.URL, hook.Topics)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// Register adds the hook to the set of registered hooks. An id and secret
+// are generated when they are not provided, and a hook without topics gets
+// the blocks, mined transactions and incoming transfers.
+func (wh *Webhooks) Register(hook Hook) (Hook, error) {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Hook{}, fmt.Errorf("invalid url %q", hook.URL)
+	}
+
+	if len(hook.Topics) == 0 {
+		hook.Topics = defaultTopics
+	}
+	for _, topic := range hook.Topics {
+		if !topics[topic] {
+			return Hook{}, fmt.Errorf("unknown topic %q", topic)
+		}
+	}
+
+	for _, accountID := range hook.Accounts {
+		if !accountID.IsAccountID() {
+			return Hook{}, fmt.Errorf("invalid account %q", accountID)
+		}
+	}
+
+	if hook.ID == "" {
+		hook.ID = uuid.NewString()
+	}
+
+	if hook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return Hook{}, err
+		}
+		hook.Secret = hex.EncodeToString(secret)
+	}
+
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	prev, exists := wh.hooks[hook.ID]
+	wh.hooks[hook.ID] = hook
+
+	if err := wh.save(); err != nil {
+		delete(wh.hooks, hook.ID)
+		if exists {
+			wh.hooks[hook.ID] = prev
+		}
+		return Hook{}, err
+	}
+
+	wh.evHandler("webhook: register: id[%s]: url[%s]: topics%v", hook.ID, hook.URL, hook.Topics)
+
+	return hook, nil
+}
+
+// Remove removes the hook from the set of registered hooks.
+func (wh *Webhooks) Remove(id string) error {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	hook, exists := wh.hooks[id]
+	if !exists {
+		return ErrNotFound
+	}
+
+	delete(wh.hooks, id)
+
+	if err := wh.save(); err != nil {
+		wh.hooks[id] = hook
+		return err
+	}
+
+	wh.evHandler("webhook: remove: id[%s]", id)
+
+	return nil
+}
+
+// Hooks returns a copy of the registered hooks. The secrets are only given
+// out when a hook is registered, so they are left out.
+func (wh *Webhooks) Hooks() []Hook {
+	wh.mu.RLock()
+	defer wh.mu.RUnlock()
+
+	hooks := make([]Hook, 0, len(wh.hooks))
+	for _, hook := range wh.hooks {
+		hook.Secret = ""
+		hooks = append(hooks, hook)
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].ID < hooks[j].ID })
+
+	return hooks
+}
+
+// Deliveries returns a copy of the delivery log, most recent first.
+func (wh *Webhooks) Deliveries() []Delivery {
+	wh.mu.RLock()
+	defer wh.mu.RUnlock()
+
+	list := make([]Delivery, len(wh.deliveries))
+	for i, d := range wh.deliveries {
+		list[len(list)-1-i] = d
+	}
+
+	return list
+}
+
+// DeadLetters returns a copy of the dead-letter list, most recent first.
+func (wh *Webhooks) DeadLetters() []DeadLetter {
+	wh.mu.RLock()
+	defer wh.mu.RUnlock()
+
+	list := make([]DeadLetter, len(wh.deadLetters))
+	for i, dl := range wh.deadLetters {
+		list[len(list)-1-i] = dl
+	}
+
+	return list
+}
+
+// =============================================================================
+
+// Send queues the event for every hook registered for it. Each block event
+// also produces a mined transaction event for each transaction in the block
+// and a transfer event for each account receiving value.
+func (wh *Webhooks) Send(ev events.Event) {
+	var jobs []job
+
+	wh.mu.RLock()
+	for _, hook := range wh.hooks {
+		for _, topic := range hook.Topics {
+			for _, data := range match(hook, topic, ev) {
+				j, err := newJob(hook, topic, ev, data)
+				if err != nil {
+					wh.evHandler("webhook: send: id[%s]: ERROR: %s", hook.ID, err)
+					continue
+				}
+				jobs = append(jobs, j)
+			}
+		}
+	}
+	wh.mu.RUnlock()
+
+	for _, j := range jobs {
+		select {
+		case wh.queue <- j:
+		default:
+			wh.record(j.delivery("delivery queue is full"), j.body)
+		}
+	}
+}
+
+// newJob constructs the job to post the data for the event to the hook.
+func newJob(hook Hook, topic string, ev events.Event, data any) (job, error) {
+	payload := Payload{
+		DeliveryID: uuid.NewString(),
+		HookID:     hook.ID,
+		Topic:      topic,
+		Seq:        ev.Seq,
+		Block:      ev.Block,
+		Time:       time.Now().UTC().UnixMilli(),
+		Data:       data,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return job{}, err
+	}
+
+	j := job{
+		hook:       hook,
+		topic:      topic,
+		deliveryID: payload.DeliveryID,
+		body:       body,
+	}
+
+	return j, nil
+}
+
+// delivery constructs the delivery for a job that was not attempted.
+func (j job) delivery(reason string) Delivery {
+	return Delivery{
+		ID:     j.deliveryID,
+		HookID: j.hook.ID,
+		URL:    j.hook.URL,
+		Topic:  j.topic,
+		Error:  reason,
+		Time:   time.Now().UTC(),
+	}
+}
+
+// deliver posts the payload for the job to the hook with retries.
+func (wh *Webhooks) deliver(j job) {
+	d := Delivery{
+		ID:     j.deliveryID,
+		HookID: j.hook.ID,
+		URL:    j.hook.URL,
+		Topic:  j.topic,
+	}
+
+	backoff := wh.backoff
+	for {
+		var err error
+		d.Attempts++
+		d.StatusCode, err = wh.post(j.hook, j.deliveryID, j.topic, j.body)
+		d.Time = time.Now().UTC()
+
+		switch {
+		case err == nil:
+			d.Delivered = true
+			d.Error = ""
+			wh.record(d, nil)
+			return
+
+		case d.Attempts >= wh.maxAttempts || !retry(d.StatusCode):
+			d.Error = err.Error()
+			wh.record(d, j.body)
+			return
+		}
+
+		d.Error = err.Error()
+		wh.evHandler("webhook: deliver: id[%s]: delivery[%s]: attempt[%d]: retry in %s: %s", j.hook.ID, d.ID, d.Attempts, backoff, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-wh.shut:
+			wh.record(d, j.body)
+			return
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// post sends the signed body to the hook and returns the status code. A
+// status code outside of the 2xx range is returned as an error.
+func (wh *Webhooks) post(hook Hook, deliveryID string, topic string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, body))
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderTopic, topic)
+
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// record adds the delivery to the log, and to the dead-letter list when the
+// body was not delivered.
+func (wh *Webhooks) record(d Delivery, body []byte) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	wh.deliveries = append(wh.deliveries, d)
+	if len(wh.deliveries) > deliveryLogSize {
+		wh.deliveries = wh.deliveries[1:]
+	}
+
+	if d.Delivered {
+		wh.evHandler("webhook: deliver: id[%s]: delivery[%s]: delivered: attempts[%d]", d.HookID, d.ID, d.Attempts)
+		return
+	}
+
+	wh.deadLetters = append(wh.deadLetters, DeadLetter{Delivery: d, Body: body})
+	if len(wh.deadLetters) > deadLetterSize {
+		wh.deadLetters = wh.deadLetters[1:]
+	}
+
+	wh.evHandler("webhook: deliver: id[%s]: delivery[%s]: WARNING: dead letter: attempts[%d]: %s", d.HookID, d.ID, d.Attempts, d.Error)
+}
+
+// save writes the hooks registered through the API to disk. The file is
+// written to the side and renamed so a crash can't leave it half written.
+// The caller must hold the write lock.
+func (wh *Webhooks) save() error {
+	if wh.path == "" {
+		return nil
+	}
+
+	hooks := make([]Hook, 0, len(wh.hooks))
+	for _, hook := range wh.hooks {
+		if !hook.Config {
+			hooks = append(hooks, hook)
+		}
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].ID < hooks[j].ID })
+
+	data, err := json.MarshalIndent(hooks, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(wh.path), 0755); err != nil {
+		return err
+	}
+
+	// The file holds the secrets, so only the node can read it.
+	tmp := wh.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, wh.path)
+}
+
+// =============================================================================
+
+// Sign returns the signature for the body using the secret. Receivers can
+// compare this with the X-Signature header using hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retry reports if a failed delivery with the status code should be tried
+// again. Network errors, rate limits and server errors are retried.
+func retry(statusCode int) bool {
+	return statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// match returns the data to deliver to the hook for the topic, if any. A
+// block event produces an entry for every transaction that matches.
+func match(hook Hook, topic string, ev events.Event) []any {
+	switch topic {
+	case TopicTxMined, TopicTransferReceived:
+		be, ok := ev.Payload.(state.BlockEvent)
+		if !ok || (ev.Topic != state.TopicBlockMined && ev.Topic != state.TopicBlockAccepted) {
+			return nil
+		}
+
+		var list []any
+		for _, tx := range be.Block.Trans {
+			switch topic {
+			case TopicTxMined:
+				if watching(hook, tx.FromID) || watching(hook, tx.ToID) {
+					list = append(list, tx)
+				}
+
+			case TopicTransferReceived:
+				if tx.Value > 0 && watching(hook, tx.ToID) {
+					list = append(list, tx)
+				}
+			}
+		}
+		return list
+	}
+
+	if topic != ev.Topic {
+		return nil
+	}
+
+	switch payload := ev.Payload.(type) {
+	case state.BlockEvent:
+		if len(hook.Accounts) == 0 || watching(hook, payload.Block.Header.BeneficiaryID) {
+			return []any{payload}
+		}
+		for _, tx := range payload.Block.Trans {
+			if watching(hook, tx.FromID) || watching(hook, tx.ToID) {
+				return []any{payload}
+			}
+		}
+		return nil
+
+	case state.TxEvent:
+		if watching(hook, payload.Tx.FromID) || watching(hook, payload.Tx.ToID) {
+			return []any{payload}
+		}
+		return nil
+	}
+
+	return []any{ev.Payload}
+}
+
+// watching reports if the hook is interested in the account. A hook without
+// accounts is interested in every account.
+func watching(hook Hook, accountID database.AccountID) bool {
+	if len(hook.Accounts) == 0 {
+		return true
+	}
+
+	for _, id := range hook.Accounts {
+		if id == accountID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,303 @@
+package webhook_test
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wtran29/go-blockchain/business/webhook"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+	"github.com/wtran29/go-blockchain/foundation/events"
+)
+
+const (
+	bill = database.AccountID("0xF01813E4B85e178A83e29B8E7bF26BD830a25f32")
+	pavl = database.AccountID("0xdd6B972ffcc631a62CAE1BB9d80b7ff429c8ebA4")
+	edua = database.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+)
+
+func Test_Deliver(t *testing.T) {
+	const secret = "test-secret"
+
+	var mu sync.Mutex
+	received := make(map[string][]webhook.Payload)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Should be able to read the body: %s", err)
+			return
+		}
+
+		if sig := r.Header.Get(webhook.HeaderSignature); !hmac.Equal([]byte(sig), []byte(webhook.Sign(secret, body))) {
+			t.Errorf("Should receive a valid signature, got %q", sig)
+		}
+
+		var payload webhook.Payload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("Should be able to decode the payload: %s", err)
+		}
+
+		mu.Lock()
+		received[payload.Topic] = append(received[payload.Topic], payload)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	wh := webhook.New(webhook.Config{MaxAttempts: 1, Timeout: time.Second})
+	defer wh.Shutdown()
+
+	_, err := wh.Register(webhook.Hook{
+		URL:      srv.URL,
+		Secret:   secret,
+		Topics:   []string{state.TopicBlockMined, webhook.TopicTxMined, webhook.TopicTransferReceived},
+		Accounts: []database.AccountID{pavl},
+	})
+	if err != nil {
+		t.Fatalf("Should be able to register a hook: %s", err)
+	}
+
+	// Bill sends to Pavl and Edua. Only the transfer to Pavl is watched.
+	wh.Send(blockEvent(newTx(bill, pavl, 1, 10), newTx(bill, edua, 2, 20)))
+
+	waitFor(t, func() bool { return len(wh.Deliveries()) == 3 })
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for topic, exp := range map[string]int{state.TopicBlockMined: 1, webhook.TopicTxMined: 1, webhook.TopicTransferReceived: 1} {
+		if got := len(received[topic]); got != exp {
+			t.Fatalf("Should receive %d deliveries for %s, got %d", exp, topic, got)
+		}
+	}
+}
+
+func Test_Retry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	wh := webhook.New(webhook.Config{MaxAttempts: 3, Backoff: 10 * time.Millisecond, Timeout: time.Second})
+	defer wh.Shutdown()
+
+	if _, err := wh.Register(webhook.Hook{URL: srv.URL, Topics: []string{state.TopicBlockMined}}); err != nil {
+		t.Fatalf("Should be able to register a hook: %s", err)
+	}
+
+	wh.Send(blockEvent(newTx(bill, pavl, 1, 10)))
+
+	waitFor(t, func() bool { return len(wh.Deliveries()) == 1 })
+
+	d := wh.Deliveries()[0]
+	if !d.Delivered || d.Attempts != 3 {
+		t.Fatalf("Should be delivered on the third attempt, got delivered[%v] attempts[%d]", d.Delivered, d.Attempts)
+	}
+
+	if n := len(wh.DeadLetters()); n != 0 {
+		t.Fatalf("Should not have any dead letters, got %d", n)
+	}
+}
+
+func Test_DeadLetter(t *testing.T) {
+	tt := []struct {
+		name     string
+		status   int
+		attempts int
+	}{
+		{"server-error", http.StatusInternalServerError, 2},
+		{"bad-request", http.StatusBadRequest, 1},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tst.status)
+			}))
+			defer srv.Close()
+
+			wh := webhook.New(webhook.Config{MaxAttempts: 2, Backoff: 10 * time.Millisecond, Timeout: time.Second})
+			defer wh.Shutdown()
+
+			if _, err := wh.Register(webhook.Hook{URL: srv.URL, Topics: []string{state.TopicBlockMined}}); err != nil {
+				t.Fatalf("Should be able to register a hook: %s", err)
+			}
+
+			wh.Send(blockEvent(newTx(bill, pavl, 1, 10)))
+
+			waitFor(t, func() bool { return len(wh.DeadLetters()) == 1 })
+
+			dl := wh.DeadLetters()[0]
+			if dl.Attempts != tst.attempts || dl.StatusCode != tst.status {
+				t.Fatalf("Should fail after %d attempts with %d, got attempts[%d] status[%d]", tst.attempts, tst.status, dl.Attempts, dl.StatusCode)
+			}
+
+			var payload webhook.Payload
+			if err := json.Unmarshal(dl.Body, &payload); err != nil || payload.Topic != state.TopicBlockMined {
+				t.Fatalf("Should keep the body that was not delivered: %s", err)
+			}
+		})
+	}
+}
+
+func Test_Register(t *testing.T) {
+	wh := webhook.New(webhook.Config{})
+	defer wh.Shutdown()
+
+	hook, err := wh.Register(webhook.Hook{URL: "http://localhost:3000/hook"})
+	if err != nil {
+		t.Fatalf("Should be able to register a hook: %s", err)
+	}
+
+	if hook.ID == "" || hook.Secret == "" || len(hook.Topics) == 0 {
+		t.Fatalf("Should get an id, secret and the default topics, got %+v", hook)
+	}
+
+	if _, err := wh.Register(webhook.Hook{URL: "localhost"}); err == nil {
+		t.Fatal("Should not be able to register an invalid url")
+	}
+
+	if _, err := wh.Register(webhook.Hook{URL: "http://localhost:3000", Topics: []string{"unknown"}}); err == nil {
+		t.Fatal("Should not be able to register an unknown topic")
+	}
+
+	if _, err := wh.Register(webhook.Hook{URL: "http://localhost:3000", Topics: []string{state.TopicMiningProgress}}); err == nil {
+		t.Fatal("Should not be able to register for mining progress")
+	}
+
+	if hooks := wh.Hooks(); len(hooks) != 1 || hooks[0].Secret != "" {
+		t.Fatalf("Should list the hook without its secret, got %+v", hooks)
+	}
+
+	if err := wh.Remove(hook.ID); err != nil {
+		t.Fatalf("Should be able to remove the hook: %s", err)
+	}
+
+	if err := wh.Remove(hook.ID); err == nil {
+		t.Fatal("Should not be able to remove the hook twice")
+	}
+}
+
+func Test_QueueFull(t *testing.T) {
+	received := make(chan struct{}, 10)
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+
+	wh := webhook.New(webhook.Config{MaxAttempts: 1, Timeout: 5 * time.Second, Workers: 1, QueueSize: 1})
+	defer wh.Shutdown()
+	defer close(release)
+
+	if _, err := wh.Register(webhook.Hook{URL: srv.URL, Topics: []string{state.TopicBlockMined}}); err != nil {
+		t.Fatalf("Should be able to register a hook: %s", err)
+	}
+
+	// The only worker is busy with the first delivery and the second one
+	// fills the queue, so the third can't be queued.
+	wh.Send(blockEvent(newTx(bill, pavl, 1, 10)))
+	<-received
+
+	wh.Send(blockEvent(newTx(bill, pavl, 2, 10)))
+	wh.Send(blockEvent(newTx(bill, pavl, 3, 10)))
+
+	dls := wh.DeadLetters()
+	if len(dls) != 1 || dls[0].Attempts != 0 {
+		t.Fatalf("Should dead letter the delivery that didn't fit in the queue, got %+v", dls)
+	}
+}
+
+func Test_Persist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+
+	wh := webhook.New(webhook.Config{Path: path})
+	defer wh.Shutdown()
+
+	saved, err := wh.Register(webhook.Hook{URL: "http://localhost:3000/saved"})
+	if err != nil {
+		t.Fatalf("Should be able to register a hook: %s", err)
+	}
+
+	removed, err := wh.Register(webhook.Hook{URL: "http://localhost:3000/removed"})
+	if err != nil {
+		t.Fatalf("Should be able to register a hook: %s", err)
+	}
+
+	if _, err := wh.Register(webhook.Hook{URL: "http://localhost:3000/config", Config: true}); err != nil {
+		t.Fatalf("Should be able to register a hook: %s", err)
+	}
+
+	if err := wh.Remove(removed.ID); err != nil {
+		t.Fatalf("Should be able to remove the hook: %s", err)
+	}
+
+	// Restart with the same path.
+	restarted := webhook.New(webhook.Config{Path: path})
+	defer restarted.Shutdown()
+
+	if err := restarted.Load(); err != nil {
+		t.Fatalf("Should be able to load the hooks: %s", err)
+	}
+
+	hooks := restarted.Hooks()
+	if len(hooks) != 1 || hooks[0].ID != saved.ID || hooks[0].URL != saved.URL {
+		t.Fatalf("Should only restore the hook registered through the API, got %+v", hooks)
+	}
+}
+
+// =============================================================================
+
+// blockEvent constructs a block.mined event for a block with the transactions.
+func blockEvent(trans ...database.BlockTx) events.Event {
+	return events.Event{
+		Seq:   1,
+		Block: 1,
+		Topic: state.TopicBlockMined,
+		Payload: state.BlockEvent{
+			Block: database.BlockData{
+				Header: database.BlockHeader{Number: 1, BeneficiaryID: edua},
+				Trans:  trans,
+			},
+		},
+	}
+}
+
+// newTx constructs a block transaction sending the value.
+func newTx(from database.AccountID, to database.AccountID, nonce uint64, value uint64) database.BlockTx {
+	tx := database.Tx{
+		FromID: from,
+		ToID:   to,
+		Nonce:  nonce,
+		Value:  value,
+	}
+
+	return database.BlockTx{SignedTx: database.SignedTx{Tx: tx}}
+}
+
+// waitFor waits for the condition to be true since deliveries happen in
+// the background.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the deliveries")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
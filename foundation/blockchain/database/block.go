@@ -7,12 +7,24 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/wtran29/go-blockchain/foundation/blockchain/merkle"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
 )
 
+// powCheckInterval represents the number of attempts a mining worker makes
+// between checks for cancellation.
+const powCheckInterval = 1_000
+
+// MaxTimeDrift represents how far ahead of the local clock a block timestamp
+// can be. The retarget rule uses the timestamps, so without a bound a miner
+// could post-date a block to lower the difficulty.
+const MaxTimeDrift = 15 * time.Second
+
 // ErrChainForked is returned from validateNextBlock if another node's chain
 // is two or more blocks ahead of ours.
 var ErrChainForked = errors.New("blockchain forked, start resync")
@@ -34,8 +46,9 @@ type BlockHeader struct {
 	PrevBlockHash string    `json:"prev_block_hash"` // Bitcoin: Hash of the previous block in the chain.
 	TimeStamp     uint64    `json:"timestamp"`       // Bitcoin: Time the block was mined.
 	BeneficiaryID AccountID `json:"beneficiary"`     // Ethereum: The account who is receiving fees and tips.
-	Difficulty    uint16    `json:"difficulty"`      // Ethereum: Number of 0's needed to solve the hash solution.
+	Difficulty    uint64    `json:"difficulty"`      // Ethereum: Amount of work needed to solve the hash solution.
 	MiningReward  uint64    `json:"mining_reward"`   // Ethereum: The reward for mining this block.
+	BaseFee       uint64    `json:"base_fee"`        // Ethereum: The fee per unit of gas that is burned for this block.
 	StateRoot     string    `json:"state_root"`      // Ethereum: Represents a hash of the accounts and their balances.
 	TransRoot     string    `json:"trans_root"`      // Both: Represents the merkle tree root hash for the transactions in this block.
 	Nonce         uint64    `json:"nonce"`           // Both: Value identified to solve the hash solution.
@@ -51,12 +64,15 @@ type Block struct {
 // POWArgs represents the set of arguments required to run POW.
 type POWArgs struct {
 	BeneficiaryID AccountID
-	Difficulty    uint16
+	Difficulty    uint64
 	MiningReward  uint64
+	BaseFee       uint64
 	PrevBlock     Block
 	StateRoot     string
 	Trans         []BlockTx
+	Workers       int
 	EvHandler     func(v string, args ...any)
+	HashRate      func(hashesPerSecond uint64)
 }
 
 // POW constructs a new Block and performs the work to find a nonce that
@@ -84,6 +100,7 @@ func POW(ctx context.Context, args POWArgs) (Block, error) {
 			BeneficiaryID: args.BeneficiaryID,
 			Difficulty:    args.Difficulty,
 			MiningReward:  args.MiningReward,
+			BaseFee:       args.BaseFee,
 			StateRoot:     args.StateRoot,
 			TransRoot:     tree.RootHex(), //
 			Nonce:         0,              // Will be identified by the POW algorithm.
@@ -92,7 +109,7 @@ func POW(ctx context.Context, args POWArgs) (Block, error) {
 	}
 
 	// Peform the proof of work mining operation.
-	if err := block.performPOW(ctx, args.EvHandler); err != nil {
+	if err := block.performPOW(ctx, args.Workers, args.EvHandler, args.HashRate); err != nil {
 		return Block{}, err
 	}
 
@@ -101,7 +118,9 @@ func POW(ctx context.Context, args POWArgs) (Block, error) {
 
 // performPOW does the work of mining to find a valid hash for a specified
 // block. Pointer semantics are being used since a nonce is being discovered.
-func (b *Block) performPOW(ctx context.Context, ev func(v string, args ...any)) error {
+// The nonce space is split across the specified number of workers and they
+// all stop as soon as one of them finds a solution or the context is cancelled.
+func (b *Block) performPOW(ctx context.Context, workers int, ev func(v string, args ...any), hashRate func(uint64)) error {
 	ev("database: PerformPOW: MINING: started")
 	defer ev("database: PerformPOW: MINING: completed")
 
@@ -110,48 +129,113 @@ func (b *Block) performPOW(ctx context.Context, ev func(v string, args ...any))
 		ev("database: PerformPOW: MINING: tx[%s]", tx)
 	}
 
-	// Choose a random starting point for the nonce. After this, the nonce
-	// will be incremented by 1 until a solution is found by us or another node.
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	// Choose a random starting point for the nonce. After this, each worker
+	// starts at its own offset and strides by the number of workers until a
+	// solution is found by us or another node.
 	nBig, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
 	if err != nil {
 		return ctx.Err()
 	}
-	b.Header.Nonce = nBig.Uint64()
-
-	ev("viewer: PerformPOW: MINING: running")
+	startNonce := nBig.Uint64()
+
+	ev("database: PerformPOW: MINING: running: workers[%d]", workers)
+
+	// This context is used to stop all the workers once a solution is found.
+	powCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var attempts atomic.Uint64
+	solution := make(chan BlockHeader, 1)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func(offset uint64) {
+			defer wg.Done()
+
+			// Each worker gets a copy of the block to change the nonce on.
+			blk := *b
+			blk.Header.Nonce = startNonce + offset
+
+			var local uint64
+			for {
+				local++
+
+				// Checking the context on every attempt is expensive so
+				// only check it every so often and report the attempts.
+				if local%powCheckInterval == 0 {
+					attempts.Add(powCheckInterval)
+					if powCtx.Err() != nil {
+						return
+					}
+				}
+
+				// Hash the block and check if we have solved the puzzle.
+				if !isHashSolved(blk.Header.Difficulty, blk.Hash()) {
+					blk.Header.Nonce += uint64(workers)
+					continue
+				}
+
+				// Only the first worker to find a solution gets to report it.
+				select {
+				case solution <- blk.Header:
+				default:
+				}
+				cancel()
+
+				return
+			}
+		}(uint64(i))
+	}
 
-	// Loop until we or another node finds a solution for the next block.
-	var attempts uint64
-	for {
-		attempts++
-		if attempts%1_000_000 == 0 {
-			ev("viewer: PerformPOW: MINING: running: attempts[%d]", attempts)
+	// This G reports the aggregated hash rate across all the workers.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		var last uint64
+		for {
+			select {
+			case <-ticker.C:
+				total := attempts.Load()
+				rate := total - last
+				last = total
+
+				if hashRate != nil {
+					hashRate(rate)
+				}
+				ev("database: PerformPOW: MINING: running: attempts[%d]: hashrate[%d/s]", total, rate)
+
+			case <-powCtx.Done():
+				return
+			}
 		}
+	}()
 
-		// Did we timeout trying to solve the problem.
-		if ctx.Err() != nil {
-			ev("database: PerformPOW: MINING: CANCELLED")
-			return ctx.Err()
-		}
+	// Wait for all the workers to stop.
+	wg.Wait()
 
-		// Hash the block and check if we have solved the puzzle.
-		hash := b.Hash()
-		if !isHashSolved(b.Header.Difficulty, hash) {
-			b.Header.Nonce++
-			continue
-		}
+	// Did we timeout trying to solve the problem.
+	if ctx.Err() != nil {
+		ev("database: PerformPOW: MINING: CANCELLED")
+		return ctx.Err()
+	}
 
-		// Did we timeout trying to solve the problem.
-		if ctx.Err() != nil {
-			ev("database: PerformPOW: MINING: CANCELLED")
-			return ctx.Err()
-		}
+	header := <-solution
+	b.Header = header
 
-		ev("database: PerformPOW: MINING: SOLVED: prevBlk[%s]: newBlk[%s]", b.Header.PrevBlockHash, hash)
-		ev("database: PerformPOW: MINING: attempts[%d]", attempts)
+	ev("database: PerformPOW: MINING: SOLVED: prevBlk[%s]: newBlk[%s]", b.Header.PrevBlockHash, b.Hash())
+	ev("database: PerformPOW: MINING: attempts[%d]", attempts.Load())
 
-		return nil
-	}
+	return nil
 }
 
 // NewBlockData constructs block data from a block.
@@ -200,8 +284,37 @@ func (b Block) Hash() string {
 	return signature.Hash(b.Header)
 }
 
+// Size returns the number of bytes used by the transactions in the block.
+func (b Block) Size() uint64 {
+	if b.MerkleTree == nil {
+		return 0
+	}
+
+	var size uint64
+	for _, tx := range b.MerkleTree.Values() {
+		size += tx.Size()
+	}
+
+	return size
+}
+
+// GasUsed returns the units of gas used by the transactions in the block.
+func (b Block) GasUsed() uint64 {
+	if b.MerkleTree == nil {
+		return 0
+	}
+
+	var gas uint64
+	for _, tx := range b.MerkleTree.Values() {
+		gas += tx.GasUnits
+	}
+
+	return gas
+}
+
 // ValidateBlock takes a block and validates it to be included into the blockchain.
-func (b Block) ValidateBlock(previousBlock Block, stateRoot string, evHandler func(v string, args ...any)) error {
+// The rules are the values the consensus rules expect for this block.
+func (b Block) ValidateBlock(previousBlock Block, stateRoot string, rules BlockRules, evHandler func(v string, args ...any)) error {
 	evHandler("database: ValidateBlock: validate: blk[%d]: check: chain is not forked", b.Header.Number)
 
 	// The node who sent this block has a chain that is two or more blocks ahead
@@ -211,10 +324,22 @@ func (b Block) ValidateBlock(previousBlock Block, stateRoot string, evHandler fu
 		return ErrChainForked
 	}
 
-	evHandler("database: ValidateBlock: validate: blk[%d]: check: block difficulty is the same or greater than parent block difficulty", b.Header.Number)
+	evHandler("database: ValidateBlock: validate: blk[%d]: check: block difficulty matches the retarget rule", b.Header.Number)
+
+	if b.Header.Difficulty != rules.Difficulty {
+		return fmt.Errorf("block difficulty doesn't match the expected difficulty, got %d, exp %d", b.Header.Difficulty, rules.Difficulty)
+	}
+
+	evHandler("database: ValidateBlock: validate: blk[%d]: check: mining reward matches the reward schedule", b.Header.Number)
+
+	if b.Header.MiningReward != rules.MiningReward {
+		return fmt.Errorf("block mining reward doesn't match the reward schedule, got %d, exp %d", b.Header.MiningReward, rules.MiningReward)
+	}
+
+	evHandler("database: ValidateBlock: validate: blk[%d]: check: base fee matches parent block fullness", b.Header.Number)
 
-	if b.Header.Difficulty < previousBlock.Header.Difficulty {
-		return fmt.Errorf("block difficulty is less than previous block difficulty, parent %d, block %d", previousBlock.Header.Difficulty, b.Header.Difficulty)
+	if b.Header.BaseFee != rules.BaseFee {
+		return fmt.Errorf("block base fee doesn't match the expected base fee, got %d, exp %d", b.Header.BaseFee, rules.BaseFee)
 	}
 
 	evHandler("database: ValidateBlock: validate: blk[%d]: check: block hash has been solved", b.Header.Number)
@@ -236,11 +361,17 @@ func (b Block) ValidateBlock(previousBlock Block, stateRoot string, evHandler fu
 		return fmt.Errorf("parent block hash doesn't match our known parent, got %s, exp %s", b.Header.PrevBlockHash, previousBlock.Hash())
 	}
 
+	evHandler("database: ValidateBlock: validate: blk[%d]: check: block's timestamp is not in the future", b.Header.Number)
+
+	blockTime := time.UnixMilli(int64(b.Header.TimeStamp))
+	if maxTime := time.Now().Add(MaxTimeDrift); blockTime.After(maxTime) {
+		return fmt.Errorf("block timestamp is too far in the future, block %s, max %s", blockTime, maxTime)
+	}
+
 	if previousBlock.Header.TimeStamp > 0 {
 		evHandler("database: ValidateBlock: validate: blk[%d]: check: block's timestamp is greater than parent block's timestamp", b.Header.Number)
 
-		parentTime := time.Unix(int64(previousBlock.Header.TimeStamp), 0)
-		blockTime := time.Unix(int64(b.Header.TimeStamp), 0)
+		parentTime := time.UnixMilli(int64(previousBlock.Header.TimeStamp))
 		if blockTime.Before(parentTime) {
 			return fmt.Errorf("block timestamp is before parent block, parent %s, block %s", parentTime, blockTime)
 		}
@@ -266,18 +397,35 @@ func (b Block) ValidateBlock(previousBlock Block, stateRoot string, evHandler fu
 		return fmt.Errorf("merkle root does not match transactions, got %s, exp %s", b.MerkleTree.RootHex(), b.Header.TransRoot)
 	}
 
-	return nil
-}
+	evHandler("database: ValidateBlock: validate: blk[%d]: check: block is within the transaction, size and gas limits", b.Header.Number)
+
+	if trans := uint64(len(b.MerkleTree.Values())); rules.MaxTrans > 0 && trans > rules.MaxTrans {
+		return fmt.Errorf("block has too many transactions, got %d, max %d", trans, rules.MaxTrans)
+	}
+
+	if size := b.Size(); rules.MaxBytes > 0 && size > rules.MaxBytes {
+		return fmt.Errorf("block is too large, got %d bytes, max %d", size, rules.MaxBytes)
+	}
 
-// isHashSolved checks the hash to make sure it complies with
-// the POW rules. We need to match a difficulty number of 0's.
-func isHashSolved(difficulty uint16, hash string) bool {
-	const match = "0x00000000000000000"
+	if gas := b.GasUsed(); rules.MaxGas > 0 && gas > rules.MaxGas {
+		return fmt.Errorf("block uses too much gas, got %d, max %d", gas, rules.MaxGas)
+	}
 
-	if len(hash) != 66 {
-		return false
+	evHandler("database: ValidateBlock: validate: blk[%d]: check: transactions pay for their gas", b.Header.Number)
+
+	for _, tx := range b.MerkleTree.Values() {
+		if err := tx.ValidateGas(rules); err != nil {
+			return fmt.Errorf("tx[%s]: %w", tx, err)
+		}
 	}
 
-	difficulty += 2
-	return hash[:difficulty] == match[:difficulty]
+	evHandler("database: ValidateBlock: validate: blk[%d]: check: transactions have not expired", b.Header.Number)
+
+	for _, tx := range b.MerkleTree.Values() {
+		if tx.IsExpired(b.Header.Number) {
+			return fmt.Errorf("tx[%s]: transaction expired, valid until %d, block %d", tx, tx.ValidUntil, b.Header.Number)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_POWWorkers(t *testing.T) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		block, err := POW(context.Background(), powArgs(workers, 5_000, nil))
+		if err != nil {
+			t.Fatalf("workers[%d]: Should be able to mine the block: %s", workers, err)
+		}
+
+		if !isHashSolved(block.Header.Difficulty, block.Hash()) {
+			t.Fatalf("workers[%d]: Should find a hash that meets the difficulty, got %s", workers, block.Hash())
+		}
+		if block.Header.Number != 1 || block.Header.TransRoot != block.MerkleTree.RootHex() {
+			t.Fatalf("workers[%d]: Should only change the nonce, got %+v", workers, block.Header)
+		}
+	}
+}
+
+func Test_POWCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	// The difficulty can't be solved in the time the test runs.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := POW(ctx, powArgs(8, math.MaxUint64, nil)); err != context.DeadlineExceeded {
+		t.Fatalf("Should stop mining when the context is done, got %v", err)
+	}
+
+	// Every worker is waited on before POW returns.
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("Should stop all the workers, got %d goroutines, had %d", after, before)
+	}
+}
+
+func Test_POWHashRate(t *testing.T) {
+	var mu sync.Mutex
+	var rates []uint64
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Stop mining once the first rate is reported.
+	hashRate := func(rate uint64) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		rates = append(rates, rate)
+		cancel()
+	}
+
+	if _, err := POW(ctx, powArgs(2, math.MaxUint64, hashRate)); err != context.Canceled {
+		t.Fatalf("Should stop mining when the context is cancelled, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(rates) != 1 {
+		t.Fatalf("Should report the hash rate once a second, got %d reports", len(rates))
+	}
+	if rates[0] == 0 || rates[0]%powCheckInterval != 0 {
+		t.Fatalf("Should report the attempts made across the workers, got %d", rates[0])
+	}
+}
+
+// =============================================================================
+
+// powArgs constructs the arguments to mine the first block with a single
+// transaction.
+func powArgs(workers int, difficulty uint64, hashRate func(uint64)) POWArgs {
+	tx := BlockTx{
+		SignedTx: SignedTx{Tx: Tx{ChainID: 1, Nonce: 1, FromID: accountFrom, ToID: accountTo, Value: 10}},
+	}
+
+	return POWArgs{
+		BeneficiaryID: accountBnfc,
+		Difficulty:    difficulty,
+		Trans:         []BlockTx{tx},
+		Workers:       workers,
+		EvHandler:     func(v string, args ...any) {},
+		HashRate:      hashRate,
+	}
+}
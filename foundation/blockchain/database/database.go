@@ -31,22 +31,57 @@ type Iterator interface {
 
 // =============================================================================
 
+// Supply represents the amount of coin in existence on the blockchain.
+type Supply struct {
+	Total  uint64 // Genesis balances plus mining rewards minus burned fees.
+	Minted uint64 // Total of all the mining rewards paid out.
+	Burned uint64 // Total of all the base fees that have been burned.
+}
+
+// BlockRules represents the values the consensus rules require the next
+// block in the chain to be built with.
+type BlockRules struct {
+	Difficulty   uint64
+	MiningReward uint64
+	BaseFee      uint64
+	GasPrice     uint64
+	Gas          genesis.GasSchedule
+	MaxTrans     uint64
+	MaxBytes     uint64
+	MaxGas       uint64
+}
+
+// =============================================================================
+
 // Database manages data related to accounts who have transacted on the blockchain.
 type Database struct {
 	mu          sync.RWMutex
 	genesis     genesis.Genesis
+	fixedDiff   uint64
 	latestBlock Block
 	accounts    map[AccountID]Account
+	hashes      map[string]uint64
+	txHashes    map[string]uint64
+	accountNums map[AccountID][]uint64
+	burned      map[uint64]uint64
+	supply      Supply
 	storage     Storage
 }
 
 // New constructs a new database and applies account genesis information and
-// reads/writes the blockchain database on disk if a dbPath is provided.
-func New(genesis genesis.Genesis, storage Storage, evHandler func(v string, args ...any)) (*Database, error) {
+// reads/writes the blockchain database on disk if a dbPath is provided. A
+// fixed difficulty greater than 0 replaces the retarget rule, which is what
+// a PoA network uses since its blocks don't need to be hard to find.
+func New(genesis genesis.Genesis, storage Storage, fixedDifficulty uint64, evHandler func(v string, args ...any)) (*Database, error) {
 	db := Database{
-		genesis:  genesis,
-		accounts: make(map[AccountID]Account),
-		storage:  storage,
+		genesis:     genesis,
+		fixedDiff:   fixedDifficulty,
+		accounts:    make(map[AccountID]Account),
+		hashes:      make(map[string]uint64),
+		txHashes:    make(map[string]uint64),
+		accountNums: make(map[AccountID][]uint64),
+		burned:      make(map[uint64]uint64),
+		storage:     storage,
 	}
 
 	// Update the database with account balance information from genesis.
@@ -56,6 +91,7 @@ func New(genesis genesis.Genesis, storage Storage, evHandler func(v string, args
 			return nil, err
 		}
 		db.accounts[accountID] = newAccount(accountID, balance)
+		db.supply.Total += balance
 	}
 
 	// Read all the blocks from storage.
@@ -65,8 +101,14 @@ func New(genesis genesis.Genesis, storage Storage, evHandler func(v string, args
 			return nil, err
 		}
 
+		// Calculate the values this block needed to be built with.
+		rules, err := db.NextBlockRules(db.latestBlock)
+		if err != nil {
+			return nil, err
+		}
+
 		// Validate the block values and cryptographic audit trail.
-		if err := block.ValidateBlock(db.latestBlock, db.HashState(), evHandler); err != nil {
+		if err := block.ValidateBlock(db.latestBlock, db.HashState(), rules, evHandler); err != nil {
 			return nil, err
 		}
 
@@ -78,6 +120,7 @@ func New(genesis genesis.Genesis, storage Storage, evHandler func(v string, args
 
 		// Update the current latest block.
 		db.latestBlock = block
+		db.index(block)
 	}
 
 	return &db, nil
@@ -109,6 +152,82 @@ func (db *Database) LatestBlock() Block {
 	return db.latestBlock
 }
 
+// Supply returns the current supply information for the blockchain.
+func (db *Database) Supply() Supply {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.supply
+}
+
+// NextBlockRules returns the values the block following the specified block
+// must be built with based on the settings in genesis.
+func (db *Database) NextBlockRules(prevBlock Block) (BlockRules, error) {
+	difficulty, err := db.nextDifficulty(prevBlock)
+	if err != nil {
+		return BlockRules{}, err
+	}
+
+	// The base fee tracks how full blocks are by gas when there is a gas limit
+	// and by the number of transactions when there isn't.
+	capacity, used := uint64(db.genesis.TransPerBlock), uint64(0)
+	if prevBlock.MerkleTree != nil {
+		used = uint64(len(prevBlock.MerkleTree.Values()))
+	}
+	if db.genesis.MaxBlockGas > 0 {
+		capacity, used = db.genesis.MaxBlockGas, prevBlock.GasUsed()
+	}
+
+	rules := BlockRules{
+		Difficulty: difficulty,
+		MiningReward: CalcMiningReward(RewardArgs{
+			InitialReward:   db.genesis.MiningReward,
+			HalvingInterval: db.genesis.HalvingInterval,
+			TailEmission:    db.genesis.TailEmission,
+			Number:          prevBlock.Header.Number + 1,
+		}),
+		BaseFee: CalcBaseFee(BaseFeeArgs{
+			InitialBaseFee: db.genesis.BaseFee,
+			Capacity:       capacity,
+			Used:           used,
+			PrevBlock:      prevBlock,
+		}),
+		GasPrice: db.genesis.GasPrice,
+		Gas:      db.genesis.Gas,
+		MaxTrans: uint64(db.genesis.TransPerBlock),
+		MaxBytes: db.genesis.MaxBlockBytes,
+		MaxGas:   db.genesis.MaxBlockGas,
+	}
+
+	return rules, nil
+}
+
+// nextDifficulty returns the difficulty the block following the specified
+// block must be mined with based on the retarget settings in genesis.
+func (db *Database) nextDifficulty(prevBlock Block) (uint64, error) {
+	if db.fixedDiff > 0 {
+		return db.fixedDiff, nil
+	}
+
+	args := RetargetArgs{
+		InitialDifficulty: db.genesis.Difficulty,
+		TargetBlockTime:   db.genesis.TargetBlockTime,
+		RetargetInterval:  db.genesis.RetargetInterval,
+		PrevBlock:         prevBlock,
+	}
+
+	// Only a retarget needs the first block of the interval that is ending.
+	if IsRetargetBlock(prevBlock.Header.Number+1, db.genesis.RetargetInterval) {
+		firstBlock, err := db.GetBlock(prevBlock.Header.Number - db.genesis.RetargetInterval + 1)
+		if err != nil {
+			return 0, err
+		}
+		args.FirstBlock = firstBlock
+	}
+
+	return CalcDifficulty(args), nil
+}
+
 // HashState returns a hash based on the contents of the accounts and
 // their balances. This is added to each block and checked by peers.
 func (db *Database) HashState() string {
@@ -136,6 +255,78 @@ func (db *Database) UpdateLatestBlock(block Block) {
 	defer db.mu.Unlock()
 
 	db.latestBlock = block
+	db.index(block)
+}
+
+// BlockNumberByHash returns the number of the block with the specified hash
+// from the index of block hashes kept in memory.
+func (db *Database) BlockNumberByHash(hash string) (uint64, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	num, exists := db.hashes[hash]
+	return num, exists
+}
+
+// BlockNumberByTxHash returns the number of the block the transaction with
+// the specified hash was mined in from the index of transaction hashes kept
+// in memory.
+func (db *Database) BlockNumberByTxHash(hash string) (uint64, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	num, exists := db.txHashes[hash]
+	return num, exists
+}
+
+// BlockNumbersByAccount returns the numbers of the blocks inside the range
+// with a transaction sent from or to the account, in number order, from the
+// index of accounts kept in memory.
+func (db *Database) BlockNumbersByAccount(accountID AccountID, from uint64, to uint64) []uint64 {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	nums := db.accountNums[accountID]
+	start := sort.Search(len(nums), func(i int) bool { return nums[i] >= from })
+	end := sort.Search(len(nums), func(i int) bool { return nums[i] > to })
+	if start >= end {
+		return nil
+	}
+
+	out := make([]uint64, end-start)
+	copy(out, nums[start:end])
+
+	return out
+}
+
+// BurnedByBlock returns the base fees burned by the transactions in the
+// block with the specified number. The burn is less than the gas used times
+// the base fee when a sender couldn't pay for all of its gas.
+func (db *Database) BurnedByBlock(num uint64) uint64 {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.burned[num]
+}
+
+// index adds the hash of the block, the hashes of its transactions and the
+// accounts sending and receiving them to the indexes. Blocks are indexed in
+// number order, so the block numbers for an account stay sorted. The caller
+// must hold the write lock or own the database.
+func (db *Database) index(block Block) {
+	num := block.Header.Number
+
+	db.hashes[block.Hash()] = num
+	for _, tx := range block.MerkleTree.Values() {
+		db.txHashes[signature.Hash(tx)] = num
+
+		for _, accountID := range []AccountID{tx.FromID, tx.ToID} {
+			nums := db.accountNums[accountID]
+			if len(nums) == 0 || nums[len(nums)-1] != num {
+				db.accountNums[accountID] = append(nums, num)
+			}
+		}
+	}
 }
 
 // ApplyMiningReward gives the specififed account the mining reward.
@@ -147,6 +338,9 @@ func (db *Database) ApplyMiningReward(block Block) {
 	account.Balance += block.Header.MiningReward
 
 	db.accounts[block.Header.BeneficiaryID] = account
+
+	db.supply.Total += block.Header.MiningReward
+	db.supply.Minted += block.Header.MiningReward
 }
 
 // ApplyTransaction performs the business logic for applying a transaction
@@ -172,15 +366,37 @@ func (db *Database) ApplyTransaction(block Block, tx BlockTx) error {
 			bnfc = newAccount(block.Header.BeneficiaryID, 0)
 		}
 
-		// The account needs to pay the gas fee regardless. Take the
-		// remaining balance if the account doesn't hold enough for the
-		// full amount of gas. This is the only way to stop bad actors.
-		gasFee := tx.GasPrice * tx.GasUnits
-		if gasFee > from.Balance {
-			gasFee = from.Balance
+		// The account buys the full gas limit up front at the price of gas
+		// plus the base fee. Take the remaining balance if the account doesn't
+		// hold enough for the full amount of gas. This is the only way to
+		// stop bad actors. The math can't wrap around, so a huge gas limit
+		// can't make the gas cheap.
+		price := addGas(tx.GasPrice, block.Header.BaseFee)
+		bought := mulGas(tx.GasLimit, price)
+		if bought > from.Balance {
+			bought = from.Balance
+		}
+		from.Balance -= bought
+
+		// Charge for the gas that was used and refund the unused gas.
+		used := mulGas(tx.GasUnits, price)
+		if used > bought {
+			used = bought
+		}
+		from.Balance += bought - used
+
+		// The beneficiary is paid the gas fee and the base fee is burned
+		// and taken out of the supply.
+		gasFee := mulGas(tx.GasUnits, tx.GasPrice)
+		if gasFee > used {
+			gasFee = used
 		}
-		from.Balance -= gasFee
+		burnFee := used - gasFee
+
 		bnfc.Balance += gasFee
+		db.supply.Total -= burnFee
+		db.supply.Burned += burnFee
+		db.burned[block.Header.Number] += burnFee
 
 		// Make sure these changes get applied.
 		db.accounts[tx.FromID] = from
@@ -246,6 +462,11 @@ func (db *Database) Reset() error {
 	// Initializes the database back to the genesis information.
 	db.latestBlock = Block{}
 	db.accounts = make(map[AccountID]Account)
+	db.hashes = make(map[string]uint64)
+	db.txHashes = make(map[string]uint64)
+	db.accountNums = make(map[AccountID][]uint64)
+	db.burned = make(map[uint64]uint64)
+	db.supply = Supply{}
 	for accountStr, balance := range db.genesis.Balances {
 		accountID, err := ToAccountID(accountStr)
 		if err != nil {
@@ -253,6 +474,7 @@ func (db *Database) Reset() error {
 		}
 
 		db.accounts[accountID] = newAccount(accountID, balance)
+		db.supply.Total += balance
 	}
 
 	return nil
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"math"
+	"math/big"
+)
+
+// CORE NOTE: Difficulty is represented the same way Ethereum does it. The hash
+// of a block header, read as a 256 bit number, must be less than or equal to
+// 2^256 / difficulty. Doubling the difficulty halves the target which doubles
+// the expected amount of work. This gives much finer control than counting
+// leading hex zeros where every step is a 16x jump in work.
+
+// maxRetargetFactor limits how far the difficulty can move in a single
+// adjustment so a few odd blocks can't swing the network too far.
+const maxRetargetFactor = 4
+
+// maxTarget represents 2^256 which is the target when the difficulty is 1.
+var maxTarget = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// =============================================================================
+
+// RetargetArgs represents the set of arguments required to calculate the
+// difficulty of the next block.
+type RetargetArgs struct {
+	InitialDifficulty uint64 // Difficulty for the first block in the chain.
+	TargetBlockTime   uint64 // Seconds the network is targeting between blocks.
+	RetargetInterval  uint64 // Number of blocks between each adjustment.
+	PrevBlock         Block  // The block the new block will be built on.
+	FirstBlock        Block  // The first block of the interval ending with PrevBlock.
+}
+
+// IsRetargetBlock reports if the block with the specified number is the
+// first block of a new retarget interval.
+func IsRetargetBlock(number uint64, interval uint64) bool {
+	if interval < 2 || number <= interval {
+		return false
+	}
+
+	return (number-1)%interval == 0
+}
+
+// CalcDifficulty determines the difficulty the next block must be mined with.
+// Every RetargetInterval blocks the difficulty is adjusted by the ratio of the
+// time the network should have taken over the time it actually took to mine
+// the last interval of blocks.
+func CalcDifficulty(args RetargetArgs) uint64 {
+	if args.PrevBlock.Header.Number == 0 {
+		return args.InitialDifficulty
+	}
+
+	prevDifficulty := args.PrevBlock.Header.Difficulty
+
+	number := args.PrevBlock.Header.Number + 1
+	if args.TargetBlockTime == 0 || !IsRetargetBlock(number, args.RetargetInterval) {
+		return prevDifficulty
+	}
+
+	// The timestamps are in milliseconds. There are interval-1 gaps between
+	// the first and last block of the interval.
+	expected := (args.RetargetInterval - 1) * args.TargetBlockTime * 1000
+	actual := uint64(1)
+	if args.PrevBlock.Header.TimeStamp > args.FirstBlock.Header.TimeStamp {
+		actual = args.PrevBlock.Header.TimeStamp - args.FirstBlock.Header.TimeStamp
+	}
+
+	// Keep the adjustment within the allowed factor in both directions.
+	switch {
+	case actual < expected/maxRetargetFactor:
+		actual = expected / maxRetargetFactor
+	case actual > expected*maxRetargetFactor:
+		actual = expected * maxRetargetFactor
+	}
+
+	next := new(big.Int).SetUint64(prevDifficulty)
+	next.Mul(next, new(big.Int).SetUint64(expected))
+	next.Div(next, new(big.Int).SetUint64(actual))
+
+	switch {
+	case next.Sign() == 0:
+		return 1
+	case !next.IsUint64():
+		return math.MaxUint64
+	}
+
+	return next.Uint64()
+}
+
+// =============================================================================
+
+// isHashSolved checks the hash to make sure it complies with the POW rules.
+// The hash must be less than or equal to the target for the difficulty.
+func isHashSolved(difficulty uint64, hash string) bool {
+	if len(hash) != 66 {
+		return false
+	}
+
+	h, ok := new(big.Int).SetString(hash[2:], 16)
+	if !ok {
+		return false
+	}
+
+	return h.Cmp(difficultyTarget(difficulty)) <= 0
+}
+
+// difficultyTarget returns the 256 bit target a hash must not exceed for
+// the specified difficulty.
+func difficultyTarget(difficulty uint64) *big.Int {
+	if difficulty <= 1 {
+		return new(big.Int).Set(maxTarget)
+	}
+
+	return new(big.Int).Div(maxTarget, new(big.Int).SetUint64(difficulty))
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/genesis"
+)
+
+func Test_IsRetargetBlock(t *testing.T) {
+	tt := []struct {
+		name     string
+		number   uint64
+		interval uint64
+		exp      bool
+	}{
+		{"first-retarget", 11, 10, true},
+		{"second-retarget", 21, 10, true},
+		{"last-of-interval", 10, 10, false},
+		{"inside-interval", 12, 10, false},
+		{"first-block", 1, 10, false},
+		{"zero-interval", 5, 0, false},
+		{"interval-of-one", 5, 1, false},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			if got := IsRetargetBlock(tst.number, tst.interval); got != tst.exp {
+				t.Errorf("Should get %v for block %d with interval %d, got %v", tst.exp, tst.number, tst.interval, got)
+			}
+		})
+	}
+}
+
+func Test_CalcDifficulty(t *testing.T) {
+	const (
+		interval   = 10
+		blockTime  = 10
+		expectedMS = (interval - 1) * blockTime * 1000
+	)
+
+	// retarget constructs the args for the first block of the second interval
+	// where the last interval took the specified milliseconds to mine.
+	retarget := func(difficulty uint64, actualMS uint64) RetargetArgs {
+		return RetargetArgs{
+			InitialDifficulty: 100,
+			TargetBlockTime:   blockTime,
+			RetargetInterval:  interval,
+			PrevBlock:         Block{Header: BlockHeader{Number: interval, Difficulty: difficulty, TimeStamp: 1_000 + actualMS}},
+			FirstBlock:        Block{Header: BlockHeader{Number: 1, Difficulty: difficulty, TimeStamp: 1_000}},
+		}
+	}
+
+	// with changes a copy of the args for the cases that aren't a retarget.
+	with := func(args RetargetArgs, change func(*RetargetArgs)) RetargetArgs {
+		change(&args)
+		return args
+	}
+
+	tt := []struct {
+		name string
+		args RetargetArgs
+		exp  uint64
+	}{
+		{"genesis", RetargetArgs{InitialDifficulty: 100}, 100},
+		{"on-time", retarget(1_000, expectedMS), 1_000},
+		{"twice-as-fast", retarget(1_000, expectedMS/2), 2_000},
+		{"twice-as-slow", retarget(1_000, expectedMS*2), 500},
+		{"clamp-fast", retarget(1_000, 1), 1_000 * maxRetargetFactor},
+		{"clamp-slow", retarget(1_000, expectedMS*100), 1_000 / maxRetargetFactor},
+		{"clamp-backwards-time", retarget(1_000, 0), 1_000 * maxRetargetFactor},
+		{"never-zero", retarget(1, expectedMS*maxRetargetFactor), 1},
+		{"never-overflow", retarget(math.MaxUint64, 1), math.MaxUint64},
+		{"inside-interval", with(retarget(1_000, 1), func(a *RetargetArgs) { a.PrevBlock.Header.Number++ }), 1_000},
+		{"zero-interval", with(retarget(1_000, 1), func(a *RetargetArgs) { a.RetargetInterval = 0 }), 1_000},
+		{"zero-block-time", with(retarget(1_000, 1), func(a *RetargetArgs) { a.TargetBlockTime = 0 }), 1_000},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			if got := CalcDifficulty(tst.args); got != tst.exp {
+				t.Errorf("Should get a difficulty of %d, got %d", tst.exp, got)
+			}
+		})
+	}
+}
+
+func Test_DifficultyTarget(t *testing.T) {
+	half := new(big.Int).Rsh(maxTarget, 1)
+
+	tt := []struct {
+		name       string
+		difficulty uint64
+		exp        *big.Int
+	}{
+		{"zero", 0, maxTarget},
+		{"one", 1, maxTarget},
+		{"two", 2, half},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			if got := difficultyTarget(tst.difficulty); got.Cmp(tst.exp) != 0 {
+				t.Errorf("Should get a target of %x, got %x", tst.exp, got)
+			}
+		})
+	}
+}
+
+func Test_IsHashSolved(t *testing.T) {
+	tt := []struct {
+		name       string
+		difficulty uint64
+		hash       string
+		exp        bool
+	}{
+		{"any-hash-at-one", 1, "0x" + strings.Repeat("f", 64), true},
+		{"any-hash-at-zero", 0, "0x" + strings.Repeat("f", 64), true},
+		{"equal-to-target", 2, "0x8" + strings.Repeat("0", 63), true},
+		{"above-target", 2, "0x8" + strings.Repeat("0", 62) + "1", false},
+		{"below-target", 2, "0x7" + strings.Repeat("f", 63), true},
+		{"short-hash", 1, "0x" + strings.Repeat("0", 63), false},
+		{"not-hex", 1, "0x" + strings.Repeat("z", 64), false},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			if got := isHashSolved(tst.difficulty, tst.hash); got != tst.exp {
+				t.Errorf("Should get %v for hash %s at difficulty %d, got %v", tst.exp, tst.hash, tst.difficulty, got)
+			}
+		})
+	}
+}
+
+func Test_FixedDifficulty(t *testing.T) {
+	db := Database{
+		genesis: genesis.Genesis{
+			Difficulty:       1_000,
+			TargetBlockTime:  10,
+			RetargetInterval: 10,
+		},
+		fixedDiff: 1,
+	}
+
+	prevBlock := Block{Header: BlockHeader{Number: 10, Difficulty: 1}}
+
+	rules, err := db.NextBlockRules(prevBlock)
+	if err != nil {
+		t.Fatalf("Should be able to calculate the rules: %s", err)
+	}
+
+	if rules.Difficulty != 1 {
+		t.Errorf("Should mine a PoA block with a difficulty of 1, got %d", rules.Difficulty)
+	}
+}
+
+func Test_ValidateBlockTimeStamp(t *testing.T) {
+	now := time.Now()
+	prevBlock := Block{Header: BlockHeader{Number: 1, TimeStamp: uint64(now.Add(-time.Minute).UnixMilli())}}
+
+	tt := []struct {
+		name      string
+		timeStamp time.Time
+		exp       string
+	}{
+		{"future", now.Add(2 * MaxTimeDrift), "future"},
+		{"before-parent", now.Add(-2 * time.Minute), "before parent"},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			block := Block{
+				Header: BlockHeader{
+					Number:        2,
+					PrevBlockHash: prevBlock.Hash(),
+					TimeStamp:     uint64(tst.timeStamp.UnixMilli()),
+					Difficulty:    1,
+				},
+			}
+
+			rules := BlockRules{Difficulty: 1}
+			ev := func(v string, args ...any) {}
+
+			err := block.ValidateBlock(prevBlock, "", rules, ev)
+			if err == nil || !strings.Contains(err.Error(), tst.exp) {
+				t.Errorf("Should reject the block timestamp with %q, got %v", tst.exp, err)
+			}
+		})
+	}
+}
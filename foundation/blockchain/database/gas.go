@@ -0,0 +1,87 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/genesis"
+)
+
+// CORE NOTE: Gas is what stops someone from stuffing megabytes of data into a
+// transaction for the same fee as a plain transfer. Every transaction pays a
+// base cost, a cost for moving value and a cost for each byte of data. Since
+// there is no execution engine yet, the gas used by a transaction is exactly
+// its intrinsic cost. The sender specifies the most gas they are willing to
+// buy and the most they are willing to pay per unit of gas. Any unused gas
+// is refunded after the transaction is applied.
+
+// IntrinsicGas calculates the units of gas the transaction will use based on
+// the specified gas schedule.
+func IntrinsicGas(tx Tx, gas genesis.GasSchedule) uint64 {
+	units := gas.TxBase
+
+	if tx.Value > 0 {
+		units += gas.Transfer
+	}
+
+	for _, b := range tx.Data {
+		switch b {
+		case 0:
+			units += gas.DataZeroByte
+		default:
+			units += gas.DataNonZeroByte
+		}
+	}
+
+	return units
+}
+
+// ValidateGas checks the gas units recorded for the transaction match the
+// schedule, the gas price is the price set in genesis, the gas limit covers
+// those units without going over the block gas limit and the max gas price
+// covers the price per unit of gas plus the base fee.
+func (tx BlockTx) ValidateGas(rules BlockRules) error {
+	if units := IntrinsicGas(tx.Tx, rules.Gas); tx.GasUnits != units {
+		return fmt.Errorf("transaction gas units don't match the gas schedule, got %d, exp %d", tx.GasUnits, units)
+	}
+
+	if tx.GasPrice != rules.GasPrice {
+		return fmt.Errorf("transaction gas price doesn't match the price of gas, got %d, exp %d", tx.GasPrice, rules.GasPrice)
+	}
+
+	if tx.GasLimit < tx.GasUnits {
+		return fmt.Errorf("transaction gas limit is below the intrinsic cost, limit %d, needed %d", tx.GasLimit, tx.GasUnits)
+	}
+
+	if rules.MaxGas > 0 && tx.GasLimit > rules.MaxGas {
+		return fmt.Errorf("transaction gas limit is above the block gas limit, limit %d, max %d", tx.GasLimit, rules.MaxGas)
+	}
+
+	price, carry := bits.Add64(tx.GasPrice, rules.BaseFee, 0)
+	if carry != 0 || tx.MaxGasPrice < price {
+		return fmt.Errorf("transaction max gas price is below the price of gas, max %d, needed %d+%d", tx.MaxGasPrice, tx.GasPrice, rules.BaseFee)
+	}
+
+	return nil
+}
+
+// mulGas multiplies the units of gas by the price. A product that doesn't
+// fit in 64 bits returns the max value, which is more than any balance.
+func mulGas(units uint64, price uint64) uint64 {
+	hi, lo := bits.Mul64(units, price)
+	if hi != 0 {
+		return math.MaxUint64
+	}
+	return lo
+}
+
+// addGas adds the two amounts. A sum that doesn't fit in 64 bits returns the
+// max value, which is more than any balance.
+func addGas(a uint64, b uint64) uint64 {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 {
+		return math.MaxUint64
+	}
+	return sum
+}
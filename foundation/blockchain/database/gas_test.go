@@ -0,0 +1,172 @@
+package database
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/genesis"
+)
+
+const (
+	accountFrom = AccountID("0xF01813E4B85e178A83e29B8E7bF26BD830a25f32")
+	accountTo   = AccountID("0xdd6B972ffcc631a62CAE1BB9d80b7ff429c8ebA4")
+	accountBnfc = AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+)
+
+var gasSchedule = genesis.GasSchedule{
+	TxBase:          21,
+	Transfer:        9,
+	DataZeroByte:    1,
+	DataNonZeroByte: 4,
+}
+
+func Test_ValidateGas(t *testing.T) {
+	rules := BlockRules{
+		BaseFee:  5,
+		GasPrice: 15,
+		Gas:      gasSchedule,
+		MaxGas:   400,
+	}
+
+	// gasTx constructs a transfer that pays for its gas and then lets the
+	// case change it.
+	gasTx := func(change func(tx *BlockTx)) BlockTx {
+		tx := BlockTx{
+			SignedTx: SignedTx{Tx: Tx{Value: 1, GasLimit: 100, MaxGasPrice: 20}},
+			GasPrice: 15,
+			GasUnits: 30,
+		}
+		change(&tx)
+		return tx
+	}
+
+	tt := []struct {
+		name  string
+		rules BlockRules
+		tx    BlockTx
+		exp   string
+	}{
+		{"valid", rules, gasTx(func(tx *BlockTx) {}), ""},
+		{"wrong-units", rules, gasTx(func(tx *BlockTx) { tx.GasUnits = 21 }), "gas schedule"},
+		{"wrong-price", rules, gasTx(func(tx *BlockTx) { tx.GasPrice = 1 }), "price of gas, got"},
+		{"limit-below-units", rules, gasTx(func(tx *BlockTx) { tx.GasLimit = 29 }), "below the intrinsic cost"},
+		{"limit-at-block-limit", rules, gasTx(func(tx *BlockTx) { tx.GasLimit = 400 }), ""},
+		{"limit-above-block-limit", rules, gasTx(func(tx *BlockTx) { tx.GasLimit = 401 }), "above the block gas limit"},
+		{"huge-limit", rules, gasTx(func(tx *BlockTx) { tx.GasLimit = math.MaxUint64 }), "above the block gas limit"},
+		{"no-block-limit", BlockRules{BaseFee: 5, GasPrice: 15, Gas: gasSchedule}, gasTx(func(tx *BlockTx) { tx.GasLimit = math.MaxUint64 }), ""},
+		{"max-price-below", rules, gasTx(func(tx *BlockTx) { tx.MaxGasPrice = 19 }), "max gas price"},
+		{"price-overflow", BlockRules{BaseFee: math.MaxUint64, GasPrice: 15, Gas: gasSchedule}, gasTx(func(tx *BlockTx) { tx.MaxGasPrice = math.MaxUint64 }), "max gas price"},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			err := tst.tx.ValidateGas(tst.rules)
+
+			switch {
+			case tst.exp == "" && err != nil:
+				t.Errorf("Should accept the gas: %s", err)
+			case tst.exp != "" && (err == nil || !strings.Contains(err.Error(), tst.exp)):
+				t.Errorf("Should reject the gas with %q, got %v", tst.exp, err)
+			}
+		})
+	}
+}
+
+func Test_ApplyTransactionGas(t *testing.T) {
+	const (
+		balance  = 10_000
+		gasPrice = 15
+		baseFee  = 5
+		gasUnits = 30
+		value    = 100
+		tip      = 10
+	)
+
+	tt := []struct {
+		name     string
+		gasLimit uint64
+	}{
+		{"refund-unused", 100},
+		{"limit-above-balance", 1_000},
+		{"limit-wraps-price", math.MaxUint64/4 + 1},
+		{"max-limit", math.MaxUint64},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			db := Database{
+				accounts: map[AccountID]Account{
+					accountFrom: newAccount(accountFrom, balance),
+				},
+				burned: make(map[uint64]uint64),
+				supply: Supply{Total: balance},
+			}
+
+			block := Block{Header: BlockHeader{Number: 1, BeneficiaryID: accountBnfc, BaseFee: baseFee}}
+			tx := BlockTx{
+				SignedTx: SignedTx{Tx: Tx{Nonce: 1, FromID: accountFrom, ToID: accountTo, Value: value, Tip: tip, GasLimit: tst.gasLimit}},
+				GasPrice: gasPrice,
+				GasUnits: gasUnits,
+			}
+
+			if err := db.ApplyTransaction(block, tx); err != nil {
+				t.Fatalf("Should be able to apply the transaction: %s", err)
+			}
+
+			// Only the gas that was used is paid for, no matter the limit.
+			used := uint64(gasUnits * (gasPrice + baseFee))
+			if got, exp := db.accounts[accountFrom].Balance, balance-used-value-tip; got != exp {
+				t.Errorf("Should charge the sender for the gas used, got %d, exp %d", got, exp)
+			}
+			if got, exp := db.accounts[accountBnfc].Balance, uint64(gasUnits*gasPrice+tip); got != exp {
+				t.Errorf("Should pay the beneficiary the gas fee and tip, got %d, exp %d", got, exp)
+			}
+			if got, exp := db.accounts[accountTo].Balance, uint64(value); got != exp {
+				t.Errorf("Should pay the receiver the value, got %d, exp %d", got, exp)
+			}
+			if got, exp := db.supply.Burned, uint64(gasUnits*baseFee); got != exp {
+				t.Errorf("Should burn the base fee, got %d, exp %d", got, exp)
+			}
+			if got := db.BurnedByBlock(1); got != db.supply.Burned {
+				t.Errorf("Should record the burn for the block, got %d, exp %d", got, db.supply.Burned)
+			}
+		})
+	}
+}
+
+func Test_ApplyTransactionGasShortBalance(t *testing.T) {
+	db := Database{
+		accounts: map[AccountID]Account{
+			accountFrom: newAccount(accountFrom, 100),
+		},
+		burned: make(map[uint64]uint64),
+		supply: Supply{Total: 100},
+	}
+
+	block := Block{Header: BlockHeader{Number: 1, BeneficiaryID: accountBnfc, BaseFee: 5}}
+	tx := BlockTx{
+		SignedTx: SignedTx{Tx: Tx{Nonce: 1, FromID: accountFrom, ToID: accountTo, Value: 10, GasLimit: math.MaxUint64}},
+		GasPrice: 15,
+		GasUnits: 30,
+	}
+
+	if err := db.ApplyTransaction(block, tx); err == nil {
+		t.Fatal("Should reject a transaction that can't pay for its gas")
+	}
+
+	// The balance only covers part of the gas, which is taken and split
+	// between the beneficiary and the burn.
+	if got := db.accounts[accountFrom].Balance; got != 0 {
+		t.Errorf("Should take the remaining balance for gas, got %d", got)
+	}
+	if got, exp := db.accounts[accountBnfc].Balance+db.supply.Burned, uint64(100); got != exp {
+		t.Errorf("Should pay out all the gas that was taken, got %d, exp %d", got, exp)
+	}
+
+	// The block records the clamped burn, not the gas used times the base
+	// fee, so the stats agree with the supply.
+	if got := db.BurnedByBlock(1); got != db.supply.Burned || got >= tx.GasUnits*block.Header.BaseFee {
+		t.Errorf("Should record the clamped burn for the block, got %d, supply burned %d", got, db.supply.Burned)
+	}
+}
@@ -0,0 +1,92 @@
+package database
+
+// CORE NOTE: The base fee follows the rules of Ethereum's EIP-1559. Each block
+// has a target capacity of half its maximum. When the parent block was fuller
+// than the target, the base fee goes up by at most 12.5% and when it was
+// emptier, it goes down by at most 12.5%. The base fee is burned and not paid
+// to the beneficiary, so it can't be gamed by a miner stuffing blocks with
+// their own transactions. When there is a gas limit, the target is half the
+// limit. The limit must be set so a block full of transactions uses more than
+// half of it, otherwise the base fee can never go up. With the gas schedule in
+// the genesis file, a full block of 10 transfers uses 300 units of gas, so the
+// limit is 400.
+
+// baseFeeChangeDenominator bounds the amount the base fee can change between
+// blocks to 1/8 (12.5%).
+const baseFeeChangeDenominator = 8
+
+// =============================================================================
+
+// RewardArgs represents the set of arguments required to calculate the
+// reward for mining a block.
+type RewardArgs struct {
+	InitialReward   uint64 // Reward for mining the first block in the chain.
+	HalvingInterval uint64 // Number of blocks between each halving, 0 never halves.
+	TailEmission    uint64 // Reward that is paid once halving drops below it.
+	Number          uint64 // Number of the block being mined.
+}
+
+// CalcMiningReward determines the reward for mining the specified block. The
+// reward is cut in half every HalvingInterval blocks until it reaches the
+// tail emission.
+func CalcMiningReward(args RewardArgs) uint64 {
+	if args.HalvingInterval == 0 || args.Number == 0 {
+		return args.InitialReward
+	}
+
+	reward := uint64(0)
+	if halvings := (args.Number - 1) / args.HalvingInterval; halvings < 64 {
+		reward = args.InitialReward >> halvings
+	}
+
+	if reward < args.TailEmission {
+		return args.TailEmission
+	}
+
+	return reward
+}
+
+// =============================================================================
+
+// BaseFeeArgs represents the set of arguments required to calculate the
+// base fee for a block.
+type BaseFeeArgs struct {
+	InitialBaseFee uint64 // Base fee for the first block, 0 disables the base fee.
+	Capacity       uint64 // Maximum capacity of a block.
+	Used           uint64 // Capacity used by the previous block.
+	PrevBlock      Block  // The block the new block will be built on.
+}
+
+// CalcBaseFee determines the base fee per unit of gas for the block following
+// the previous block based on how full the previous block was.
+func CalcBaseFee(args BaseFeeArgs) uint64 {
+	if args.InitialBaseFee == 0 {
+		return 0
+	}
+
+	if args.PrevBlock.Header.Number == 0 {
+		return args.InitialBaseFee
+	}
+
+	target := args.Capacity / 2
+	if target == 0 {
+		return args.PrevBlock.Header.BaseFee
+	}
+
+	baseFee := args.PrevBlock.Header.BaseFee
+
+	switch {
+	case args.Used > target:
+		delta := baseFee * (args.Used - target) / target / baseFeeChangeDenominator
+		if delta == 0 {
+			delta = 1
+		}
+		return baseFee + delta
+
+	case args.Used < target:
+		delta := baseFee * (target - args.Used) / target / baseFeeChangeDenominator
+		return baseFee - delta
+	}
+
+	return baseFee
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"testing"
+)
+
+func Test_CalcMiningReward(t *testing.T) {
+	const (
+		initial  = 700
+		interval = 10
+		tail     = 10
+	)
+
+	tt := []struct {
+		name string
+		args RewardArgs
+		exp  uint64
+	}{
+		{"never-halves", RewardArgs{InitialReward: initial, Number: 1_000}, initial},
+		{"genesis", RewardArgs{InitialReward: initial, HalvingInterval: interval, Number: 0}, initial},
+		{"first-block", RewardArgs{InitialReward: initial, HalvingInterval: interval, Number: 1}, initial},
+		{"last-before-halving", RewardArgs{InitialReward: initial, HalvingInterval: interval, Number: interval}, initial},
+		{"first-halving", RewardArgs{InitialReward: initial, HalvingInterval: interval, Number: interval + 1}, initial / 2},
+		{"second-halving", RewardArgs{InitialReward: initial, HalvingInterval: interval, Number: 2*interval + 1}, initial / 4},
+		{"at-tail", RewardArgs{InitialReward: initial, HalvingInterval: interval, TailEmission: tail, Number: 6*interval + 1}, tail},
+		{"below-tail", RewardArgs{InitialReward: initial, HalvingInterval: interval, TailEmission: tail, Number: 7*interval + 1}, tail},
+		{"past-64-halvings", RewardArgs{InitialReward: initial, HalvingInterval: interval, TailEmission: tail, Number: 100*interval + 1}, tail},
+		{"no-tail", RewardArgs{InitialReward: initial, HalvingInterval: interval, Number: 100*interval + 1}, 0},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			if got := CalcMiningReward(tst.args); got != tst.exp {
+				t.Errorf("Should get a reward of %d, got %d", tst.exp, got)
+			}
+		})
+	}
+}
+
+func Test_CalcBaseFee(t *testing.T) {
+	// prev constructs the previous block with the specified base fee.
+	prev := func(baseFee uint64) Block {
+		return Block{Header: BlockHeader{Number: 5, BaseFee: baseFee}}
+	}
+
+	tt := []struct {
+		name string
+		args BaseFeeArgs
+		exp  uint64
+	}{
+		{"disabled", BaseFeeArgs{InitialBaseFee: 0, Capacity: 600, Used: 600, PrevBlock: prev(800)}, 0},
+		{"genesis", BaseFeeArgs{InitialBaseFee: 5, Capacity: 600, Used: 600, PrevBlock: Block{}}, 5},
+		{"no-target", BaseFeeArgs{InitialBaseFee: 5, Capacity: 1, Used: 1, PrevBlock: prev(800)}, 800},
+		{"at-target", BaseFeeArgs{InitialBaseFee: 5, Capacity: 600, Used: 300, PrevBlock: prev(800)}, 800},
+		{"full", BaseFeeArgs{InitialBaseFee: 5, Capacity: 600, Used: 600, PrevBlock: prev(800)}, 800 + 800/baseFeeChangeDenominator},
+		{"empty", BaseFeeArgs{InitialBaseFee: 5, Capacity: 600, Used: 0, PrevBlock: prev(800)}, 800 - 800/baseFeeChangeDenominator},
+		{"half-step-up", BaseFeeArgs{InitialBaseFee: 5, Capacity: 600, Used: 450, PrevBlock: prev(800)}, 850},
+		{"half-step-down", BaseFeeArgs{InitialBaseFee: 5, Capacity: 600, Used: 150, PrevBlock: prev(800)}, 750},
+		{"min-step-up", BaseFeeArgs{InitialBaseFee: 5, Capacity: 600, Used: 301, PrevBlock: prev(5)}, 6},
+		{"no-step-down", BaseFeeArgs{InitialBaseFee: 5, Capacity: 600, Used: 299, PrevBlock: prev(5)}, 5},
+		{"genesis-full-transfers", BaseFeeArgs{InitialBaseFee: 5, Capacity: 400, Used: 300, PrevBlock: prev(800)}, 850},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			if got := CalcBaseFee(tst.args); got != tst.exp {
+				t.Errorf("Should get a base fee of %d, got %d", tst.exp, got)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -16,17 +17,20 @@ import (
 
 // Tx is the transactional information between two parties.
 type Tx struct {
-	ChainID uint16    `json:"chain_id"` // Ethereum: The chain id that is listed in the genesis file.
-	Nonce   uint64    `json:"nonce"`    // Ethereum: Unique id for the transaction supplied by the user.
-	FromID  AccountID `json:"from"`     // Ethereum: Account sending the transaction. Will be checked against signature.
-	ToID    AccountID `json:"to"`       // Ethereum: Account receiving the benefit of the transaction.
-	Value   uint64    `json:"value"`    // Ethereum: Monetary value received from this transaction.
-	Tip     uint64    `json:"tip"`      // Ethereum: Tip offered by the sender as an incentive to mine this transaction.
-	Data    []byte    `json:"data"`     // Ethereum: Extra data related to the transaction.
+	ChainID     uint16    `json:"chain_id"`      // Ethereum: The chain id that is listed in the genesis file.
+	Nonce       uint64    `json:"nonce"`         // Ethereum: Unique id for the transaction supplied by the user.
+	FromID      AccountID `json:"from"`          // Ethereum: Account sending the transaction. Will be checked against signature.
+	ToID        AccountID `json:"to"`            // Ethereum: Account receiving the benefit of the transaction.
+	Value       uint64    `json:"value"`         // Ethereum: Monetary value received from this transaction.
+	Tip         uint64    `json:"tip"`           // Ethereum: Tip offered by the sender as an incentive to mine this transaction.
+	Data        []byte    `json:"data"`          // Ethereum: Extra data related to the transaction.
+	GasLimit    uint64    `json:"gas_limit"`     // Ethereum: Maximum units of gas the sender is willing to buy.
+	MaxGasPrice uint64    `json:"max_gas_price"` // Ethereum: Maximum price per unit of gas the sender is willing to pay.
+	ValidUntil  uint64    `json:"valid_until"`   // Last block number the transaction can be mined in, 0 never expires.
 }
 
 // NewTx constructs a new transaction.
-func NewTx(chainID uint16, nonce uint64, fromID AccountID, toID AccountID, value uint64, tip uint64, data []byte) (Tx, error) {
+func NewTx(chainID uint16, nonce uint64, fromID AccountID, toID AccountID, value uint64, tip uint64, data []byte, gasLimit uint64, maxGasPrice uint64) (Tx, error) {
 	if !fromID.IsAccountID() {
 		return Tx{}, errors.New("from account is not properly formatted")
 	}
@@ -35,18 +39,26 @@ func NewTx(chainID uint16, nonce uint64, fromID AccountID, toID AccountID, value
 	}
 
 	tx := Tx{
-		ChainID: chainID,
-		Nonce:   nonce,
-		FromID:  fromID,
-		ToID:    toID,
-		Value:   value,
-		Tip:     tip,
-		Data:    data,
+		ChainID:     chainID,
+		Nonce:       nonce,
+		FromID:      fromID,
+		ToID:        toID,
+		Value:       value,
+		Tip:         tip,
+		Data:        data,
+		GasLimit:    gasLimit,
+		MaxGasPrice: maxGasPrice,
 	}
 
 	return tx, nil
 }
 
+// IsExpired reports if the transaction can no longer be mined into the block
+// with the specified number.
+func (tx Tx) IsExpired(blockNumber uint64) bool {
+	return tx.ValidUntil != 0 && blockNumber > tx.ValidUntil
+}
+
 // Sign uses the specified private key to sign the transaction.
 func (tx Tx) Sign(privateKey *ecdsa.PrivateKey) (SignedTx, error) {
 
@@ -81,37 +93,45 @@ type SignedTx struct {
 	S *big.Int `json:"s"` // Ethereum: Second coordinate of the ECDSA signature.
 }
 
+// Set of errors returned by Validate so the caller can tell which check
+// the transaction failed.
+var (
+	ErrInvalidChainID   = errors.New("invalid chain id")
+	ErrInvalidAccount   = errors.New("invalid account")
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
 // Validate verifies the transaction has a proper signature that conforms to our
 // standards. It also checks the from field matches the account that signed the
 // transaction. Last it checks the format of the from and to fields.
 func (tx SignedTx) Validate(chainID uint16) error {
 	if tx.ChainID != chainID {
-		return fmt.Errorf("invalid chain id, got[%d] exp[%d]", tx.ChainID, chainID)
+		return fmt.Errorf("%w, got[%d] exp[%d]", ErrInvalidChainID, tx.ChainID, chainID)
 	}
 
 	if !tx.FromID.IsAccountID() {
-		return errors.New("from account is not properly formatted")
+		return fmt.Errorf("%w: from account is not properly formatted", ErrInvalidAccount)
 	}
 
 	if !tx.ToID.IsAccountID() {
-		return errors.New("to account is not properly formatted")
+		return fmt.Errorf("%w: to account is not properly formatted", ErrInvalidAccount)
 	}
 
 	if tx.FromID == tx.ToID {
-		return fmt.Errorf("transaction invalid, sending money to yourself, from %s, to %s", tx.FromID, tx.ToID)
+		return fmt.Errorf("%w: sending money to yourself, from %s, to %s", ErrInvalidAccount, tx.FromID, tx.ToID)
 	}
 
 	if err := signature.VerifySignature(tx.V, tx.R, tx.S); err != nil {
-		return err
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
 	}
 
 	address, err := signature.FromAddress(tx.Tx, tx.V, tx.R, tx.S)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
 	}
 
 	if address != string(tx.FromID) {
-		return errors.New("signature address doesn't match from address")
+		return fmt.Errorf("%w: signature address doesn't match from address", ErrInvalidSignature)
 	}
 
 	return nil
@@ -135,7 +155,7 @@ type BlockTx struct {
 	SignedTx
 	TimeStamp uint64 `json:"timestamp"` // Ethereum: The time the transaction was received.
 	GasPrice  uint64 `json:"gas_price"` // Ethereum: The price of one unit of gas to be paid for fees.
-	GasUnits  uint64 `json:"gas_units"` // Ethereum: The number of units of gas used for this transaction.
+	GasUnits  uint64 `json:"gas_units"` // Ethereum: The number of units of gas used by this transaction.
 }
 
 // NewBlockTx constructs a new block transaction.
@@ -148,6 +168,33 @@ func NewBlockTx(signedTx SignedTx, gasPrice uint64, unitsOfGas uint64) BlockTx {
 	}
 }
 
+// Size returns the number of bytes the transaction takes up in a block.
+func (tx BlockTx) Size() uint64 {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return 0
+	}
+
+	return uint64(len(data))
+}
+
+// Fee returns the amount the beneficiary receives for mining the transaction,
+// which is the gas fee plus the tip.
+func (tx BlockTx) Fee() uint64 {
+	return tx.GasPrice*tx.GasUnits + tx.Tip
+}
+
+// FeeDensity returns the fee paid for each byte of the transaction. This is
+// what a miner wants to maximize when a block is limited by size.
+func (tx BlockTx) FeeDensity() float64 {
+	size := tx.Size()
+	if size == 0 {
+		return 0
+	}
+
+	return float64(tx.Fee()) / float64(size)
+}
+
 // Hash implements the merkle Hashable interface for providing a hash
 // of a block transaction.
 func (tx BlockTx) Hash() ([]byte, error) {
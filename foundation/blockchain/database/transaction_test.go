@@ -0,0 +1,42 @@
+package database
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func Test_ValidateErrors(t *testing.T) {
+	// signedTx constructs a transaction with a bad signature and then lets
+	// the case change it.
+	signedTx := func(change func(tx *SignedTx)) SignedTx {
+		tx := SignedTx{
+			Tx: Tx{ChainID: 1, Nonce: 1, FromID: accountFrom, ToID: accountTo, Value: 10},
+			V:  big.NewInt(0),
+			R:  big.NewInt(0),
+			S:  big.NewInt(0),
+		}
+		change(&tx)
+		return tx
+	}
+
+	tt := []struct {
+		name string
+		tx   SignedTx
+		exp  error
+	}{
+		{"chain-id", signedTx(func(tx *SignedTx) { tx.ChainID = 2 }), ErrInvalidChainID},
+		{"from-account", signedTx(func(tx *SignedTx) { tx.FromID = "0x01" }), ErrInvalidAccount},
+		{"to-account", signedTx(func(tx *SignedTx) { tx.ToID = "0x01" }), ErrInvalidAccount},
+		{"to-self", signedTx(func(tx *SignedTx) { tx.ToID = tx.FromID }), ErrInvalidAccount},
+		{"signature", signedTx(func(tx *SignedTx) {}), ErrInvalidSignature},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			if err := tst.tx.Validate(1); !errors.Is(err, tst.exp) {
+				t.Errorf("Should fail with %q, got %v", tst.exp, err)
+			}
+		})
+	}
+}
@@ -11,10 +11,29 @@ type Genesis struct {
 	Date          time.Time         `json:"date"`
 	ChainID       uint16            `json:"chain_id"`        // The chain id represents an unique id for this running instance.
 	TransPerBlock uint16            `json:"trans_per_block"` // The maximum number of transactions that can be in a block.
-	Difficulty    uint16            `json:"difficulty"`      // How difficult it needs to be to solve the work problem.
+	MaxBlockBytes uint64            `json:"max_block_bytes"` // The maximum size in bytes of the transactions in a block, 0 is unlimited.
+	MaxBlockGas   uint64            `json:"max_block_gas"`   // The maximum units of gas the transactions in a block can use, 0 is unlimited.
+	Difficulty    uint64            `json:"difficulty"`      // How difficult it needs to be to solve the work problem for the first block.
 	MiningReward  uint64            `json:"mining_reward"`   // Reward for mining a block.
 	GasPrice      uint64            `json:"gas_price"`       // Fee paid for each transaction mined into a block.
 	Balances      map[string]uint64 `json:"balances"`
+
+	TargetBlockTime  uint64 `json:"target_block_time"` // The number of seconds the network is trying to keep between blocks.
+	RetargetInterval uint64 `json:"retarget_interval"` // The number of blocks between each difficulty adjustment.
+	HalvingInterval  uint64 `json:"halving_interval"`  // The number of blocks between each halving of the mining reward, 0 never halves.
+	TailEmission     uint64 `json:"tail_emission"`     // The mining reward that is paid forever once halving drops below it.
+	BaseFee          uint64 `json:"base_fee"`          // The base fee per unit of gas for the first block that is burned, 0 disables it.
+
+	Gas GasSchedule `json:"gas"` // The units of gas charged for processing a transaction.
+}
+
+// GasSchedule represents the units of gas charged for the different parts
+// of processing a transaction.
+type GasSchedule struct {
+	TxBase          uint64 `json:"tx_base"`            // Charged for every transaction.
+	Transfer        uint64 `json:"transfer"`           // Charged when the transaction moves value.
+	DataZeroByte    uint64 `json:"data_zero_byte"`     // Charged for every zero byte of data.
+	DataNonZeroByte uint64 `json:"data_non_zero_byte"` // Charged for every non-zero byte of data.
 }
 
 // Load opens and consumes the genesis file.
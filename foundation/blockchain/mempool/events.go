@@ -0,0 +1,162 @@
+package mempool
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+)
+
+// Set of event types that describe a change to the mempool.
+const (
+	EventAdd     = "add"
+	EventReplace = "replace"
+	EventRemove  = "remove"
+	EventEvict   = "evict"
+)
+
+// Set of reasons a transaction leaves the mempool, on top of the reasons a
+// transaction can be rejected or evicted.
+const (
+	ReasonMined    = "mined"
+	ReasonReplaced = "replaced"
+	ReasonInvalid  = "invalid"
+)
+
+// Event represents a change to the transactions in the mempool. The reason
+// explains why a transaction was replaced, removed or evicted. Dropped is the
+// number of events the subscriber missed right before this one because it
+// was not keeping up.
+type Event struct {
+	Type    string
+	Reason  string
+	Tx      database.BlockTx
+	Dropped uint64
+}
+
+// EventHandler defines a function that is called for every change to the
+// mempool. The handler is called while the mempool is locked, so it must not
+// block or call back into the mempool.
+type EventHandler func(ev Event)
+
+// SetEventHandler registers the function to call for every change to the
+// mempool. This must be called before the mempool is used.
+func (mp *Mempool) SetEventHandler(evHandler EventHandler) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	mp.evHandler = evHandler
+}
+
+// emit sends the event to the registered handler.
+func (mp *Mempool) emit(typ string, reason string, tx database.BlockTx) {
+	if mp.evHandler != nil {
+		mp.evHandler(Event{Type: typ, Reason: reason, Tx: tx})
+	}
+}
+
+// =============================================================================
+
+// Feed fans out mempool events to subscribers. A subscriber can ask for just
+// the events for transactions sent from or to a set of accounts.
+type Feed struct {
+	mu   sync.RWMutex
+	subs map[string]*subscription
+}
+
+// subscription represents a subscriber, the accounts it's filtering on and
+// the number of events it missed since the last one it received.
+type subscription struct {
+	ch       chan Event
+	accounts map[database.AccountID]struct{}
+	dropped  atomic.Uint64
+}
+
+// NewFeed constructs a feed for subscribing to mempool events.
+func NewFeed() *Feed {
+	return &Feed{
+		subs: make(map[string]*subscription),
+	}
+}
+
+// Subscribe takes a unique id and returns a channel that can be used to
+// receive events. When accounts are provided, only the events for
+// transactions sent from or to those accounts are received.
+func (f *Feed) Subscribe(id string, accounts ...database.AccountID) <-chan Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if sub, exists := f.subs[id]; exists {
+		return sub.ch
+	}
+
+	// Since an event will be dropped if the receiver is not ready to
+	// receive, this arbitrary buffer should give the receiver enough time
+	// to not lose an event.
+	const eventBuffer = 100
+
+	sub := &subscription{
+		ch:       make(chan Event, eventBuffer),
+		accounts: make(map[database.AccountID]struct{}, len(accounts)),
+	}
+	for _, accountID := range accounts {
+		sub.accounts[accountID] = struct{}{}
+	}
+
+	f.subs[id] = sub
+	return sub.ch
+}
+
+// Unsubscribe closes and removes the channel that was provided by the call
+// to Subscribe.
+func (f *Feed) Unsubscribe(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sub, exists := f.subs[id]
+	if !exists {
+		return
+	}
+
+	delete(f.subs, id)
+	close(sub.ch)
+}
+
+// Shutdown closes and removes all the channels that were provided by the
+// call to Subscribe.
+func (f *Feed) Shutdown() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for id, sub := range f.subs {
+		delete(f.subs, id)
+		close(sub.ch)
+	}
+}
+
+// Send signals the event to every subscriber interested in it. Send will
+// not block waiting for a receiver on any given channel. When a receiver is
+// not ready, the event is counted as dropped and that count is reported with
+// the next event the receiver gets.
+func (f *Feed) Send(ev Event) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, sub := range f.subs {
+		if len(sub.accounts) > 0 {
+			_, from := sub.accounts[ev.Tx.FromID]
+			_, to := sub.accounts[ev.Tx.ToID]
+			if !from && !to {
+				continue
+			}
+		}
+
+		ev.Dropped = sub.dropped.Swap(0)
+
+		select {
+		case sub.ch <- ev:
+		default:
+			sub.dropped.Add(ev.Dropped + 1)
+		}
+	}
+}
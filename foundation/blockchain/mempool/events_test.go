@@ -0,0 +1,89 @@
+package mempool_test
+
+import (
+	"testing"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
+)
+
+func Test_FeedFilter(t *testing.T) {
+	feed := mempool.NewFeed()
+	defer feed.Shutdown()
+
+	// Every transaction is sent to account C.
+	all := feed.Subscribe("all")
+	from := feed.Subscribe("from", accountA)
+	to := feed.Subscribe("to", accountC)
+
+	if feed.Subscribe("all") != all {
+		t.Fatal("Should get the same channel when subscribing with the same id")
+	}
+
+	feed.Send(mempool.Event{Type: mempool.EventAdd, Tx: newTx(accountA, 1, 10)})
+	feed.Send(mempool.Event{Type: mempool.EventAdd, Tx: newTx(accountB, 1, 10)})
+
+	tt := []struct {
+		name string
+		ch   <-chan mempool.Event
+		exp  []database.AccountID
+	}{
+		{"all", all, []database.AccountID{accountA, accountB}},
+		{"from", from, []database.AccountID{accountA}},
+		{"to", to, []database.AccountID{accountA, accountB}},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			if len(tst.ch) != len(tst.exp) {
+				t.Fatalf("Should receive %d events, got %d", len(tst.exp), len(tst.ch))
+			}
+			for i, exp := range tst.exp {
+				if ev := <-tst.ch; ev.Tx.FromID != exp {
+					t.Fatalf("Should receive the event from %s at %d, got %s", exp, i, ev.Tx.FromID)
+				}
+			}
+		})
+	}
+
+	feed.Unsubscribe("from")
+	if _, open := <-from; open {
+		t.Fatal("Should close the channel on unsubscribe")
+	}
+}
+
+func Test_FeedDropped(t *testing.T) {
+	feed := mempool.NewFeed()
+	defer feed.Shutdown()
+
+	ch := feed.Subscribe("slow")
+
+	// Fill the buffer and keep sending without receiving.
+	var sent int
+	for len(ch) < cap(ch) {
+		feed.Send(mempool.Event{Type: mempool.EventAdd, Tx: newTx(accountA, uint64(sent+1), 10)})
+		sent++
+	}
+	const missed = 3
+	for i := 0; i < missed; i++ {
+		feed.Send(mempool.Event{Type: mempool.EventAdd, Tx: newTx(accountA, uint64(sent+1), 10)})
+		sent++
+	}
+
+	for len(ch) > 0 {
+		if ev := <-ch; ev.Dropped != 0 {
+			t.Fatalf("Should not report drops before they happen, got %d", ev.Dropped)
+		}
+	}
+
+	// The next event received reports what was missed, once.
+	feed.Send(mempool.Event{Type: mempool.EventRemove, Tx: newTx(accountA, 1, 10)})
+	feed.Send(mempool.Event{Type: mempool.EventRemove, Tx: newTx(accountA, 2, 10)})
+
+	if ev := <-ch; ev.Dropped != missed {
+		t.Fatalf("Should report %d dropped events, got %d", missed, ev.Dropped)
+	}
+	if ev := <-ch; ev.Dropped != 0 {
+		t.Fatalf("Should reset the dropped count once reported, got %d", ev.Dropped)
+	}
+}
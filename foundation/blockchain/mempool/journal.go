@@ -0,0 +1,138 @@
+package mempool
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+)
+
+// CORE NOTE: The journal is an append only file with one transaction per
+// line. Every transaction accepted into the mempool is appended, so the file
+// keeps growing with transactions that have since been mined, replaced or
+// evicted. Rotating the journal rewrites it with just the transactions that
+// are in the mempool at that moment. On startup the journal is replayed and
+// every transaction is validated again against the current state, which
+// takes care of dropping anything that can no longer be executed.
+
+// Journal maintains a file on disk of the transactions in the mempool so
+// they can be restored when the node restarts.
+type Journal struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewJournal constructs a journal that is stored at the specified path.
+func NewJournal(path string) *Journal {
+	return &Journal{path: path}
+}
+
+// Insert appends the transaction to the end of the journal.
+func (j *Journal) Insert(tx database.BlockTx) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return err
+	}
+
+	// The file is opened and closed for each write since the database
+	// directory can be removed and recreated when the blockchain is reset.
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Load reads all the transactions in the journal. A journal that doesn't
+// exist yet has no transactions. A line that can't be decoded is skipped
+// since it could be a partial write from a node that crashed.
+func (j *Journal) Load() ([]database.BlockTx, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	f, err := os.Open(j.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var trans []database.BlockTx
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var tx database.BlockTx
+		if err := json.Unmarshal(scanner.Bytes(), &tx); err != nil {
+			continue
+		}
+		trans = append(trans, tx)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return trans, nil
+}
+
+// Rotate replaces the contents of the journal with the specified
+// transactions. The new journal is written to a temporary file first so a
+// crash never leaves a half written journal behind.
+func (j *Journal) Rotate(trans []database.BlockTx) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return err
+	}
+
+	tmp := j.path + ".new"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, tx := range trans {
+		data, err := json.Marshal(tx)
+		if err != nil {
+			f.Close()
+			return err
+		}
+
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, j.path)
+}
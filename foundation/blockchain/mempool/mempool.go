@@ -1,43 +1,135 @@
 // Package mempool maintains the mempool for the blockchain.
-// TODO: Can we make the mempool persistent?
 package mempool
 
 import (
 	"errors"
 	"fmt"
 	"math"
-	"strings"
+	"math/bits"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
 )
 
-// Mempool represents a cache of transactions organized by account:nonce.
-type Mempool struct {
-	mu       sync.RWMutex
-	pool     map[string]database.BlockTx
-	selectFn selector.Func
+// CORE NOTE: Like Ethereum, the transactions for each account are kept in two
+// lists. The pending list holds the transactions that can be executed right
+// now, which means their nonces form a contiguous sequence starting with the
+// next nonce expected for the account. The queued list holds the transactions
+// that are waiting on a nonce gap to be filled. When a gap is filled, queued
+// transactions are promoted to the pending list. Only pending transactions are
+// ever selected for a block, so a transaction will never be mined just to
+// fail on a wrong nonce and still cost the sender fees.
+
+// AccountFunc returns the current state of the specified account on the
+// blockchain. The nonce is for the last transaction that was applied.
+type AccountFunc func(accountID database.AccountID) database.Account
+
+// Set of policies for picking the transaction to evict when the mempool
+// is full.
+const (
+	EvictFeeDensity = "fee_density"
+	EvictOldest     = "oldest"
+)
+
+// Capacity represents the limits on the size of the mempool and how long a
+// transaction can stay in it. A value of 0 means there is no limit.
+type Capacity struct {
+	MaxTrans      uint64
+	MaxBytes      uint64
+	MaxPerAccount uint64
+	Eviction      string
+	TTL           time.Duration
+}
+
+// Eviction represents a transaction that was removed from the pool without
+// being mined and the reason it was removed.
+type Eviction struct {
+	Tx     database.BlockTx
+	Reason string
+}
+
+// entry represents a transaction in the pool. The size and hash of a
+// transaction require marshaling it, so they are calculated once when the
+// transaction is added and kept with it.
+type entry struct {
+	database.BlockTx
+	hash    string
+	size    uint64
+	density float64
 }
 
-// New constructs a new mempool using the default sort strategy.
-func New() (*Mempool, error) {
-	m := Mempool{
-		pool: make(map[string]database.BlockTx),
+// newEntry constructs an entry for the transaction.
+func newEntry(tx database.BlockTx) entry {
+	e := entry{
+		BlockTx: tx,
+		hash:    signature.Hash(tx),
+		size:    tx.Size(),
 	}
-	return &m, nil
-	// return NewWithStrategy(selector.StrategyTip)
+
+	if e.size > 0 {
+		e.density = float64(tx.Fee()) / float64(e.size)
+	}
+
+	return e
+}
+
+// =============================================================================
+
+// Mempool represents a cache of transactions organized by account and nonce.
+type Mempool struct {
+	mu        sync.RWMutex
+	pending   map[database.AccountID][]entry
+	queued    map[database.AccountID][]entry
+	bytes     map[database.AccountID]uint64
+	capacity  Capacity
+	account   AccountFunc
+	selectFn  selector.Func
+	evHandler EventHandler
+}
+
+// New constructs a new unbounded mempool using the default sort strategy.
+func New(account AccountFunc) (*Mempool, error) {
+	return NewWithStrategy(selector.StrategyTip, Capacity{}, account)
 }
 
-// NewWithStrategy constructs a new mempool with specified sort strategy.
-func NewWithStrategy(strategy string) (*Mempool, error) {
+// NewWithStrategy constructs a new mempool with specified sort strategy and
+// capacity. The account function is used to know which transactions can be
+// executed and paid for.
+func NewWithStrategy(strategy string, capacity Capacity, account AccountFunc) (*Mempool, error) {
 	selectFn, err := selector.Retrieve(strategy)
 	if err != nil {
 		return nil, err
 	}
 
+	return NewWithSelector(selectFn, capacity, account)
+}
+
+// NewWithSelector constructs a new mempool with the specified select function
+// and capacity. This allows an application to provide its own ordering of
+// transactions without registering it by name.
+func NewWithSelector(selectFn selector.Func, capacity Capacity, account AccountFunc) (*Mempool, error) {
+	if selectFn == nil {
+		return nil, errors.New("select function must be provided")
+	}
+
+	switch capacity.Eviction {
+	case "":
+		capacity.Eviction = EvictFeeDensity
+	case EvictFeeDensity, EvictOldest:
+	default:
+		return nil, fmt.Errorf("eviction policy %q does not exist", capacity.Eviction)
+	}
+
 	mp := Mempool{
-		pool:     make(map[string]database.BlockTx),
+		pending:  make(map[database.AccountID][]entry),
+		queued:   make(map[database.AccountID][]entry),
+		bytes:    make(map[database.AccountID]uint64),
+		capacity: capacity,
+		account:  account,
 		selectFn: selectFn,
 	}
 
@@ -49,11 +141,39 @@ func (mp *Mempool) Count() int {
 	mp.mu.RLock()
 	defer mp.mu.RUnlock()
 
-	return len(mp.pool)
+	return count(mp.pending) + count(mp.queued)
+}
+
+// PendingCount returns the current number of transactions that can be
+// executed.
+func (mp *Mempool) PendingCount() int {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+
+	return count(mp.pending)
+}
+
+// NextNonce returns the nonce the account's next transaction needs to use to
+// follow the transactions that are pending for it. Queued transactions behind
+// a nonce gap are not counted.
+func (mp *Mempool) NextNonce(accountID database.AccountID) uint64 {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+
+	if pending := mp.pending[accountID]; len(pending) > 0 {
+		return pending[len(pending)-1].Nonce + 1
+	}
+
+	return mp.account(accountID).Nonce + 1
 }
 
 // Upsert adds or replaces a transaction from the mempool.
 func (mp *Mempool) Upsert(tx database.BlockTx) error {
+
+	// Calculate the size and hash of the transaction before taking the lock
+	// since they require marshaling the transaction.
+	e := newEntry(tx)
+
 	mp.mu.Lock()
 	defer mp.mu.Unlock()
 
@@ -63,51 +183,205 @@ func (mp *Mempool) Upsert(tx database.BlockTx) error {
 	// is met, then either the transaction that has the least return on investment
 	// or the oldest will be dropped from the pool to make room for new the transaction.
 
-	// For now, the Go blockchain in not imposing any limits.
-	key, err := mapKey(tx)
-	if err != nil {
-		return err
+	// The Go blockchain limits the number of transactions, the number of bytes
+	// and the number of transactions for a single account. The eviction
+	// policy decides which transaction is dropped when the pool is full.
+
+	account := mp.account(tx.FromID)
+
+	// A transaction with a nonce that has already been used can never be
+	// executed.
+	if tx.Nonce <= account.Nonce {
+		err := fmt.Errorf("transaction nonce has already been used, got %d, exp greater than %d", tx.Nonce, account.Nonce)
+		return NewRejectError(ReasonStaleNonce, err)
 	}
 
 	// Ethereum requires a 10% bump in the tip to replace an existing
 	// transaction in the mempool and so do we. We want to limit users
 	// from this sort of behavior.
-	if etx, exists := mp.pool[key]; exists {
+	etx, replacing := mp.find(tx.FromID, tx.Nonce)
+	if replacing {
 		if tx.Tip < uint64(math.Round(float64(etx.Tip)*1.10)) {
-			return errors.New("replacing a transaction requires a 10% bump in the tip")
+			err := errors.New("replacing a transaction requires a 10% bump in the tip")
+			return NewRejectError(ReasonUnderpriced, err)
 		}
 	}
 
-	mp.pool[key] = tx
+	// The account must be able to pay for every transaction it has in the
+	// pool at the most it could be charged. Otherwise some of them are
+	// guaranteed to fail when mined.
+	cost, ok := mp.accountCost(tx)
+	if !ok {
+		return NewRejectError(ReasonInvalidCost, errors.New("cost of the account's transactions overflows"))
+	}
+	if cost > account.Balance {
+		err := fmt.Errorf("account balance can't cover its transactions, bal %d, needed %d", account.Balance, cost)
+		return NewRejectError(ReasonInsufficientFunds, err)
+	}
+
+	// Make room for the transaction or reject it when it doesn't pay enough
+	// to push another transaction out of the pool.
+	if err := mp.makeRoom(e); err != nil {
+		return err
+	}
+
+	// A replacement for a pending transaction stays in place, otherwise the
+	// transaction is queued until it becomes executable.
+	switch {
+	case replaceByNonce(mp.pending[tx.FromID], e):
+		mp.setLists(tx.FromID, mp.pending[tx.FromID], mp.queued[tx.FromID])
+
+	default:
+		mp.queued[tx.FromID] = upsertByNonce(mp.queued[tx.FromID], e)
+		mp.promote(tx.FromID, account.Nonce)
+	}
+
+	switch {
+	case replacing:
+		mp.emit(EventReplace, ReasonReplaced, tx)
+	default:
+		mp.emit(EventAdd, "", tx)
+	}
 
 	return nil
 }
 
-// Delete removed a transaction from the mempool.
-func (mp *Mempool) Delete(tx database.BlockTx) error {
+// Delete removes a transaction from the mempool. The reason explains why
+// the transaction was removed, like it was mined into a block.
+func (mp *Mempool) Delete(tx database.BlockTx, reason string) error {
 	mp.mu.Lock()
 	defer mp.mu.Unlock()
 
-	key, err := mapKey(tx)
-	if err != nil {
-		return err
+	etx, exists := mp.find(tx.FromID, tx.Nonce)
+	if !exists {
+		return nil
 	}
 
-	delete(mp.pool, key)
+	mp.pending[tx.FromID] = removeByNonce(mp.pending[tx.FromID], tx.Nonce)
+	mp.queued[tx.FromID] = removeByNonce(mp.queued[tx.FromID], tx.Nonce)
+	mp.emit(EventRemove, reason, etx.BlockTx)
+
+	// Removing a transaction from the middle of the pending list leaves a
+	// gap, so the transactions after it are no longer executable.
+	mp.demote(tx.FromID)
 
 	return nil
 }
 
+// Refresh re-checks the transactions for the specified accounts against the
+// current account nonces. Transactions with a nonce that has been used are
+// dropped and queued transactions that are now executable are promoted. This
+// needs to be called after a block has been applied to the database.
+func (mp *Mempool) Refresh(accountIDs ...database.AccountID) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	for _, accountID := range accountIDs {
+		nonce := mp.account(accountID).Nonce
+
+		// Move everything back to the queued list and drop what can
+		// never be executed.
+		trans := append(mp.pending[accountID], mp.queued[accountID]...)
+		delete(mp.pending, accountID)
+
+		queued := make([]entry, 0, len(trans))
+		for _, e := range trans {
+			if e.Nonce > nonce {
+				queued = append(queued, e)
+				continue
+			}
+
+			// The nonce was used by a different transaction.
+			mp.emit(EventRemove, ReasonInvalid, e.BlockTx)
+		}
+		sort.Sort(byNonce(queued))
+		mp.queued[accountID] = queued
+
+		mp.promote(accountID, nonce)
+	}
+}
+
+// Sweep removes the transactions that have been in the pool longer than the
+// TTL and the transactions that can't be mined into the block with the
+// specified number because they have expired. The transactions that follow
+// a removed transaction for the same account are moved to the queued list.
+func (mp *Mempool) Sweep(now time.Time, blockNumber uint64) []Eviction {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	// The transaction timestamps are in milliseconds.
+	var cutoff uint64
+	if mp.capacity.TTL > 0 {
+		if c := now.Add(-mp.capacity.TTL).UnixMilli(); c > 0 {
+			cutoff = uint64(c)
+		}
+	}
+
+	var evictions []Eviction
+	sweep := func(list []entry) []entry {
+		var keep []entry
+		for _, e := range list {
+			switch {
+			case e.IsExpired(blockNumber):
+				evictions = append(evictions, Eviction{Tx: e.BlockTx, Reason: ReasonExpired})
+				mp.emit(EventEvict, ReasonExpired, e.BlockTx)
+			case e.TimeStamp < cutoff:
+				evictions = append(evictions, Eviction{Tx: e.BlockTx, Reason: ReasonTTL})
+				mp.emit(EventEvict, ReasonTTL, e.BlockTx)
+			default:
+				keep = append(keep, e)
+			}
+		}
+		return keep
+	}
+
+	accounts := make([]database.AccountID, 0, len(mp.bytes))
+	for accountID := range mp.bytes {
+		accounts = append(accounts, accountID)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i] < accounts[j] })
+
+	for _, accountID := range accounts {
+		pending := sweep(mp.pending[accountID])
+		queued := sweep(mp.queued[accountID])
+
+		mp.setLists(accountID, pending, queued)
+		mp.demote(accountID)
+	}
+
+	return evictions
+}
+
 // Truncate clears all the transactions from the pool.
 func (mp *Mempool) Truncate() {
 	mp.mu.Lock()
 	defer mp.mu.Unlock()
 
-	mp.pool = make(map[string]database.BlockTx)
+	mp.pending = make(map[database.AccountID][]entry)
+	mp.queued = make(map[database.AccountID][]entry)
+	mp.bytes = make(map[database.AccountID]uint64)
 }
 
-// PickBest uses the configured sort strategy to return a set of transactions.
-// If 0 is passed, all transactions in the mempool will be returned.
+// Copy returns all the transactions in the pool. The pending transactions
+// are returned first in the order of the configured strategy, followed by
+// the queued transactions.
+func (mp *Mempool) Copy() []database.BlockTx {
+	trans := mp.PickBest()
+
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+
+	for _, accountID := range sortedAccounts(mp.queued) {
+		for _, e := range mp.queued[accountID] {
+			trans = append(trans, e.BlockTx)
+		}
+	}
+
+	return trans
+}
+
+// PickBest uses the configured sort strategy to return a set of pending
+// transactions. If 0 is passed, all pending transactions will be returned.
 func (mp *Mempool) PickBest(howMany ...uint16) []database.BlockTx {
 	number := 0
 	if len(howMany) > 0 {
@@ -115,9 +389,9 @@ func (mp *Mempool) PickBest(howMany ...uint16) []database.BlockTx {
 	}
 
 	// CORE NOTE: Most blockchains do set a max block size limit and this size
-	// will determined which transactions are selected. When picking the best
-	// transactions for the next block, this blockchain is currently not
-	// focused on block size but a max number of transactions.
+	// will determined which transactions are selected. When mining, PickBlock
+	// packs a block to the size and gas limits in the order of the configured
+	// strategy. This function only limits the number of transactions.
 	//
 	// When the selection algorithm does need to consider sizing, picking the
 	// right transactions that maximize profit gets really hard. On top of this,
@@ -126,55 +400,409 @@ func (mp *Mempool) PickBest(howMany ...uint16) []database.BlockTx {
 	// selected as the only form of revenue. This will change how transactions
 	// need to be selected.
 
-	// Copy all the transactions for each account into separate slices.
-	m := make(map[database.AccountID][]database.BlockTx)
-	mp.mu.RLock()
-	{
-		if number == 0 {
-			number = len(mp.pool)
+	// Copy the pending transactions for each account into separate slices.
+	// These are already sorted by nonce and contiguous, so any selection
+	// that respects nonce ordering only returns executable transactions.
+	m, _ := mp.copyPending()
+
+	// The selection algorithms is expecting this slice of transactions
+	// organized by account. Passing 0 through asks for every transaction,
+	// which also lifts any cap a strategy puts on a single account.
+	return mp.selectFn(m, number)
+}
+
+// PickBlock uses the configured sort strategy to pack a block with pending
+// transactions that fit within the block limits. Transactions that fail the
+// valid function are left behind along with the transactions that follow
+// them for the same account.
+func (mp *Mempool) PickBlock(limits selector.Limits, valid selector.ValidFunc) []database.BlockTx {
+
+	// Copy the pending transactions for each account into separate slices
+	// so the strategy can reorder them.
+	m, _ := mp.copyPending()
+
+	return selector.Pack(mp.selectFn, m, limits, valid)
+}
+
+// PickBlocks packs up to the specified number of blocks one after the other
+// the same way PickBlock does, as if each block was mined before the next
+// one is packed. Packing stops early once a block comes back empty.
+func (mp *Mempool) PickBlocks(n int, limits selector.Limits, valid selector.ValidFunc) [][]database.BlockTx {
+	m, _ := mp.copyPending()
+
+	var blocks [][]database.BlockTx
+	for len(blocks) < n {
+		trans := selector.Pack(mp.selectFn, m, limits, valid)
+		if len(trans) == 0 {
+			break
+		}
+		blocks = append(blocks, trans)
+
+		// Take the packed transactions out before packing the next block.
+		// An account's transactions are packed in nonce order, so what was
+		// packed is the front of its list up to the highest nonce.
+		last := make(map[database.AccountID]uint64)
+		for _, tx := range trans {
+			if tx.Nonce > last[tx.FromID] {
+				last[tx.FromID] = tx.Nonce
+			}
 		}
 
-		for key, tx := range mp.pool {
-			account := accountFromMapKey(key)
-			m[account] = append(m[account], tx)
+		for accountID, nonce := range last {
+			list := m[accountID]
+			i := 0
+			for i < len(list) && list[i].Nonce <= nonce {
+				i++
+			}
+			m[accountID] = list[i:]
 		}
 	}
-	mp.mu.RUnlock()
 
-	// The selection algorithms is expecting this slice of transactions
-	// organized by account.
-	return mp.selectFn(m, number)
+	return blocks
+}
+
+// =============================================================================
+
+// find locates the transaction for the account and nonce in either list.
+func (mp *Mempool) find(accountID database.AccountID, nonce uint64) (entry, bool) {
+	for _, list := range [][]entry{mp.pending[accountID], mp.queued[accountID]} {
+		for _, e := range list {
+			if e.Nonce == nonce {
+				return e, true
+			}
+		}
+	}
+
+	return entry{}, false
+}
+
+// accountCost calculates the most the account could be charged for all the
+// transactions it has in the pool once the specified transaction is added.
+// A transaction the specified transaction replaces is not counted. False is
+// returned when the cost overflows.
+func (mp *Mempool) accountCost(tx database.BlockTx) (uint64, bool) {
+	cost, ok := maxCost(tx)
+	if !ok {
+		return 0, false
+	}
+
+	for _, list := range [][]entry{mp.pending[tx.FromID], mp.queued[tx.FromID]} {
+		for _, e := range list {
+			if e.Nonce == tx.Nonce {
+				continue
+			}
+
+			c, ok := maxCost(e.BlockTx)
+			if !ok {
+				return 0, false
+			}
+
+			var carry uint64
+			if cost, carry = bits.Add64(cost, c, 0); carry != 0 {
+				return 0, false
+			}
+		}
+	}
+
+	return cost, true
+}
+
+// makeRoom checks the transaction fits within the capacity of the pool and
+// evicts transactions from other accounts to make room when it doesn't. A
+// transaction is rejected when it pays less than the eviction threshold,
+// which is the fee density of the transaction that would be evicted.
+func (mp *Mempool) makeRoom(e entry) error {
+	trans := uint64(count(mp.pending) + count(mp.queued))
+	bytes := mp.totalBytes()
+	accountTrans := uint64(len(mp.pending[e.FromID]) + len(mp.queued[e.FromID]))
+
+	// A replacement takes the place of the existing transaction.
+	if ee, exists := mp.find(e.FromID, e.Nonce); exists {
+		trans--
+		bytes -= ee.size
+		accountTrans--
+	}
+
+	if mp.capacity.MaxPerAccount > 0 && accountTrans >= mp.capacity.MaxPerAccount {
+		err := fmt.Errorf("account has reached the limit of %d transactions in the mempool", mp.capacity.MaxPerAccount)
+		return NewRejectError(ReasonAccountLimit, err)
+	}
+
+	// Only the transaction with the highest nonce for an account can be
+	// evicted, otherwise the transactions that follow it would be left with
+	// a nonce gap. The account adding the transaction is never evicted.
+	tails := make(map[database.AccountID][]entry)
+	for accountID := range mp.bytes {
+		if accountID != e.FromID {
+			tails[accountID] = append(append([]entry{}, mp.pending[accountID]...), mp.queued[accountID]...)
+		}
+	}
+
+	// Work out all the transactions that need to be evicted before touching
+	// the pool, so a rejected transaction leaves the pool as it was.
+	var evict []entry
+	for mp.isFull(trans+1, bytes+e.size) {
+		victim, exists := mp.evictionCandidate(tails)
+		if !exists {
+			return NewRejectError(ReasonPoolFull, errors.New("mempool is full"))
+		}
+
+		if threshold := victim.density; e.density <= threshold {
+			err := fmt.Errorf("transaction fee density %.4f is below the eviction threshold %.4f", e.density, threshold)
+			return NewRejectError(ReasonPoolFull, err)
+		}
+
+		evict = append(evict, victim)
+		tails[victim.FromID] = tails[victim.FromID][:len(tails[victim.FromID])-1]
+		if len(tails[victim.FromID]) == 0 {
+			delete(tails, victim.FromID)
+		}
+
+		trans--
+		bytes -= victim.size
+	}
+
+	for _, victim := range evict {
+		pending := removeByNonce(mp.pending[victim.FromID], victim.Nonce)
+		queued := removeByNonce(mp.queued[victim.FromID], victim.Nonce)
+		mp.setLists(victim.FromID, pending, queued)
+		mp.emit(EventEvict, ReasonPoolFull, victim.BlockTx)
+	}
+
+	return nil
+}
+
+// isFull reports if the specified number of transactions and bytes exceed
+// the capacity of the pool.
+func (mp *Mempool) isFull(trans uint64, bytes uint64) bool {
+	return (mp.capacity.MaxTrans > 0 && trans > mp.capacity.MaxTrans) ||
+		(mp.capacity.MaxBytes > 0 && bytes > mp.capacity.MaxBytes)
+}
+
+// evictionCandidate picks the transaction to evict from the last transaction
+// of each account based on the eviction policy.
+func (mp *Mempool) evictionCandidate(tails map[database.AccountID][]entry) (entry, bool) {
+	var victim entry
+	var exists bool
+
+	// Sort the accounts so ties are always broken the same way.
+	for _, accountID := range sortedAccounts(tails) {
+		list := tails[accountID]
+		e := list[len(list)-1]
+
+		if !exists {
+			victim, exists = e, true
+			continue
+		}
+
+		switch mp.capacity.Eviction {
+		case EvictOldest:
+			if e.TimeStamp < victim.TimeStamp {
+				victim = e
+			}
+		default:
+			if e.density < victim.density {
+				victim = e
+			}
+		}
+	}
+
+	return victim, exists
+}
+
+// totalBytes returns the number of bytes used by all the transactions.
+func (mp *Mempool) totalBytes() uint64 {
+	var bytes uint64
+	for _, b := range mp.bytes {
+		bytes += b
+	}
+
+	return bytes
+}
+
+// promote moves queued transactions for the account to the pending list
+// as long as they continue the contiguous nonce sequence.
+func (mp *Mempool) promote(accountID database.AccountID, nonce uint64) {
+	pending := mp.pending[accountID]
+	queued := mp.queued[accountID]
 
-	// Alternative Implementation without slicing
-	// mp.mu.RLock()
-	// defer mp.mu.RUnlock()
+	next := nonce + 1
+	if len(pending) > 0 {
+		next = pending[len(pending)-1].Nonce + 1
+	}
+
+	for len(queued) > 0 && queued[0].Nonce == next {
+		pending = append(pending, queued[0])
+		queued = queued[1:]
+		next++
+	}
+
+	mp.setLists(accountID, pending, queued)
+}
+
+// demote moves pending transactions for the account that follow a nonce
+// gap back to the queued list.
+func (mp *Mempool) demote(accountID database.AccountID) {
+	pending := mp.pending[accountID]
+	queued := mp.queued[accountID]
+
+	for i := 1; i < len(pending); i++ {
+		if pending[i].Nonce != pending[i-1].Nonce+1 {
+			queued = append(append([]entry{}, pending[i:]...), queued...)
+			sort.Sort(byNonce(queued))
+			pending = pending[:i]
+			break
+		}
+	}
+
+	// The first pending transaction must be the next nonce for the account.
+	if len(pending) > 0 && pending[0].Nonce != mp.account(accountID).Nonce+1 {
+		queued = append(append([]entry{}, pending...), queued...)
+		sort.Sort(byNonce(queued))
+		pending = nil
+	}
+
+	mp.setLists(accountID, pending, queued)
+}
+
+// setLists stores the lists for the account and removes empty lists so
+// they don't accumulate.
+func (mp *Mempool) setLists(accountID database.AccountID, pending []entry, queued []entry) {
+	switch len(pending) {
+	case 0:
+		delete(mp.pending, accountID)
+	default:
+		mp.pending[accountID] = pending
+	}
 
-	// var txs []database.BlockTx
-	// for _, tx := range mp.pool {
-	// 	txs = append(txs, tx)
-	// }
+	switch len(queued) {
+	case 0:
+		delete(mp.queued, accountID)
+	default:
+		mp.queued[accountID] = queued
+	}
 
-	// sort.Sort(byNonce(txs))
+	// Keep track of the bytes used by the account so the pool can be
+	// checked against its capacity.
+	var bytes uint64
+	for _, list := range [][]entry{pending, queued} {
+		for _, e := range list {
+			bytes += e.size
+		}
+	}
+
+	switch bytes {
+	case 0:
+		delete(mp.bytes, accountID)
+	default:
+		mp.bytes[accountID] = bytes
+	}
+}
+
+// copyPending returns a copy of the pending transactions by account along
+// with the number of transactions.
+func (mp *Mempool) copyPending() (map[database.AccountID][]database.BlockTx, int) {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+
+	m := make(map[database.AccountID][]database.BlockTx, len(mp.pending))
+	var total int
+	for accountID, list := range mp.pending {
+		trans := make([]database.BlockTx, len(list))
+		for i, e := range list {
+			trans[i] = e.BlockTx
+		}
+		m[accountID] = trans
+		total += len(trans)
+	}
 
-	// return txs
+	return m, total
 }
 
 // =============================================================================
 
-// mapKey is used to generate the map key.
-func mapKey(tx database.BlockTx) (string, error) {
-	return fmt.Sprintf("%s:%d", tx.FromID, tx.Nonce), nil
+// count returns the number of transactions across all the accounts.
+func count(m map[database.AccountID][]entry) int {
+	var n int
+	for _, list := range m {
+		n += len(list)
+	}
+
+	return n
 }
 
-// accountFromMapKey extracts the account information from the mapkey.
-func accountFromMapKey(key string) database.AccountID {
-	return database.AccountID(strings.Split(key, ":")[0])
+// maxCost returns the most a transaction can be charged, which is the value
+// and tip plus the full gas limit at the max gas price. False is returned
+// when the cost overflows.
+func maxCost(tx database.BlockTx) (uint64, bool) {
+	hi, gas := bits.Mul64(tx.GasLimit, tx.MaxGasPrice)
+	if hi != 0 {
+		return 0, false
+	}
+
+	cost, carry := bits.Add64(tx.Value, tx.Tip, 0)
+	if carry != 0 {
+		return 0, false
+	}
+
+	cost, carry = bits.Add64(cost, gas, 0)
+	if carry != 0 {
+		return 0, false
+	}
+
+	return cost, true
+}
+
+// sortedAccounts returns the accounts in the map sorted by account id.
+func sortedAccounts(m map[database.AccountID][]entry) []database.AccountID {
+	accounts := make([]database.AccountID, 0, len(m))
+	for accountID := range m {
+		accounts = append(accounts, accountID)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i] < accounts[j] })
+
+	return accounts
+}
+
+// upsertByNonce adds the transaction to the list sorted by nonce, replacing
+// any transaction with the same nonce.
+func upsertByNonce(list []entry, e entry) []entry {
+	list = removeByNonce(list, e.Nonce)
+	list = append(list, e)
+	sort.Sort(byNonce(list))
+
+	return list
+}
+
+// replaceByNonce replaces the transaction in the list with the same nonce
+// and reports if there was one to replace.
+func replaceByNonce(list []entry, e entry) bool {
+	for i := range list {
+		if list[i].Nonce == e.Nonce {
+			list[i] = e
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeByNonce returns the list without the transaction with the nonce.
+func removeByNonce(list []entry, nonce uint64) []entry {
+	out := make([]entry, 0, len(list))
+	for _, e := range list {
+		if e.Nonce != nonce {
+			out = append(out, e)
+		}
+	}
+
+	return out
 }
 
 // =============================================================================
 
 // byNonce provides sorting support by the transaction id value.
-type byNonce []database.BlockTx
+type byNonce []entry
 
 // Len returns the number of transactions in the list.
 func (bn byNonce) Len() int {
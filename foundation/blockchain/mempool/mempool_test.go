@@ -0,0 +1,506 @@
+package mempool_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
+)
+
+const (
+	accountA = database.AccountID("0xF01813E4B85e178A83e29B8E7bF26BD830a25f32")
+	accountB = database.AccountID("0xdd6B972ffcc631a62CAE1BB9d80b7ff429c8ebA4")
+	accountC = database.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+)
+
+func Test_NonceGap(t *testing.T) {
+	accounts := newAccounts()
+	mp := newMempool(t, mempool.Capacity{}, accounts)
+
+	// Nonce 2 is missing so nonce 3 has to wait.
+	upsert(t, mp, newTx(accountA, 1, 10))
+	upsert(t, mp, newTx(accountA, 3, 10))
+
+	if got := mp.Count(); got != 2 {
+		t.Fatalf("Should have 2 transactions in the pool, got %d", got)
+	}
+	if got := mp.PendingCount(); got != 1 {
+		t.Fatalf("Should have 1 pending transaction, got %d", got)
+	}
+	if trans := mp.PickBest(); len(trans) != 1 || trans[0].Nonce != 1 {
+		t.Fatalf("Should only pick the transaction before the gap, got %v", trans)
+	}
+	if got := mp.NextNonce(accountA); got != 2 {
+		t.Fatalf("Should need nonce 2 to fill the gap, got %d", got)
+	}
+	if got := mp.NextNonce(accountB); got != 1 {
+		t.Fatalf("Should need nonce 1 for an account without transactions, got %d", got)
+	}
+
+	// Filling the gap promotes the queued transaction.
+	upsert(t, mp, newTx(accountA, 2, 10))
+
+	if got := mp.PendingCount(); got != 3 {
+		t.Fatalf("Should have 3 pending transactions after the gap is filled, got %d", got)
+	}
+	for i, tx := range mp.PickBest() {
+		if tx.Nonce != uint64(i+1) {
+			t.Fatalf("Should pick the transactions in nonce order, got nonce %d at %d", tx.Nonce, i)
+		}
+	}
+	if got := mp.NextNonce(accountA); got != 4 {
+		t.Fatalf("Should need nonce 4 after the pending transactions, got %d", got)
+	}
+}
+
+func Test_RefreshPromotes(t *testing.T) {
+	accounts := newAccounts()
+	mp := newMempool(t, mempool.Capacity{}, accounts)
+
+	// The pool doesn't know about nonce 1, which is mined in a block by
+	// another node, so nonces 2 and 3 are queued.
+	upsert(t, mp, newTx(accountA, 2, 10))
+	upsert(t, mp, newTx(accountA, 3, 10))
+
+	if got := mp.PendingCount(); got != 0 {
+		t.Fatalf("Should have no pending transactions, got %d", got)
+	}
+
+	var removed []mempool.Event
+	mp.SetEventHandler(func(ev mempool.Event) {
+		if ev.Type == mempool.EventRemove {
+			removed = append(removed, ev)
+		}
+	})
+
+	accounts[accountA] = database.Account{AccountID: accountA, Balance: 1_000_000, Nonce: 1}
+	mp.Refresh(accountA)
+
+	if got := mp.PendingCount(); got != 2 {
+		t.Fatalf("Should promote the transactions after the block, got %d pending", got)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("Should not remove transactions with an unused nonce, got %d removed", len(removed))
+	}
+
+	// A block that used nonce 2 with a different transaction drops the
+	// transaction in the pool with that nonce.
+	accounts[accountA] = database.Account{AccountID: accountA, Balance: 1_000_000, Nonce: 2}
+	mp.Refresh(accountA)
+
+	if got := mp.Count(); got != 1 {
+		t.Fatalf("Should drop the transaction with the used nonce, got %d", got)
+	}
+	if got := mp.PendingCount(); got != 1 {
+		t.Fatalf("Should keep the next transaction pending, got %d", got)
+	}
+	if len(removed) != 1 || removed[0].Tx.Nonce != 2 || removed[0].Reason != mempool.ReasonInvalid {
+		t.Fatalf("Should emit a remove event for the used nonce, got %v", removed)
+	}
+}
+
+func Test_Replace(t *testing.T) {
+	accounts := newAccounts()
+	mp := newMempool(t, mempool.Capacity{}, accounts)
+
+	upsert(t, mp, newTx(accountA, 1, 100))
+	upsert(t, mp, newTx(accountA, 2, 100))
+
+	tt := []struct {
+		name   string
+		tip    uint64
+		reason string
+	}{
+		{"same-tip", 100, mempool.ReasonUnderpriced},
+		{"below-bump", 109, mempool.ReasonUnderpriced},
+		{"at-bump", 110, ""},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			err := mp.Upsert(newTx(accountA, 1, tst.tip))
+
+			switch tst.reason {
+			case "":
+				if err != nil {
+					t.Fatalf("Should be able to replace the transaction: %s", err)
+				}
+			default:
+				re := mempool.GetRejectError(err)
+				if re == nil || re.Reason != tst.reason {
+					t.Fatalf("Should reject the replacement with %q, got %v", tst.reason, err)
+				}
+			}
+		})
+	}
+
+	// The replacement keeps its place in the pending list.
+	trans := mp.PickBest()
+	if len(trans) != 2 {
+		t.Fatalf("Should still have 2 pending transactions, got %d", len(trans))
+	}
+	if trans[0].Nonce != 1 || trans[0].Tip != 110 {
+		t.Fatalf("Should pick the replacement first, got %s with tip %d", trans[0], trans[0].Tip)
+	}
+}
+
+func Test_CostOverflow(t *testing.T) {
+	accounts := newAccounts()
+	mp := newMempool(t, mempool.Capacity{}, accounts)
+
+	tt := []struct {
+		name   string
+		change func(tx *database.BlockTx)
+	}{
+		{"gas", func(tx *database.BlockTx) { tx.GasLimit, tx.MaxGasPrice = math.MaxUint64, 2 }},
+		{"value", func(tx *database.BlockTx) { tx.Value, tx.Tip = math.MaxUint64, 1 }},
+		{"value-and-gas", func(tx *database.BlockTx) { tx.Value = math.MaxUint64 - 100 }},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			tx := newTx(accountA, 1, 10)
+			tst.change(&tx)
+
+			re := mempool.GetRejectError(mp.Upsert(tx))
+			if re == nil || re.Reason != mempool.ReasonInvalidCost {
+				t.Fatalf("Should reject the transaction with %q, got %v", mempool.ReasonInvalidCost, re)
+			}
+		})
+	}
+
+	// The cost of the transactions for the account together can overflow.
+	accounts[accountB] = database.Account{AccountID: accountB, Balance: math.MaxUint64}
+
+	tx := newTx(accountB, 1, 10)
+	tx.Value = math.MaxUint64 / 2
+	upsert(t, mp, tx)
+
+	tx = newTx(accountB, 2, 10)
+	tx.Value = math.MaxUint64 / 2
+	re := mempool.GetRejectError(mp.Upsert(tx))
+	if re == nil || re.Reason != mempool.ReasonInvalidCost {
+		t.Fatalf("Should reject the transaction with %q, got %v", mempool.ReasonInvalidCost, re)
+	}
+}
+
+func Test_CapacityLimits(t *testing.T) {
+	size := newTx(accountA, 1, 10).Size()
+
+	tt := []struct {
+		name     string
+		capacity mempool.Capacity
+		trans    []database.BlockTx
+		tx       database.BlockTx
+		reason   string
+	}{
+		{"fits", mempool.Capacity{MaxTrans: 3, MaxBytes: 3 * size, MaxPerAccount: 2}, []database.BlockTx{newTx(accountA, 1, 10), newTx(accountB, 1, 10)}, newTx(accountA, 2, 10), ""},
+		{"max-trans", mempool.Capacity{MaxTrans: 2}, []database.BlockTx{newTx(accountA, 1, 10), newTx(accountB, 1, 10)}, newTx(accountC, 1, 10), mempool.ReasonPoolFull},
+		{"max-bytes", mempool.Capacity{MaxBytes: 2 * size}, []database.BlockTx{newTx(accountA, 1, 10), newTx(accountB, 1, 10)}, newTx(accountC, 1, 10), mempool.ReasonPoolFull},
+		{"max-per-account", mempool.Capacity{MaxPerAccount: 2}, []database.BlockTx{newTx(accountA, 1, 10), newTx(accountA, 2, 10)}, newTx(accountA, 3, 10), mempool.ReasonAccountLimit},
+		{"replace-when-full", mempool.Capacity{MaxTrans: 2, MaxPerAccount: 2}, []database.BlockTx{newTx(accountA, 1, 10), newTx(accountA, 2, 10)}, newTx(accountA, 2, 20), ""},
+		{"own-account-not-evicted", mempool.Capacity{MaxTrans: 2}, []database.BlockTx{newTx(accountA, 1, 10), newTx(accountA, 2, 10)}, newTx(accountA, 3, 100), mempool.ReasonPoolFull},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			mp := newMempool(t, tst.capacity, newAccounts())
+			for _, tx := range tst.trans {
+				upsert(t, mp, tx)
+			}
+
+			err := mp.Upsert(tst.tx)
+
+			switch tst.reason {
+			case "":
+				if err != nil {
+					t.Fatalf("Should be able to add the transaction: %s", err)
+				}
+			default:
+				re := mempool.GetRejectError(err)
+				if re == nil || re.Reason != tst.reason {
+					t.Fatalf("Should reject the transaction with %q, got %v", tst.reason, err)
+				}
+				if got := mp.Count(); got != len(tst.trans) {
+					t.Fatalf("Should leave the pool as it was, got %d transactions", got)
+				}
+			}
+		})
+	}
+}
+
+func Test_Eviction(t *testing.T) {
+
+	// Account A pays the least and account B has been in the pool the
+	// longest.
+	cheap := newTx(accountA, 1, 10)
+	cheap.TimeStamp = 2
+	oldest := newTx(accountB, 1, 50)
+	oldest.TimeStamp = 1
+
+	tt := []struct {
+		name     string
+		eviction string
+		exp      database.AccountID
+	}{
+		{"fee-density", mempool.EvictFeeDensity, accountA},
+		{"oldest", mempool.EvictOldest, accountB},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			mp := newMempool(t, mempool.Capacity{MaxTrans: 2, Eviction: tst.eviction}, newAccounts())
+			upsert(t, mp, cheap)
+			upsert(t, mp, oldest)
+
+			var evicted []mempool.Event
+			mp.SetEventHandler(func(ev mempool.Event) {
+				if ev.Type == mempool.EventEvict {
+					evicted = append(evicted, ev)
+				}
+			})
+
+			// A transaction paying less than the transaction that would be
+			// evicted is rejected.
+			if err := mp.Upsert(newTx(accountC, 1, 1)); err == nil {
+				t.Fatal("Should reject a transaction below the eviction threshold")
+			}
+
+			upsert(t, mp, newTx(accountC, 1, 100))
+
+			if got := mp.Count(); got != 2 {
+				t.Fatalf("Should keep the pool at capacity, got %d transactions", got)
+			}
+			if len(evicted) != 1 || evicted[0].Tx.FromID != tst.exp || evicted[0].Reason != mempool.ReasonPoolFull {
+				t.Fatalf("Should evict the transaction for %s, got %v", tst.exp, evicted)
+			}
+		})
+	}
+}
+
+func Test_Sweep(t *testing.T) {
+	const ttl = time.Minute
+	now := time.UnixMilli(10_000_000)
+	cutoff := uint64(now.Add(-ttl).UnixMilli())
+
+	// stamp changes the time the transaction was received and the last
+	// block it can be mined in.
+	stamp := func(tx database.BlockTx, timeStamp uint64, validUntil uint64) database.BlockTx {
+		tx.TimeStamp = timeStamp
+		tx.ValidUntil = validUntil
+		return tx
+	}
+
+	tt := []struct {
+		name   string
+		tx     database.BlockTx
+		reason string
+	}{
+		{"before-cutoff", stamp(newTx(accountA, 1, 10), cutoff-1, 0), mempool.ReasonTTL},
+		{"at-cutoff", stamp(newTx(accountA, 1, 10), cutoff, 0), ""},
+		{"after-cutoff", stamp(newTx(accountA, 1, 10), cutoff+1, 0), ""},
+		{"valid-until-before-block", stamp(newTx(accountA, 1, 10), cutoff, 4), mempool.ReasonExpired},
+		{"valid-until-block", stamp(newTx(accountA, 1, 10), cutoff, 5), ""},
+		{"expired-and-old", stamp(newTx(accountA, 1, 10), cutoff-1, 4), mempool.ReasonExpired},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			mp := newMempool(t, mempool.Capacity{TTL: ttl}, newAccounts())
+			upsert(t, mp, tst.tx)
+			upsert(t, mp, stamp(newTx(accountA, 2, 10), cutoff, 0))
+
+			evictions := mp.Sweep(now, 5)
+
+			switch tst.reason {
+			case "":
+				if len(evictions) != 0 {
+					t.Fatalf("Should not evict the transaction, got %v", evictions)
+				}
+				if got := mp.PendingCount(); got != 2 {
+					t.Fatalf("Should keep both transactions pending, got %d", got)
+				}
+
+			default:
+				if len(evictions) != 1 || evictions[0].Reason != tst.reason || evictions[0].Tx.Nonce != 1 {
+					t.Fatalf("Should evict the transaction with %q, got %v", tst.reason, evictions)
+				}
+
+				// The transaction that followed the evicted one is left
+				// waiting on the nonce gap.
+				if got := mp.Count(); got != 1 {
+					t.Fatalf("Should keep the next transaction, got %d", got)
+				}
+				if got := mp.PendingCount(); got != 0 {
+					t.Fatalf("Should queue the next transaction, got %d pending", got)
+				}
+			}
+		})
+	}
+}
+
+func Test_CopyFair(t *testing.T) {
+	accounts := newAccounts()
+	account := func(accountID database.AccountID) database.Account {
+		return accounts[accountID]
+	}
+
+	mp, err := mempool.NewWithStrategy("fair", mempool.Capacity{}, account)
+	if err != nil {
+		t.Fatalf("Should be able to construct the mempool: %s", err)
+	}
+
+	// More pending transactions than the fair strategy lets one account have
+	// in a block, and one queued behind a gap.
+	for nonce := uint64(1); nonce <= 6; nonce++ {
+		upsert(t, mp, newTx(accountA, nonce, 10))
+	}
+	upsert(t, mp, newTx(accountA, 8, 10))
+
+	if got := len(mp.PickBest()); got != 6 {
+		t.Fatalf("Should pick all 6 pending transactions, got %d", got)
+	}
+	if got := len(mp.Copy()); got != 7 {
+		t.Fatalf("Should copy all 7 transactions, got %d", got)
+	}
+
+	// Rotating the journal with the copy keeps every transaction.
+	journal := mempool.NewJournal(filepath.Join(t.TempDir(), "mempool.journal"))
+	if err := journal.Rotate(mp.Copy()); err != nil {
+		t.Fatalf("Should be able to rotate the journal: %s", err)
+	}
+
+	trans, err := journal.Load()
+	if err != nil {
+		t.Fatalf("Should be able to load the journal: %s", err)
+	}
+	if len(trans) != 7 {
+		t.Fatalf("Should keep all 7 transactions in the journal, got %d", len(trans))
+	}
+}
+
+func Test_PickBlocks(t *testing.T) {
+	mp := newMempool(t, mempool.Capacity{}, newAccounts())
+
+	// Account B pays more, so its transactions are packed first.
+	for nonce := uint64(1); nonce <= 3; nonce++ {
+		upsert(t, mp, newTx(accountA, nonce, 10))
+	}
+	for nonce := uint64(1); nonce <= 2; nonce++ {
+		upsert(t, mp, newTx(accountB, nonce, 20))
+	}
+
+	onlyB := func(tx database.BlockTx) error {
+		if tx.FromID != accountB {
+			return errors.New("account can't pay")
+		}
+		return nil
+	}
+
+	tt := []struct {
+		name  string
+		n     int
+		valid selector.ValidFunc
+		exp   []string
+	}{
+		{"all", 5, nil, []string{"B1 B2", "A1 A2", "A3"}},
+		{"first", 2, nil, []string{"B1 B2", "A1 A2"}},
+		{"stop-empty", 5, onlyB, []string{"B1 B2"}},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			blocks := mp.PickBlocks(tst.n, selector.Limits{Trans: 2}, tst.valid)
+
+			got := make([]string, len(blocks))
+			for i, trans := range blocks {
+				ids := make([]string, len(trans))
+				for j, tx := range trans {
+					name := "A"
+					if tx.FromID == accountB {
+						name = "B"
+					}
+					ids[j] = fmt.Sprintf("%s%d", name, tx.Nonce)
+				}
+				got[i] = strings.Join(ids, " ")
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tst.exp) {
+				t.Fatalf("Should pack the blocks %q, got %q", tst.exp, got)
+			}
+		})
+	}
+
+	// Packing ahead doesn't take anything out of the pool.
+	if got := mp.Count(); got != 5 {
+		t.Fatalf("Should keep all 5 transactions in the pool, got %d", got)
+	}
+}
+
+// =============================================================================
+
+// newAccounts constructs the accounts on the chain the pool checks the
+// transactions against.
+func newAccounts() map[database.AccountID]database.Account {
+	return map[database.AccountID]database.Account{
+		accountA: {AccountID: accountA, Balance: 1_000_000},
+		accountB: {AccountID: accountB, Balance: 1_000_000},
+		accountC: {AccountID: accountC, Balance: 1_000_000},
+	}
+}
+
+// newMempool constructs a pool using the fee density strategy so the
+// transactions are ordered by what they pay.
+func newMempool(t *testing.T, capacity mempool.Capacity, accounts map[database.AccountID]database.Account) *mempool.Mempool {
+	t.Helper()
+
+	account := func(accountID database.AccountID) database.Account {
+		return accounts[accountID]
+	}
+
+	mp, err := mempool.NewWithStrategy("fee_density", capacity, account)
+	if err != nil {
+		t.Fatalf("Should be able to construct the mempool: %s", err)
+	}
+
+	return mp
+}
+
+// upsert adds the transaction to the pool and fails the test when it's
+// rejected.
+func upsert(t *testing.T, mp *mempool.Mempool, tx database.BlockTx) {
+	t.Helper()
+
+	if err := mp.Upsert(tx); err != nil {
+		t.Fatalf("Should be able to add %s: %s", tx, err)
+	}
+}
+
+// newTx constructs a block transaction for the account with the specified
+// nonce and tip.
+func newTx(from database.AccountID, nonce uint64, tip uint64) database.BlockTx {
+	tx := database.Tx{
+		ChainID:     1,
+		Nonce:       nonce,
+		FromID:      from,
+		ToID:        accountC,
+		Value:       10,
+		Tip:         tip,
+		GasLimit:    30,
+		MaxGasPrice: 20,
+	}
+
+	return database.BlockTx{
+		SignedTx:  database.SignedTx{Tx: tx},
+		TimeStamp: nonce,
+		GasPrice:  15,
+		GasUnits:  30,
+	}
+}
@@ -0,0 +1,75 @@
+package mempool
+
+import "errors"
+
+// Set of reasons a transaction can be rejected from or evicted out of the
+// mempool. These are stable values a wallet can use to tell the user what
+// went wrong.
+const (
+	ReasonInvalidChainID    = "invalid_chain_id"
+	ReasonInvalidAccount    = "invalid_account"
+	ReasonInvalidSignature  = "invalid_signature"
+	ReasonInvalidCost       = "invalid_cost"
+	ReasonInvalidGas        = "invalid_gas"
+	ReasonStaleNonce        = "stale_nonce"
+	ReasonUnderpriced       = "replacement_underpriced"
+	ReasonInsufficientFunds = "insufficient_funds"
+	ReasonAccountLimit      = "account_limit"
+	ReasonPoolFull          = "pool_full"
+	ReasonExpired           = "expired"
+	ReasonTTL               = "ttl_exceeded"
+)
+
+// IsInvalid reports if the reason means the transaction itself is invalid
+// and could never be accepted by any node, as opposed to being rejected by
+// the policy of this node's mempool.
+func IsInvalid(reason string) bool {
+	switch reason {
+	case ReasonInvalidChainID, ReasonInvalidAccount, ReasonInvalidSignature,
+		ReasonInvalidGas, ReasonInvalidCost:
+		return true
+	}
+
+	return false
+}
+
+// =============================================================================
+
+// RejectError is used to pass back the reason a transaction was not
+// accepted into the mempool.
+type RejectError struct {
+	Reason string
+	Err    error
+}
+
+// NewRejectError wraps a provided error with the reason the transaction
+// was rejected.
+func NewRejectError(reason string, err error) error {
+	return &RejectError{reason, err}
+}
+
+// Error implements the error interface. It uses the default message of the
+// wrapped error.
+func (re *RejectError) Error() string {
+	return re.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (re *RejectError) Unwrap() error {
+	return re.Err
+}
+
+// IsRejectError checks if an error of type RejectError exists.
+func IsRejectError(err error) bool {
+	var re *RejectError
+	return errors.As(err, &re)
+}
+
+// GetRejectError returns a copy of the RejectError pointer.
+func GetRejectError(err error) *RejectError {
+	var re *RejectError
+	if !errors.As(err, &re) {
+		return nil
+	}
+	return re
+}
@@ -6,98 +6,118 @@ import (
 	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
 )
 
+// CORE NOTE: Taking a transaction from an account means taking every
+// transaction with a lower nonce for that account first. So for each account
+// the choice is how many transactions to take from the front of its list.
+// Picking those counts to get the most tips for howMany transactions is a
+// knapsack problem where each account is a group and exactly one choice is
+// made per group. Trying every combination grows exponentially with the
+// number of accounts. Dynamic programming solves it by keeping, for every
+// number of transactions, the best total tip found so far as each account is
+// added. The work is bounded by accounts * howMany * howMany.
+
 // advancedTipSelect returns transactions with the best tip while respecting the nonce
 // for each account/transaction. This strategy takes into account high-value transactions
 // that happens to be stuck on a low-nonce transaction with a low tip price.
 var advancedTipSelect = func(m map[database.AccountID][]database.BlockTx, howMany int) []database.BlockTx {
-	final := []database.BlockTx{}
 
-	// Sort the transactions per account by nonce.
-	for key := range m {
-		if len(m[key]) > 1 {
-			sort.Sort(byNonce(m[key]))
+	// Sort the accounts so ties are always broken the same way and sort
+	// the transactions per account by nonce.
+	m = copyPool(m)
+	accounts := make([]database.AccountID, 0, len(m))
+	var total int
+	for from, trans := range m {
+		if len(trans) == 0 {
+			continue
 		}
+		sort.Sort(byNonce(trans))
+		accounts = append(accounts, from)
+		total += len(trans)
 	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i] < accounts[j] })
 
-	at := newAdvancedTips(m, howMany)
-	for from, num := range at.findBest() {
-		for i := 0; i < num; i++ {
-			final = append(final, m[from][i])
+	// When every transaction fits, there is nothing to choose.
+	if howMany <= 0 || howMany >= total {
+		final := make([]database.BlockTx, 0, total)
+		for _, from := range accounts {
+			final = append(final, m[from]...)
 		}
+		return final
 	}
 
-	return final
-}
+	take := bestPrefixes(m, accounts, howMany)
 
-// =============================================================================
+	final := make([]database.BlockTx, 0, howMany)
+	for i, from := range accounts {
+		final = append(final, m[from][:take[i]]...)
+	}
 
-type advancedTips struct {
-	howMany   int
-	bestTip   uint64
-	bestPos   map[database.AccountID]int
-	groupTips map[database.AccountID][]uint64
-	groups    []database.AccountID
+	return final
 }
 
-func newAdvancedTips(m map[database.AccountID][]database.BlockTx, howMany int) *advancedTips {
-	groupTips := map[database.AccountID][]uint64{}
-	groups := []database.AccountID{}
+// =============================================================================
 
-	for from := range m {
-		groupTips[from] = []uint64{0}
-		groups = append(groups, from)
-	}
+// bestPrefixes calculates how many transactions to take from the front of
+// each account's list to get the most tips using no more than howMany
+// transactions.
+func bestPrefixes(m map[database.AccountID][]database.BlockTx, accounts []database.AccountID, howMany int) []int {
 
-	for from, group := range m {
-		for i, tx := range group {
-			if i > howMany {
-				break
-			}
-			groupTips[from] = append(groupTips[from], tx.Tip+groupTips[from][i])
-		}
+	// best[k] is the most tips found using exactly k transactions, or -1
+	// when k transactions can't be reached with the accounts seen so far.
+	best := make([]int64, howMany+1)
+	for k := 1; k <= howMany; k++ {
+		best[k] = -1
 	}
 
-	return &advancedTips{
-		howMany:   howMany,
-		groupTips: groupTips,
-		groups:    groups,
-	}
-}
+	// choice[i][k] records how many transactions were taken from account i
+	// to reach best[k] after account i was added.
+	choice := make([][]int, len(accounts))
 
-func (at *advancedTips) findBest() map[database.AccountID]int {
-	at.findBestTransactions(0, 0, at.howMany, at.bestPos, 0)
-	return at.bestPos
-}
+	for i, from := range accounts {
+		trans := m[from]
+		limit := len(trans)
+		if limit > howMany {
+			limit = howMany
+		}
 
-func (at *advancedTips) findBestTransactions(groupID, pos int, left int, currPos map[database.AccountID]int, prevTip uint64) {
-	if prevTip > at.bestTip {
-		at.bestTip = prevTip
-		at.bestPos = currPos
-	}
+		// Running total of the tips for the first n transactions.
+		prefix := make([]int64, limit+1)
+		for n := 1; n <= limit; n++ {
+			prefix[n] = prefix[n-1] + int64(trans[n-1].Tip)
+		}
 
-	if groupID >= len(at.groups) {
-		return
+		next := make([]int64, howMany+1)
+		choice[i] = make([]int, howMany+1)
+		for k := 0; k <= howMany; k++ {
+			next[k] = best[k]
+			for n := 1; n <= limit && n <= k; n++ {
+				if best[k-n] < 0 {
+					continue
+				}
+				if tips := best[k-n] + prefix[n]; tips > next[k] {
+					next[k] = tips
+					choice[i][k] = n
+				}
+			}
+		}
+		best = next
 	}
-	from := at.groups[groupID]
 
-	for pos, tip := range at.groupTips[from] {
-		if left-pos < 0 {
-			break
+	// Find the number of transactions with the most tips, preferring more
+	// transactions when the tips are the same.
+	k := 0
+	for n := 1; n <= howMany; n++ {
+		if best[n] >= best[k] {
+			k = n
 		}
-
-		newCurrPos := copyMap(currPos)
-		newCurrPos[from] = pos
-		at.findBestTransactions(groupID+1, pos, left-pos, newCurrPos, prevTip+tip)
 	}
-}
-
-// =============================================================================
 
-func copyMap(m map[database.AccountID]int) map[database.AccountID]int {
-	newCurrPos := map[database.AccountID]int{}
-	for from, pos := range m {
-		newCurrPos[from] = pos
+	// Walk back through the choices to find the count for each account.
+	take := make([]int, len(accounts))
+	for i := len(accounts) - 1; i >= 0; i-- {
+		take[i] = choice[i][k]
+		k -= take[i]
 	}
 
-	return newCurrPos
+	return take
 }
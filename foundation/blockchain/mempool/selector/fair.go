@@ -0,0 +1,24 @@
+package selector
+
+import (
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+)
+
+// CORE NOTE: When a single account floods the mempool with well paying
+// transactions, every other account can be pushed out of the block. The fair
+// strategy still picks transactions by fee density but caps the number of
+// transactions any one account can have in a block. Once an account reaches
+// the cap, the rest of the block is shared by the other accounts.
+
+// fairShareLimit represents the most transactions a single account can have
+// selected for one block.
+const fairShareLimit = 4
+
+// =============================================================================
+
+// fairSelect returns transactions with the best fee per byte while respecting
+// the nonce for each account/transaction and the cap per account. When all
+// the transactions are requested, the cap doesn't apply.
+var fairSelect = func(m map[database.AccountID][]database.BlockTx, howMany int) []database.BlockTx {
+	return densitySelect(m, howMany, fairShareLimit)
+}
@@ -0,0 +1,118 @@
+package selector
+
+import (
+	"container/heap"
+	"sort"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+)
+
+// CORE NOTE: A block has a limited number of bytes, so a miner wants the
+// transactions that pay the most for every byte they take up. The fee for a
+// transaction is the tip plus the gas it pays for. Only the next transaction
+// for each account can be selected, so a heap of the account heads is kept
+// ordered by fee density. Each time the best head is taken, the next
+// transaction for that account takes its place in the heap.
+
+// feeDensitySelect returns transactions with the best fee per byte while
+// respecting the nonce for each account/transaction.
+var feeDensitySelect = func(m map[database.AccountID][]database.BlockTx, howMany int) []database.BlockTx {
+	return densitySelect(m, howMany, 0)
+}
+
+// =============================================================================
+
+// densitySelect selects up to howMany transactions ordered by fee density.
+// When perAccount is greater than 0, no more than that many transactions
+// are selected for any one account. Receiving 0 for howMany returns all the
+// transactions ordered by fee density without a limit per account.
+func densitySelect(m map[database.AccountID][]database.BlockTx, howMany int, perAccount int) []database.BlockTx {
+	m = copyPool(m)
+
+	h := make(densityHeap, 0, len(m))
+	var total int
+	for from, trans := range m {
+		if len(trans) == 0 {
+			continue
+		}
+		sort.Sort(byNonce(trans))
+		h = append(h, newDensityItem(from, trans, 0))
+		total += len(trans)
+	}
+	heap.Init(&h)
+
+	if howMany <= 0 {
+		howMany = total
+		perAccount = 0
+	}
+
+	final := []database.BlockTx{}
+	for h.Len() > 0 && len(final) < howMany {
+		item := heap.Pop(&h).(densityItem)
+		final = append(final, item.trans[item.pos])
+
+		next := item.pos + 1
+		if next < len(item.trans) && (perAccount <= 0 || next < perAccount) {
+			heap.Push(&h, newDensityItem(item.from, item.trans, next))
+		}
+	}
+
+	return final
+}
+
+// densityItem represents the next transaction that can be selected for an
+// account. The fee density is calculated once since it requires marshaling
+// the transaction to know its size.
+type densityItem struct {
+	from    database.AccountID
+	trans   []database.BlockTx
+	pos     int
+	density float64
+}
+
+// newDensityItem constructs an item for the transaction at the specified
+// position for the account.
+func newDensityItem(from database.AccountID, trans []database.BlockTx, pos int) densityItem {
+	return densityItem{
+		from:    from,
+		trans:   trans,
+		pos:     pos,
+		density: trans[pos].FeeDensity(),
+	}
+}
+
+// densityHeap implements heap.Interface with the best fee density on top.
+// Ties are broken by account so the selection is always the same.
+type densityHeap []densityItem
+
+// Len returns the number of items in the heap.
+func (dh densityHeap) Len() int {
+	return len(dh)
+}
+
+// Less orders the items by fee density in descending order.
+func (dh densityHeap) Less(i, j int) bool {
+	if dh[i].density != dh[j].density {
+		return dh[i].density > dh[j].density
+	}
+	return dh[i].from < dh[j].from
+}
+
+// Swap moves items in the heap.
+func (dh densityHeap) Swap(i, j int) {
+	dh[i], dh[j] = dh[j], dh[i]
+}
+
+// Push adds an item to the heap.
+func (dh *densityHeap) Push(x any) {
+	*dh = append(*dh, x.(densityItem))
+}
+
+// Pop removes the last item from the heap.
+func (dh *densityHeap) Pop() any {
+	old := *dh
+	n := len(old)
+	item := old[n-1]
+	*dh = old[:n-1]
+	return item
+}
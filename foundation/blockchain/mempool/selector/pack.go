@@ -0,0 +1,104 @@
+package selector
+
+import (
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+)
+
+// CORE NOTE: A block is limited by the number of transactions, the number of
+// bytes and the amount of gas. The strategy decides the order the
+// transactions are considered in and packing takes them in that order while
+// they still fit. When a transaction is left behind, every transaction that
+// follows it for the same account would fail on a nonce gap, so they are
+// left behind as well. Some strategies only rank the transactions when asked
+// for fewer than they hold, so the strategy is first asked for a block's worth
+// and the full order is only used to fill the space left behind.
+
+// Limits represents the capacity of a block the selected transactions must
+// fit within. A value of 0 means there is no limit.
+type Limits struct {
+	Trans uint64
+	Bytes uint64
+	Gas   uint64
+}
+
+// ValidFunc reports if a transaction can be mined into the block being
+// packed. A transaction that returns an error is left behind.
+type ValidFunc func(tx database.BlockTx) error
+
+// Pack uses the select function to order the transactions and packs as many
+// of them as fit within the limits. The valid function is optional.
+func Pack(fn Func, m map[database.AccountID][]database.BlockTx, limits Limits, valid ValidFunc) []database.BlockTx {
+	var total int
+	for _, trans := range m {
+		total += len(trans)
+	}
+	if total == 0 {
+		return []database.BlockTx{}
+	}
+
+	// Ask for a block's worth first, then for every transaction so the ones
+	// left behind can be replaced by transactions further down the order.
+	ordered := fn(m, total)
+	if limits.Trans > 0 && limits.Trans < uint64(total) {
+		ordered = fill(fn(m, int(limits.Trans)), ordered)
+	}
+
+	skip := make(map[database.AccountID]bool)
+	final := []database.BlockTx{}
+	var bytes, gas uint64
+	for _, tx := range ordered {
+		if limits.Trans > 0 && uint64(len(final)) == limits.Trans {
+			break
+		}
+
+		if skip[tx.FromID] {
+			continue
+		}
+
+		if valid != nil {
+			if err := valid(tx); err != nil {
+				skip[tx.FromID] = true
+				continue
+			}
+		}
+
+		// The size requires marshaling the transaction, so it's only
+		// calculated once.
+		size := tx.Size()
+		if (limits.Bytes > 0 && bytes+size > limits.Bytes) ||
+			(limits.Gas > 0 && gas+tx.GasUnits > limits.Gas) {
+			skip[tx.FromID] = true
+			continue
+		}
+
+		final = append(final, tx)
+		bytes += size
+		gas += tx.GasUnits
+	}
+
+	return final
+}
+
+// fill returns the picked transactions followed by the transactions in the
+// order that weren't picked.
+func fill(picked []database.BlockTx, order []database.BlockTx) []database.BlockTx {
+	type key struct {
+		from  database.AccountID
+		nonce uint64
+	}
+
+	seen := make(map[key]bool, len(picked))
+	for _, tx := range picked {
+		seen[key{tx.FromID, tx.Nonce}] = true
+	}
+
+	final := make([]database.BlockTx, 0, len(order))
+	final = append(final, picked...)
+	for _, tx := range order {
+		if !seen[key{tx.FromID, tx.Nonce}] {
+			final = append(final, tx)
+		}
+	}
+
+	return final
+}
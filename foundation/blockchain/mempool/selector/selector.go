@@ -2,8 +2,11 @@
 package selector
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
 )
@@ -12,22 +15,59 @@ import (
 const (
 	StrategyTip         = "tip"
 	StrategyTipAdvanced = "tip_advanced"
+	StrategyFeeDensity  = "fee_density"
+	StrategyFair        = "fair"
 )
 
-// Map of different select strategies with functions.
+// mu protects the map of strategies since custom strategies can be
+// registered at any time.
+var mu sync.RWMutex
+
+// Map of different select strategies with functions. Custom strategies are
+// added to this map with Register.
 var strategies = map[string]Func{
 	StrategyTip:         tipSelect,
 	StrategyTipAdvanced: advancedTipSelect,
+	StrategyFeeDensity:  feeDensitySelect,
+	StrategyFair:        fairSelect,
 }
 
 // Func defines a function that takes a mempool of transactions grouped by
 // account and selects howMany of them in an order based on the functions
 // strategy. All selector functions MUST respect nonce ordering. Receiving 0
 // for howMany must return all the transactions in the strategies ordering.
+// The same transactions must always produce the same selection and the
+// transactions passed in must not be modified. The selectortest package
+// provides a conformance test for these rules.
 type Func func(transactions map[database.AccountID][]database.BlockTx, howMany int) []database.BlockTx
 
+// Register adds a custom select strategy that can then be retrieved by name.
+// Names are not case sensitive and a name can only be registered once.
+func Register(strategy string, fn Func) error {
+	if strategy == "" {
+		return errors.New("strategy name must be provided")
+	}
+	if fn == nil {
+		return fmt.Errorf("strategy %q must have a function", strategy)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	name := strings.ToLower(strategy)
+	if _, exists := strategies[name]; exists {
+		return fmt.Errorf("strategy %q is already registered", strategy)
+	}
+
+	strategies[name] = fn
+	return nil
+}
+
 // Retrieve returns the specified select strategy function.
 func Retrieve(strategy string) (Func, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
 	fn, exists := strategies[strings.ToLower(strategy)]
 	if !exists {
 		return nil, fmt.Errorf("strategy %q does not exist", strategy)
@@ -35,6 +75,33 @@ func Retrieve(strategy string) (Func, error) {
 	return fn, nil
 }
 
+// Strategies returns the names of all the registered strategies.
+func Strategies() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// =============================================================================
+
+// copyPool returns a copy of the transactions for each account so they can be
+// sorted and sliced without changing the caller's transactions.
+func copyPool(m map[database.AccountID][]database.BlockTx) map[database.AccountID][]database.BlockTx {
+	cp := make(map[database.AccountID][]database.BlockTx, len(m))
+	for from, trans := range m {
+		cp[from] = append([]database.BlockTx{}, trans...)
+	}
+
+	return cp
+}
+
 // =============================================================================
 
 // byNonce provides sorting support by the transaction id value.
@@ -0,0 +1,345 @@
+package selector_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector/selectortest"
+)
+
+func Test_Conformance(t *testing.T) {
+	strategies := []string{
+		selector.StrategyTip,
+		selector.StrategyTipAdvanced,
+		selector.StrategyFeeDensity,
+		selector.StrategyFair,
+	}
+
+	for _, strategy := range strategies {
+		fn, err := selector.Retrieve(strategy)
+		if err != nil {
+			t.Fatalf("Should be able to retrieve the strategy %q: %s", strategy, err)
+		}
+
+		t.Run(strategy, func(t *testing.T) {
+			selectortest.Run(t, fn)
+		})
+	}
+}
+
+// registered is used to give each run of Test_Register a unique name since
+// strategies can't be unregistered.
+var registered int
+
+func Test_Register(t *testing.T) {
+	registered++
+	name := fmt.Sprintf("Test_Lanes_%d", registered)
+
+	fn, err := selector.Retrieve(selector.StrategyFeeDensity)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the strategy: %s", err)
+	}
+
+	lanes := func(m map[database.AccountID][]database.BlockTx, howMany int) []database.BlockTx {
+		return fn(m, howMany)
+	}
+
+	if err := selector.Register(name, lanes); err != nil {
+		t.Fatalf("Should be able to register a new strategy: %s", err)
+	}
+
+	custom, err := selector.Retrieve(strings.ToLower(name))
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the registered strategy: %s", err)
+	}
+	selectortest.Run(t, custom)
+
+	if err := selector.Register(strings.ToUpper(name), lanes); err == nil {
+		t.Fatal("Should not be able to register the same name twice")
+	}
+
+	if err := selector.Register(selector.StrategyTip, lanes); err == nil {
+		t.Fatal("Should not be able to replace a built in strategy")
+	}
+
+	if err := selector.Register("no_func", nil); err == nil {
+		t.Fatal("Should not be able to register a strategy without a function")
+	}
+}
+
+func Test_AdvancedTipUnsticks(t *testing.T) {
+	fn, err := selector.Retrieve(selector.StrategyTipAdvanced)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the strategy: %s", err)
+	}
+
+	// Bill has a high tip stuck behind a low tip. Taking both pays more
+	// than taking the next best transactions from the other accounts.
+	m := map[database.AccountID][]database.BlockTx{
+		"bill": {selectortest.NewTx("bill", 1, 10), selectortest.NewTx("bill", 2, 500)},
+		"pavl": {selectortest.NewTx("pavl", 1, 100)},
+		"edua": {selectortest.NewTx("edua", 1, 90)},
+	}
+
+	trans := fn(m, 2)
+	if len(trans) != 2 {
+		t.Fatalf("Should get back 2 transactions, got %d", len(trans))
+	}
+
+	for i, tx := range trans {
+		if tx.FromID != "bill" || tx.Nonce != uint64(i+1) {
+			t.Fatalf("Should get back bill's transactions in nonce order, got %s", tx)
+		}
+	}
+}
+
+func Test_FeeDensityOrdering(t *testing.T) {
+	fn, err := selector.Retrieve(selector.StrategyFeeDensity)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the strategy: %s", err)
+	}
+
+	// Bill pays the biggest tip but the transaction carries data, so it
+	// pays less for each byte than the transaction from pavl.
+	bill := selectortest.NewTx("bill", 1, 200)
+	bill.Data = make([]byte, 1_000)
+	pavl := selectortest.NewTx("pavl", 1, 100)
+	edua := selectortest.NewTx("edua", 1, 10)
+
+	m := map[database.AccountID][]database.BlockTx{
+		"bill": {bill},
+		"pavl": {pavl},
+		"edua": {edua},
+	}
+
+	trans := fn(m, 0)
+	if len(trans) != 3 {
+		t.Fatalf("Should get back 3 transactions, got %d", len(trans))
+	}
+
+	for i := 1; i < len(trans); i++ {
+		if trans[i].FeeDensity() > trans[i-1].FeeDensity() {
+			t.Fatalf("Should order the transactions by fee density, %s pays more than %s", trans[i], trans[i-1])
+		}
+	}
+
+	if trans[0].FromID != "pavl" || trans[2].FromID != "bill" {
+		t.Fatalf("Should pick pavl first and bill last, got %v", trans)
+	}
+}
+
+func Test_FairShare(t *testing.T) {
+	fn, err := selector.Retrieve(selector.StrategyFair)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the strategy: %s", err)
+	}
+
+	// Bill floods the pool with transactions that pay more than everyone.
+	pool := func() map[database.AccountID][]database.BlockTx {
+		m := map[database.AccountID][]database.BlockTx{
+			"pavl": {selectortest.NewTx("pavl", 1, 10), selectortest.NewTx("pavl", 2, 10)},
+			"edua": {selectortest.NewTx("edua", 1, 10)},
+		}
+		for nonce := uint64(1); nonce <= 6; nonce++ {
+			m["bill"] = append(m["bill"], selectortest.NewTx("bill", nonce, 500))
+		}
+		return m
+	}
+
+	tt := []struct {
+		name    string
+		howMany int
+		bill    int
+		total   int
+	}{
+		{"capped", 5, 4, 5},
+		{"capped-with-room", 9, 4, 7},
+		{"all", 0, 6, 9},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			trans := fn(pool(), tst.howMany)
+			if len(trans) != tst.total {
+				t.Fatalf("Should get back %d transactions, got %d", tst.total, len(trans))
+			}
+
+			var bill int
+			for _, tx := range trans {
+				if tx.FromID == "bill" {
+					bill++
+				}
+			}
+			if bill != tst.bill {
+				t.Fatalf("Should select %d of bill's transactions, got %d", tst.bill, bill)
+			}
+		})
+	}
+}
+
+func Test_Pack(t *testing.T) {
+	fn, err := selector.Retrieve(selector.StrategyFeeDensity)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the strategy: %s", err)
+	}
+
+	// Bill pays the most, then pavl, then edua. Bill's second transaction
+	// uses more gas than the others.
+	bill1 := selectortest.NewTx("bill", 1, 500)
+	bill2 := selectortest.NewTx("bill", 2, 400)
+	bill2.GasUnits = 100
+	bill3 := selectortest.NewTx("bill", 3, 300)
+	pavl1 := selectortest.NewTx("pavl", 1, 200)
+	edua1 := selectortest.NewTx("edua", 1, 100)
+
+	pool := func() map[database.AccountID][]database.BlockTx {
+		return map[database.AccountID][]database.BlockTx{
+			"bill": {bill1, bill2, bill3},
+			"pavl": {pavl1},
+			"edua": {edua1},
+		}
+	}
+
+	invalid := func(bad database.BlockTx) selector.ValidFunc {
+		return func(tx database.BlockTx) error {
+			if tx.FromID == bad.FromID && tx.Nonce == bad.Nonce {
+				return errors.New("invalid")
+			}
+			return nil
+		}
+	}
+
+	tt := []struct {
+		name   string
+		limits selector.Limits
+		valid  selector.ValidFunc
+		exp    []database.BlockTx
+	}{
+		{"no-limits", selector.Limits{}, nil, []database.BlockTx{bill1, bill2, bill3, pavl1, edua1}},
+		{"trans", selector.Limits{Trans: 2}, nil, []database.BlockTx{bill1, bill2}},
+		{"bytes", selector.Limits{Bytes: bill1.Size() + bill2.Size() + bill3.Size()}, nil, []database.BlockTx{bill1, bill2, bill3}},
+		{"gas", selector.Limits{Gas: 21 + 100 + 21}, nil, []database.BlockTx{bill1, bill2, bill3}},
+		{"gas-skips-account", selector.Limits{Gas: 21 * 3}, nil, []database.BlockTx{bill1, pavl1, edua1}},
+		{"invalid-skips-account", selector.Limits{}, invalid(bill2), []database.BlockTx{bill1, pavl1, edua1}},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			trans := selector.Pack(fn, pool(), tst.limits, tst.valid)
+
+			if len(trans) != len(tst.exp) {
+				t.Fatalf("Should pack %d transactions, got %d", len(tst.exp), len(trans))
+			}
+			for i, tx := range trans {
+				if tx.FromID != tst.exp[i].FromID || tx.Nonce != tst.exp[i].Nonce {
+					t.Errorf("Should pack %s at %d, got %s", tst.exp[i], i, tx)
+				}
+			}
+		})
+	}
+}
+
+func Test_PackPicks(t *testing.T) {
+
+	// The best tips aren't from the first accounts, so a strategy that only
+	// ranks the transactions when asked for fewer than it holds would pack
+	// the wrong ones.
+	pool := func() map[database.AccountID][]database.BlockTx {
+		return map[database.AccountID][]database.BlockTx{
+			"bill": {selectortest.NewTx("bill", 1, 100), selectortest.NewTx("bill", 2, 100)},
+			"edua": {selectortest.NewTx("edua", 1, 200)},
+			"pavl": {selectortest.NewTx("pavl", 1, 500), selectortest.NewTx("pavl", 2, 400)},
+		}
+	}
+
+	strategies := []string{
+		selector.StrategyTip,
+		selector.StrategyTipAdvanced,
+		selector.StrategyFeeDensity,
+		selector.StrategyFair,
+	}
+
+	for _, strategy := range strategies {
+		fn, err := selector.Retrieve(strategy)
+		if err != nil {
+			t.Fatalf("Should be able to retrieve the strategy %q: %s", strategy, err)
+		}
+
+		for howMany := 1; howMany <= 3; howMany++ {
+			exp := fn(pool(), howMany)
+			trans := selector.Pack(fn, pool(), selector.Limits{Trans: uint64(howMany)}, nil)
+
+			if len(trans) != len(exp) {
+				t.Fatalf("%s[%d]: Should pack %d transactions, got %d", strategy, howMany, len(exp), len(trans))
+			}
+			for i, tx := range trans {
+				if tx.FromID != exp[i].FromID || tx.Nonce != exp[i].Nonce {
+					t.Errorf("%s[%d]: Should pack %s at %d, got %s", strategy, howMany, exp[i], i, tx)
+				}
+			}
+		}
+	}
+}
+
+// =============================================================================
+
+func Benchmark_Tip(b *testing.B) {
+	benchmarkStrategy(b, selector.StrategyTip)
+}
+
+func Benchmark_TipAdvanced(b *testing.B) {
+	benchmarkStrategy(b, selector.StrategyTipAdvanced)
+}
+
+func Benchmark_FeeDensity(b *testing.B) {
+	benchmarkStrategy(b, selector.StrategyFeeDensity)
+}
+
+func Benchmark_Fair(b *testing.B) {
+	benchmarkStrategy(b, selector.StrategyFair)
+}
+
+// =============================================================================
+
+// benchmarkStrategy runs the strategy against pools with a growing number
+// of accounts, selecting enough transactions for a block.
+func benchmarkStrategy(b *testing.B, strategy string) {
+	fn, err := selector.Retrieve(strategy)
+	if err != nil {
+		b.Fatalf("Should be able to retrieve the strategy: %s", err)
+	}
+
+	const transPerAccount = 10
+	const howMany = 100
+
+	for _, accounts := range []int{10, 100, 1_000} {
+		b.Run(fmt.Sprintf("accounts-%d", accounts), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				m := newPool(accounts, transPerAccount)
+				b.StartTimer()
+
+				fn(m, howMany)
+			}
+		})
+	}
+}
+
+// newPool constructs a pool of transactions with the specified number of
+// accounts and transactions per account.
+func newPool(accounts int, transPerAccount int) map[database.AccountID][]database.BlockTx {
+	m := make(map[database.AccountID][]database.BlockTx, accounts)
+	for a := 0; a < accounts; a++ {
+		from := database.AccountID(fmt.Sprintf("0x%040x", a))
+		for n := transPerAccount; n > 0; n-- {
+			tip := uint64((a*31 + n*17) % 250)
+			m[from] = append(m[from], selectortest.NewTx(from, uint64(n), tip))
+		}
+	}
+
+	return m
+}
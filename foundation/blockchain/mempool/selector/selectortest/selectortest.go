@@ -0,0 +1,172 @@
+// Package selectortest provides a conformance test suite that every mempool
+// select strategy must pass, including custom strategies registered by an
+// application embedding the state package.
+package selectortest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
+)
+
+// Run executes the conformance tests against the specified select strategy.
+// It checks the strategy respects nonce ordering, returns every transaction
+// when howMany is 0, never returns more than howMany transactions, always
+// produces the same selection for the same transactions and doesn't modify
+// the transactions it's given.
+func Run(t *testing.T, fn selector.Func) {
+	t.Helper()
+
+	t.Run("nonce-ordering", func(t *testing.T) { nonceOrdering(t, fn) })
+	t.Run("all-transactions", func(t *testing.T) { allTransactions(t, fn) })
+	t.Run("how-many", func(t *testing.T) { howMany(t, fn) })
+	t.Run("deterministic", func(t *testing.T) { deterministic(t, fn) })
+	t.Run("unmodified", func(t *testing.T) { unmodified(t, fn) })
+}
+
+// =============================================================================
+
+// nonceOrdering checks that the transactions selected for each account are
+// the ones with the lowest nonces and are returned in nonce order.
+func nonceOrdering(t *testing.T, fn selector.Func) {
+	for _, n := range []int{1, 3, 7, 0} {
+		trans := fn(NewPool(), n)
+
+		next := make(map[database.AccountID]uint64)
+		for _, tx := range trans {
+			exp := next[tx.FromID] + 1
+			if tx.Nonce != exp {
+				t.Fatalf("howMany[%d]: Should select account %s nonce %d next, got %d", n, tx.FromID, exp, tx.Nonce)
+			}
+			next[tx.FromID] = tx.Nonce
+		}
+	}
+}
+
+// allTransactions checks that receiving 0 for howMany returns every
+// transaction exactly once.
+func allTransactions(t *testing.T, fn selector.Func) {
+	exp := make(map[string]bool)
+	for _, trans := range NewPool() {
+		for _, tx := range trans {
+			exp[key(tx)] = true
+		}
+	}
+
+	trans := fn(NewPool(), 0)
+	if len(trans) != len(exp) {
+		t.Fatalf("Should return all %d transactions, got %d", len(exp), len(trans))
+	}
+
+	for _, tx := range trans {
+		if !exp[key(tx)] {
+			t.Fatalf("Should return each transaction once, got %s again", tx)
+		}
+		delete(exp, key(tx))
+	}
+}
+
+// howMany checks that no more than howMany transactions are returned.
+func howMany(t *testing.T, fn selector.Func) {
+	for _, n := range []int{1, 2, 5, 10} {
+		if trans := fn(NewPool(), n); len(trans) > n {
+			t.Fatalf("Should return no more than %d transactions, got %d", n, len(trans))
+		}
+	}
+}
+
+// deterministic checks that the same transactions always produce the same
+// selection no matter the order the accounts are iterated in.
+func deterministic(t *testing.T, fn selector.Func) {
+	for _, n := range []int{3, 7, 0} {
+		exp := fn(NewPool(), n)
+
+		for i := 0; i < 20; i++ {
+			got := fn(NewPool(), n)
+			if len(got) != len(exp) {
+				t.Fatalf("howMany[%d]: Should get the same number of transactions, got %d, exp %d", n, len(got), len(exp))
+			}
+
+			for j := range got {
+				if key(got[j]) != key(exp[j]) {
+					t.Fatalf("howMany[%d]: Should get the same selection, position %d got %s, exp %s", n, j, got[j], exp[j])
+				}
+			}
+		}
+	}
+}
+
+// unmodified checks that the transactions passed to the strategy are left
+// in the order they were given.
+func unmodified(t *testing.T, fn selector.Func) {
+	pool := NewPool()
+
+	exp := make(map[database.AccountID][]string, len(pool))
+	for from, trans := range pool {
+		for _, tx := range trans {
+			exp[from] = append(exp[from], key(tx))
+		}
+	}
+
+	for _, n := range []int{3, 0} {
+		fn(pool, n)
+
+		for from, trans := range pool {
+			if len(trans) != len(exp[from]) {
+				t.Fatalf("howMany[%d]: Should not modify the transactions for %s, got %d, exp %d", n, from, len(trans), len(exp[from]))
+			}
+			for i, tx := range trans {
+				if key(tx) != exp[from][i] {
+					t.Fatalf("howMany[%d]: Should not modify the transactions for %s, position %d got %s, exp %s", n, from, i, key(tx), exp[from][i])
+				}
+			}
+		}
+	}
+}
+
+// =============================================================================
+
+// NewPool constructs the pool of transactions used by the conformance tests.
+// The transactions for each account are out of nonce order and some accounts
+// share the same tips to exercise how ties are broken.
+func NewPool() map[database.AccountID][]database.BlockTx {
+	tips := map[database.AccountID][]uint64{
+		"0x0000000000000000000000000000000000000001": {150, 250, 10},
+		"0x0000000000000000000000000000000000000002": {75, 200},
+		"0x0000000000000000000000000000000000000003": {100, 75, 75, 300},
+		"0x0000000000000000000000000000000000000004": {100},
+		"0x0000000000000000000000000000000000000005": {100, 100},
+	}
+
+	m := make(map[database.AccountID][]database.BlockTx, len(tips))
+	for from, list := range tips {
+		for i := len(list) - 1; i >= 0; i-- {
+			m[from] = append(m[from], NewTx(from, uint64(i+1), list[i]))
+		}
+	}
+
+	return m
+}
+
+// NewTx constructs a block transaction for the account with the specified
+// nonce and tip.
+func NewTx(from database.AccountID, nonce uint64, tip uint64) database.BlockTx {
+	tx := database.Tx{
+		FromID: from,
+		Nonce:  nonce,
+		Tip:    tip,
+	}
+
+	return database.BlockTx{
+		SignedTx: database.SignedTx{Tx: tx},
+		GasPrice: 15,
+		GasUnits: 21,
+	}
+}
+
+// key returns a unique key for the transaction.
+func key(tx database.BlockTx) string {
+	return fmt.Sprintf("%s:%d", tx.FromID, tx.Nonce)
+}
@@ -8,9 +8,10 @@ import (
 
 // CORE NOTE: On Ethereum a transaction will stay in the mempool and not be selected
 // unless the transaction holds the next expected nonce. Transactions can get stuck
-// in the mempool because of this. This is very complicated for us to implement for
-// now. So we will check the nonce for each transaction when the block is mined.
-// If the nonce is not expected, it will fail but the user continues to pay fees.
+// in the mempool because of this. The mempool only hands the pending transactions
+// to a selector, which for each account are a contiguous sequence starting with
+// the next expected nonce. As long as nonce ordering is respected, every
+// transaction that is selected can be executed.
 
 // tipSelect returns transactions with the best tip while respecting the nonce
 // for each account/transaction.
@@ -25,11 +26,22 @@ var tipSelect = func(m map[database.AccountID][]database.BlockTx, howMany int) [
 			  {Nonce: 1, To: "0x6Fe6CF3c8fF57c58d24BfC869668F48BCbDb3BD9", Tip: 100},
 	*/
 
-	// Sort the transactions per account by nonce.
+	// Sort the transactions per account by nonce and sort the accounts so
+	// ties are always broken the same way.
+	m = copyPool(m)
+	accounts := make([]database.AccountID, 0, len(m))
+	var total int
 	for key := range m {
 		if len(m[key]) > 1 {
 			sort.Sort(byNonce(m[key]))
 		}
+		accounts = append(accounts, key)
+		total += len(m[key])
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i] < accounts[j] })
+
+	if howMany <= 0 {
+		howMany = total
 	}
 
 	/*
@@ -47,7 +59,7 @@ var tipSelect = func(m map[database.AccountID][]database.BlockTx, howMany int) [
 	var rows [][]database.BlockTx
 	for {
 		var row []database.BlockTx
-		for key := range m {
+		for _, key := range accounts {
 			if len(m[key]) > 0 {
 				row = append(row, m[key][0])
 				m[key] = m[key][1:]
@@ -76,7 +88,7 @@ var tipSelect = func(m map[database.AccountID][]database.BlockTx, howMany int) [
 	for _, row := range rows {
 		need := howMany - len(final)
 		if len(row) > need {
-			sort.Sort(byTip(row))
+			sort.Stable(byTip(row))
 			final = append(final, row[:need]...)
 			break
 		}
@@ -0,0 +1,62 @@
+package mempool
+
+import (
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+)
+
+// CORE NOTE: Sending the entire mempool to a peer on every sync wastes
+// bandwidth when most of the transactions are already known. Instead a node
+// first asks for a reference to each transaction, which is the hash of the
+// transaction along with the account and nonce. The node can then work out
+// which transactions it's missing and only fetch those. The hash of every
+// transaction is calculated once when it's added to the pool, so answering
+// these requests doesn't require hashing the pool.
+
+// TxRef represents a reference to a transaction in the mempool.
+type TxRef struct {
+	Hash   string             `json:"hash"`
+	FromID database.AccountID `json:"from"`
+	Nonce  uint64             `json:"nonce"`
+}
+
+// Refs returns a reference to every transaction in the pool.
+func (mp *Mempool) Refs() []TxRef {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+
+	var refs []TxRef
+	for _, m := range []map[database.AccountID][]entry{mp.pending, mp.queued} {
+		for _, accountID := range sortedAccounts(m) {
+			for _, e := range m[accountID] {
+				refs = append(refs, TxRef{Hash: e.hash, FromID: e.FromID, Nonce: e.Nonce})
+			}
+		}
+	}
+
+	return refs
+}
+
+// Lookup returns the transactions in the pool that match the specified
+// hashes. Hashes for transactions that are not in the pool are ignored.
+func (mp *Mempool) Lookup(hashes []string) []database.BlockTx {
+	want := make(map[string]struct{}, len(hashes))
+	for _, hash := range hashes {
+		want[hash] = struct{}{}
+	}
+
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+
+	var trans []database.BlockTx
+	for _, m := range []map[database.AccountID][]entry{mp.pending, mp.queued} {
+		for _, accountID := range sortedAccounts(m) {
+			for _, e := range m[accountID] {
+				if _, exists := want[e.hash]; exists {
+					trans = append(trans, e.BlockTx)
+				}
+			}
+		}
+	}
+
+	return trans
+}
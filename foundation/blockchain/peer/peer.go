@@ -35,14 +35,16 @@ type PeerStatus struct {
 
 // PeerSet represents the data representation to maintain a set of known peers.
 type PeerSet struct {
-	mu  sync.RWMutex
-	set map[Peer]struct{}
+	mu    sync.RWMutex
+	set   map[Peer]struct{}
+	flags map[Peer]int
 }
 
 // NewPeerSet constructs a new info set to manage node peer information.
 func NewPeerSet() *PeerSet {
 	return &PeerSet{
-		set: make(map[Peer]struct{}),
+		set:   make(map[Peer]struct{}),
+		flags: make(map[Peer]int),
 	}
 }
 
@@ -82,3 +84,14 @@ func (ps *PeerSet) Copy(host string) []Peer {
 
 	return peers
 }
+
+// Flag records that the peer misbehaved and returns the number of times the
+// peer has been flagged. Flags are kept when a peer is removed so a peer that
+// comes back starts where it left off.
+func (ps *PeerSet) Flag(peer Peer) int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.flags[peer]++
+	return ps.flags[peer]
+}
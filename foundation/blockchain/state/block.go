@@ -2,11 +2,11 @@ package state
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
 
 	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
 )
 
 // ErrNoTransactions is returned when a block is requested to be created
@@ -18,34 +18,70 @@ var ErrNoTransactions = errors.New("no transactions in mempool")
 // MineNewBlock attempts to create a new block with a proper hash that can become
 // the next block in the chain.
 func (s *State) MineNewBlock(ctx context.Context) (database.Block, error) {
-	defer s.evHandler("viewer: MineNewBlock: MINING: completed")
+	defer func() {
+		// Mining has stopped, so don't keep reporting the last hash rate.
+		s.hashRate.Store(0)
+
+		s.evHandler("state: MineNewBlock: MINING: completed")
+		s.publish(TopicMiningProgress, MiningEvent{Status: StatusCompleted})
+	}()
 
 	s.evHandler("state: MineNewBlock: MINING: check mempool count")
 
-	// Are there enough transactions in the pool.
-	if s.mempool.Count() == 0 {
+	// Are there enough transactions in the pool that can be executed.
+	if s.mempool.PendingCount() == 0 {
+		return database.Block{}, ErrNoTransactions
+	}
+
+	// CORE NOTE: PoW blocks follow the retarget rule so the difficulty moves
+	// towards the target block time. PoA blocks are always mined with a
+	// difficulty of 1 since the authority, not the work, decides who mines.
+	// Every node in a PoA network must be started with PoA consensus so they
+	// all expect the same difficulty when validating blocks.
+
+	// Calculate the difficulty, reward and base fee for the next block.
+	latestBlock := s.db.LatestBlock()
+	rules, err := s.db.NextBlockRules(latestBlock)
+	if err != nil {
+		return database.Block{}, err
+	}
+
+	// Pack the block in the order of the selected strategy. The base fee may
+	// have gone up since these transactions were accepted. Leave behind any
+	// transaction that can no longer pay for its gas since peers would reject
+	// the block.
+	trans := s.mempool.PickBlock(blockLimits(rules), func(tx database.BlockTx) error {
+		if err := tx.ValidateGas(rules); err != nil {
+			s.evHandler("state: MineNewBlock: MINING: skip tx[%s]: %s", tx, err)
+			return err
+		}
+		return nil
+	})
+
+	if len(trans) == 0 {
 		return database.Block{}, ErrNoTransactions
 	}
 
-	// Pick the best transactions from the mempool.
-	trans := s.mempool.PickBest(s.genesis.TransPerBlock)
+	s.publish(TopicMiningProgress, MiningEvent{Status: StatusStarted, NumTrans: len(trans)})
 
-	// If PoA is being used, drop the difficulty down to 1 to speed up
-	// the mining operation.
-	difficulty := s.genesis.Difficulty
-	if s.Consensus() == ConsensusPOA {
-		difficulty = 1
+	// Report the hash rate with the metrics and as mining progress.
+	hashRate := func(rate uint64) {
+		s.hashRate.Store(rate)
+		s.publish(TopicMiningProgress, MiningEvent{Status: StatusRunning, HashRate: rate})
 	}
 
 	// Attempt to create a new block by solving the POW puzzle. This can be cancelled.
 	block, err := database.POW(ctx, database.POWArgs{
 		BeneficiaryID: s.beneficiaryID,
-		Difficulty:    difficulty,
-		MiningReward:  s.genesis.MiningReward,
-		PrevBlock:     s.db.LatestBlock(),
+		Difficulty:    rules.Difficulty,
+		MiningReward:  rules.MiningReward,
+		BaseFee:       rules.BaseFee,
+		PrevBlock:     latestBlock,
 		StateRoot:     s.db.HashState(),
 		Trans:         trans,
+		Workers:       s.miningWorkers,
 		EvHandler:     s.evHandler,
+		HashRate:      hashRate,
 	})
 	if err != nil {
 		return database.Block{}, err
@@ -62,6 +98,7 @@ func (s *State) MineNewBlock(ctx context.Context) (database.Block, error) {
 	if err := s.validateUpdateDatabase(block); err != nil {
 		return database.Block{}, err
 	}
+	s.publish(TopicBlockMined, BlockEvent{Block: database.NewBlockData(block)})
 
 	// TEMP REMOVE MEMPOOL
 	// txs := block.MerkleTree.Values()
@@ -83,6 +120,7 @@ func (s *State) ProcessProposedBlock(block database.Block) error {
 	if err := s.validateUpdateDatabase(block); err != nil {
 		return err
 	}
+	s.publish(TopicBlockAccepted, BlockEvent{Block: database.NewBlockData(block)})
 
 	// If the runMiningOperation function is being executed it needs to stop
 	// immediately.
@@ -107,7 +145,14 @@ func (s *State) validateUpdateDatabase(block database.Block) error {
 	// me to this function for the same block number, I could replace the peer
 	// block with my own and attempt to have other peers accept my block instead.
 
-	if err := block.ValidateBlock(s.db.LatestBlock(), s.db.HashState(), s.evHandler); err != nil {
+	latestBlock := s.db.LatestBlock()
+
+	rules, err := s.db.NextBlockRules(latestBlock)
+	if err != nil {
+		return err
+	}
+
+	if err := block.ValidateBlock(latestBlock, s.db.HashState(), rules, s.evHandler); err != nil {
 		return err
 	}
 
@@ -122,11 +167,13 @@ func (s *State) validateUpdateDatabase(block database.Block) error {
 	s.evHandler("state: validateUpdateDatabase: update accounts and remove from mempool")
 
 	// Process the transactions and update the accounts.
+	accounts := make(map[database.AccountID]struct{})
 	for _, tx := range block.MerkleTree.Values() {
 		s.evHandler("state: validateUpdateDatabase: tx[%s] update and remove", tx)
+		accounts[tx.FromID] = struct{}{}
 
 		// Remove this transaction from the mempool.
-		s.mempool.Delete(tx)
+		s.mempool.Delete(tx, mempool.ReasonMined)
 
 		// Apply the balance changes based on this transaction.
 		if err := s.db.ApplyTransaction(block, tx); err != nil {
@@ -135,24 +182,34 @@ func (s *State) validateUpdateDatabase(block database.Block) error {
 		}
 	}
 
+	// The nonces for these accounts have moved forward, so transactions that
+	// were waiting on them can now be executed.
+	for accountID := range accounts {
+		s.mempool.Refresh(accountID)
+	}
+
+	// Transactions that can't be mined into the next block are evicted.
+	s.sweepMempool()
+
+	// Drop the mined and evicted transactions from the journal.
+	s.rotateJournal()
+
 	s.evHandler("state: validateUpdateDatabase: apply mining reward")
 
 	// Apply the mining reward for this block.
 	s.db.ApplyMiningReward(block)
 
-	// Send an event about this new block.
-	s.blockEvent(block)
+	// Add the block to the chain statistics.
+	s.stats.Add(block, s.db.BurnedByBlock(block.Header.Number))
 
 	return nil
 }
 
-// blockEvent provides a specific event about a new block in the chain for
-// application specific support.
-func (s *State) blockEvent(block database.Block) {
-	data, err := json.Marshal(database.NewBlockData(block))
-	if err != nil {
-		data = []byte(fmt.Sprintf("{error: %q}", err.Error()))
+// blockLimits returns the capacity of a block built with the rules.
+func blockLimits(rules database.BlockRules) selector.Limits {
+	return selector.Limits{
+		Trans: rules.MaxTrans,
+		Bytes: rules.MaxBytes,
+		Gas:   rules.MaxGas,
 	}
-
-	s.evHandler("viewer: block: %s", string(data))
 }
@@ -0,0 +1,59 @@
+package state
+
+import (
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+)
+
+// Set of topics the state publishes events for.
+const (
+	TopicBlockMined     = "block.mined"
+	TopicBlockAccepted  = "block.accepted"
+	TopicTxAdded        = "tx.added"
+	TopicReorg          = "reorg"
+	TopicPeerAdded      = "peer.added"
+	TopicMiningProgress = "mining.progress"
+)
+
+// Set of status values for the mining and reorg events.
+const (
+	StatusStarted   = "started"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+)
+
+// PublishHandler defines a function that is called with a structured payload
+// when something happens that an application may want to react to, such as a
+// new block being added to the chain. The block is the number of the latest
+// block in the chain when it happened.
+type PublishHandler func(topic string, block uint64, payload any)
+
+// BlockEvent is the payload for the block.mined and block.accepted topics.
+type BlockEvent struct {
+	Block database.BlockData `json:"block"`
+}
+
+// TxEvent is the payload for the tx.added topic. Replaced is true when the
+// transaction replaced one with the same nonce.
+type TxEvent struct {
+	Tx       database.BlockTx `json:"tx"`
+	Replaced bool             `json:"replaced"`
+}
+
+// ReorgEvent is the payload for the reorg topic.
+type ReorgEvent struct {
+	Status            string `json:"status"`
+	LatestBlockNumber uint64 `json:"latest_block_number"`
+}
+
+// PeerEvent is the payload for the peer.added topic.
+type PeerEvent struct {
+	Host string `json:"host"`
+}
+
+// MiningEvent is the payload for the mining.progress topic. The hash rate
+// is reported while the puzzle is being solved.
+type MiningEvent struct {
+	Status   string `json:"status"`
+	NumTrans int    `json:"num_trans,omitempty"`
+	HashRate uint64 `json:"hash_rate,omitempty"`
+}
@@ -0,0 +1,225 @@
+package state
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
+)
+
+// CORE NOTE: A tip only needs to be high enough to get the transaction into a
+// block soon enough. The recent blocks show what tips have been getting mined,
+// but they can't see a sudden rush of transactions. So the mempool is also
+// checked. The next blocks are packed from the mempool the same way a miner
+// will pack them, and the weakest transaction in a target block shows what
+// has to be beaten to get in. When a fee density strategy is active, what
+// counts is the fee per byte, so the tip needed depends on the size and gas
+// of the transaction. Unless the caller says otherwise, the estimate is for a
+// transaction the size of the typical one mined recently.
+
+// Set of values used to estimate the tips.
+const (
+	feeHistoryBlocks = 20 // Number of recent blocks to read the tips from.
+	fastBlocks       = 1  // Number of blocks a fast tip should be mined within.
+	normalBlocks     = 3  // Number of blocks a normal tip should be mined within.
+	slowBlocks       = 6  // Number of blocks a slow tip should be mined within.
+)
+
+// FeeEstimate represents the suggested tips for a transaction to be mined
+// with different levels of urgency.
+type FeeEstimate struct {
+	Slow          uint64 // Tip expected to be mined within a few blocks.
+	Normal        uint64 // Tip expected to be mined within a couple of blocks.
+	Fast          uint64 // Tip expected to be mined in the next block.
+	BaseFee       uint64 // Base fee per unit of gas for the next block.
+	Strategy      string // Select strategy the mempool is ordered with.
+	Pending       int    // Number of transactions waiting to be mined.
+	BlockCapacity int    // Number of transactions that fit in a block.
+	BlocksRead    int    // Number of recent blocks the tips were read from.
+	Size          uint64 // Size in bytes of the transaction the tips are for.
+	GasUnits      uint64 // Gas units of the transaction the tips are for.
+}
+
+// feeHistory represents what was read from the recent blocks. It's kept
+// until a new block becomes the latest block.
+type feeHistory struct {
+	mu         sync.Mutex
+	latest     string
+	tips       []uint64
+	sizes      []uint64
+	gasUnits   []uint64
+	blocksRead int
+}
+
+// EstimateFees suggests the tips for a transaction based on the tips mined in
+// recent blocks and the transactions waiting in the mempool. The size and gas
+// units describe the transaction the tips are for. When they are 0, the
+// median size and gas units of the recently mined transactions are used.
+func (s *State) EstimateFees(size uint64, gasUnits uint64) (FeeEstimate, error) {
+	rules, err := s.db.NextBlockRules(s.db.LatestBlock())
+	if err != nil {
+		return FeeEstimate{}, err
+	}
+
+	hist, err := s.recentFees()
+	if err != nil {
+		return FeeEstimate{}, err
+	}
+
+	if size == 0 {
+		size = percentile(hist.sizes, 50)
+	}
+	if gasUnits == 0 {
+		gasUnits = percentile(hist.gasUnits, 50)
+	}
+
+	est := FeeEstimate{
+		Slow:          percentile(hist.tips, 25),
+		Normal:        percentile(hist.tips, 50),
+		Fast:          percentile(hist.tips, 90),
+		BaseFee:       rules.BaseFee,
+		Strategy:      s.strategy,
+		Pending:       s.mempool.PendingCount(),
+		BlockCapacity: int(s.genesis.TransPerBlock),
+		BlocksRead:    hist.blocksRead,
+		Size:          size,
+		GasUnits:      gasUnits,
+	}
+
+	// Pack one block past the slowest target. When a target block is
+	// followed by another one, the mempool has more than fits and the tip
+	// must beat the weakest transaction that makes it into the target block.
+	valid := func(tx database.BlockTx) error {
+		return tx.ValidateGas(rules)
+	}
+	blocks := s.mempool.PickBlocks(slowBlocks+1, blockLimits(rules), valid)
+
+	beat := func(tip uint64, target int) uint64 {
+		if len(blocks) <= target {
+			return tip
+		}
+		if need := s.beatTip(blocks[target-1], size, gasUnits); need > tip {
+			return need
+		}
+		return tip
+	}
+
+	est.Fast = beat(est.Fast, fastBlocks)
+	est.Normal = beat(est.Normal, normalBlocks)
+	est.Slow = beat(est.Slow, slowBlocks)
+
+	// A faster estimate should never suggest a lower tip.
+	if est.Normal < est.Slow {
+		est.Normal = est.Slow
+	}
+	if est.Fast < est.Normal {
+		est.Fast = est.Normal
+	}
+
+	return est, nil
+}
+
+// recentFees returns the tips, sizes and gas units of the transactions in the
+// recent blocks in ascending order. They are only read from disk again once
+// the latest block changes.
+func (s *State) recentFees() (*feeHistory, error) {
+	latestBlock := s.db.LatestBlock()
+	hash := latestBlock.Hash()
+
+	hist := &s.feeHistory
+	hist.mu.Lock()
+	defer hist.mu.Unlock()
+
+	if hist.latest == hash {
+		return hist, nil
+	}
+
+	latest := latestBlock.Header.Number
+
+	from := uint64(1)
+	if latest > feeHistoryBlocks {
+		from = latest - feeHistoryBlocks + 1
+	}
+
+	var tips, sizes, gasUnits []uint64
+	var blocksRead int
+	for num := from; num <= latest; num++ {
+		block, err := s.db.GetBlock(num)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tx := range block.MerkleTree.Values() {
+			tips = append(tips, tx.Tip)
+			sizes = append(sizes, tx.Size())
+			gasUnits = append(gasUnits, tx.GasUnits)
+		}
+		blocksRead++
+	}
+
+	for _, list := range [][]uint64{tips, sizes, gasUnits} {
+		sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	}
+
+	hist.latest = hash
+	hist.tips = tips
+	hist.sizes = sizes
+	hist.gasUnits = gasUnits
+	hist.blocksRead = blocksRead
+
+	return hist, nil
+}
+
+// beatTip returns the tip a transaction with the size and gas units needs to
+// be selected ahead of the weakest transaction in the block. The density
+// strategies order by the fee per byte, the others by the tip.
+func (s *State) beatTip(block []database.BlockTx, size uint64, gasUnits uint64) uint64 {
+	switch s.strategy {
+	case selector.StrategyFeeDensity, selector.StrategyFair:
+		if size == 0 {
+			size = block[len(block)-1].Size()
+		}
+		if gasUnits == 0 {
+			gasUnits = block[len(block)-1].GasUnits
+		}
+
+		density := math.Inf(1)
+		for _, tx := range block {
+			if d := tx.FeeDensity(); d < density {
+				density = d
+			}
+		}
+
+		// The fee needs to be more than the weakest density for this size,
+		// and the gas already pays for part of it.
+		need := uint64(math.Floor(density*float64(size))) + 1
+		gasFee := s.genesis.GasPrice * gasUnits
+		if need <= gasFee {
+			return 0
+		}
+		return need - gasFee
+
+	default:
+		tip := block[0].Tip
+		for _, tx := range block {
+			if tx.Tip < tip {
+				tip = tx.Tip
+			}
+		}
+		return tip + 1
+	}
+}
+
+// =============================================================================
+
+// percentile returns the value at the percentile of the sorted values. No
+// values returns 0.
+func percentile(sorted []uint64, pct int) uint64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	return sorted[(len(sorted)-1)*pct/100]
+}
@@ -0,0 +1,158 @@
+package state_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
+)
+
+func Test_EstimateFeesDensity(t *testing.T) {
+	keys := newKeys(t, 8)
+	st := newState(t, newConfig(t, keys))
+
+	// One transaction fits in a block, so the best paying transaction is the
+	// one to beat for the next block.
+	var best database.BlockTx
+	for i, key := range keys {
+		tx := submit(t, st, signTx(t, key, 1, uint64(10*(i+1))))
+		if tx.FeeDensity() > best.FeeDensity() {
+			best = tx
+		}
+	}
+
+	const gasPrice = 15
+	const gasUnits = 30
+
+	for _, size := range []uint64{best.Size(), 2 * best.Size()} {
+		est, err := st.EstimateFees(size, gasUnits)
+		if err != nil {
+			t.Fatalf("Should be able to estimate the fees: %s", err)
+		}
+
+		if est.Size != size || est.GasUnits != gasUnits {
+			t.Fatalf("Should estimate for size[%d] gas[%d], got size[%d] gas[%d]", size, gasUnits, est.Size, est.GasUnits)
+		}
+
+		density := func(tip uint64) float64 {
+			return float64(gasPrice*gasUnits+tip) / float64(size)
+		}
+		if density(est.Fast) <= best.FeeDensity() {
+			t.Fatalf("size[%d]: Should beat the density %f with a tip of %d", size, best.FeeDensity(), est.Fast)
+		}
+		if density(est.Fast-1) > best.FeeDensity() {
+			t.Fatalf("size[%d]: Should suggest the lowest tip to beat the density %f, got %d", size, best.FeeDensity(), est.Fast)
+		}
+	}
+}
+
+func Test_EstimateFeesDensityDefaults(t *testing.T) {
+	for _, strategy := range []string{selector.StrategyFeeDensity, selector.StrategyFair} {
+		t.Run(strategy, func(t *testing.T) {
+			keys := newKeys(t, 8)
+
+			cfg := newConfig(t, keys)
+			cfg.SelectStrategy = strategy
+			st := newState(t, cfg)
+
+			var best database.BlockTx
+			for i, key := range keys {
+				tx := submit(t, st, signTx(t, key, 1, uint64(10*(i+1))))
+				if tx.FeeDensity() > best.FeeDensity() {
+					best = tx
+				}
+			}
+
+			// Without a size, gas units or any history, the tip is priced for
+			// the size and gas units of the transaction to beat.
+			est, err := st.EstimateFees(0, 0)
+			if err != nil {
+				t.Fatalf("Should be able to estimate the fees: %s", err)
+			}
+
+			density := func(tip uint64) float64 {
+				return float64(15*best.GasUnits+tip) / float64(best.Size())
+			}
+			if density(est.Fast) <= best.FeeDensity() || density(est.Fast-1) > best.FeeDensity() {
+				t.Fatalf("Should suggest the lowest tip to beat the density %f for its size, got %d", best.FeeDensity(), est.Fast)
+			}
+
+			// A transaction whose gas already pays more than the weakest
+			// density in every block doesn't need a tip.
+			est, err = st.EstimateFees(best.Size(), 1_000)
+			if err != nil {
+				t.Fatalf("Should be able to estimate the fees: %s", err)
+			}
+			if est.Fast != 0 || est.Normal != 0 || est.Slow != 0 {
+				t.Fatalf("Should not need a tip when the gas covers the fee, got fast[%d] normal[%d] slow[%d]", est.Fast, est.Normal, est.Slow)
+			}
+		})
+	}
+}
+
+func Test_EstimateFeesTip(t *testing.T) {
+	keys := newKeys(t, 8)
+
+	cfg := newConfig(t, keys)
+	cfg.SelectStrategy = selector.StrategyTip
+	st := newState(t, cfg)
+
+	for i, key := range keys {
+		submit(t, st, signTx(t, key, 1, uint64(10*(i+1))))
+	}
+
+	est, err := st.EstimateFees(0, 0)
+	if err != nil {
+		t.Fatalf("Should be able to estimate the fees: %s", err)
+	}
+
+	// Seven blocks are packed by the best tip and the tips of the transactions
+	// in the first, third and sixth are the ones to beat.
+	exp := []uint64{81, 61, 31}
+	got := []uint64{est.Fast, est.Normal, est.Slow}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Fatalf("Should get the tips %v, got %v", exp, got)
+		}
+	}
+}
+
+func Test_EstimateFeesHistory(t *testing.T) {
+	keys := newKeys(t, 3)
+	st := newState(t, newConfig(t, keys))
+
+	// A mempool that doesn't fill the next block doesn't need to be beaten.
+	submit(t, st, signTx(t, keys[0], 1, 40))
+
+	est, err := st.EstimateFees(0, 0)
+	if err != nil {
+		t.Fatalf("Should be able to estimate the fees: %s", err)
+	}
+	if est.Fast != 0 || est.BlocksRead != 0 {
+		t.Fatalf("Should not suggest a tip without history, got fast[%d] blocks[%d]", est.Fast, est.BlocksRead)
+	}
+
+	// Each new block is read into the history.
+	for i, tip := range []uint64{40, 20, 60} {
+		if i > 0 {
+			submit(t, st, signTx(t, keys[i], 1, tip))
+		}
+
+		if _, err := st.MineNewBlock(context.Background()); err != nil {
+			t.Fatalf("Should be able to mine a block: %s", err)
+		}
+
+		est, err := st.EstimateFees(0, 0)
+		if err != nil {
+			t.Fatalf("Should be able to estimate the fees: %s", err)
+		}
+		if est.BlocksRead != i+1 || est.Size == 0 || est.GasUnits == 0 {
+			t.Fatalf("Should read %d blocks into the history, got blocks[%d] size[%d] gas[%d]", i+1, est.BlocksRead, est.Size, est.GasUnits)
+		}
+	}
+
+	if est, _ := st.EstimateFees(0, 0); est.Fast != 40 || est.Slow != 20 {
+		t.Fatalf("Should suggest the tips from the history, got fast[%d] slow[%d]", est.Fast, est.Slow)
+	}
+}
@@ -0,0 +1,78 @@
+package state
+
+import (
+	"sort"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+)
+
+// replayJournal loads the transactions from the mempool journal back into
+// the mempool. The state of the blockchain may have changed since these
+// transactions were accepted, so every transaction is validated again. The
+// transactions that were mined or can no longer be executed are dropped.
+func (s *State) replayJournal() error {
+	trans, err := s.journal.Load()
+	if err != nil {
+		return err
+	}
+
+	s.evHandler("state: replayJournal: started: trans[%d]", len(trans))
+	defer s.evHandler("state: replayJournal: completed: trans[%d]", s.mempool.Count())
+
+	// Replay the transactions for each account in nonce order.
+	sort.SliceStable(trans, func(i, j int) bool {
+		if trans[i].FromID != trans[j].FromID {
+			return trans[i].FromID < trans[j].FromID
+		}
+		return trans[i].Nonce < trans[j].Nonce
+	})
+
+	for _, tx := range trans {
+		if err := s.validateTx(tx); err != nil {
+			s.evHandler("state: replayJournal: dropped: tx[%s]: %s", tx, err)
+			continue
+		}
+
+		if err := s.mempool.Upsert(tx); err != nil {
+			s.evHandler("state: replayJournal: dropped: tx[%s]: %s", tx, err)
+			continue
+		}
+	}
+
+	// Rewrite the journal with just the transactions that were restored.
+	s.rotateJournal()
+
+	return nil
+}
+
+// journalTx appends the transaction to the mempool journal. A failure to
+// write to the journal doesn't stop the transaction from being accepted.
+func (s *State) journalTx(tx database.BlockTx) {
+	if s.journal == nil {
+		return
+	}
+
+	if err := s.journal.Insert(tx); err != nil {
+		s.evHandler("state: journalTx: ERROR: %s", err)
+	}
+
+	// Transactions evicted to make room for this one would come back when
+	// the journal is replayed, so they are dropped now.
+	if s.evicted.Load() {
+		s.rotateJournal()
+	}
+}
+
+// rotateJournal rewrites the mempool journal with the transactions that are
+// currently in the mempool.
+func (s *State) rotateJournal() {
+	if s.journal == nil {
+		return
+	}
+
+	s.evicted.Store(false)
+
+	if err := s.journal.Rotate(s.mempool.Copy()); err != nil {
+		s.evHandler("state: rotateJournal: ERROR: %s", err)
+	}
+}
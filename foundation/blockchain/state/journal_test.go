@@ -0,0 +1,65 @@
+package state_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
+)
+
+func Test_JournalRestart(t *testing.T) {
+	keys := newKeys(t, 3)
+	path := filepath.Join(t.TempDir(), "mempool.journal")
+
+	cfg := newConfig(t, keys)
+	cfg.MempoolJournal = path
+	cfg.MempoolCapacity = mempool.Capacity{MaxTrans: 2}
+
+	st := newState(t, cfg)
+
+	// The first transaction pays the most and is mined into the block.
+	mined := submit(t, st, signTx(t, keys[0], 1, 100))
+	cheap := submit(t, st, signTx(t, keys[1], 1, 1))
+
+	if _, err := st.MineNewBlock(context.Background()); err != nil {
+		t.Fatalf("Should be able to mine a block: %s", err)
+	}
+
+	// The pool is full, so the cheapest transaction is evicted to make room.
+	kept := submit(t, st, signTx(t, keys[0], 2, 50))
+	added := submit(t, st, signTx(t, keys[2], 1, 100))
+
+	exp := map[string]bool{kept.String(): true, added.String(): true}
+	if got := mempoolKeys(st); len(got) != len(exp) || !got[kept.String()] || !got[added.String()] {
+		t.Fatalf("Should have the kept and added transactions in the mempool, got %v", got)
+	}
+
+	trans, err := mempool.NewJournal(path).Load()
+	if err != nil {
+		t.Fatalf("Should be able to load the journal: %s", err)
+	}
+	for _, tx := range trans {
+		if !exp[tx.String()] {
+			t.Errorf("Should not have %s in the journal", tx)
+		}
+	}
+
+	// Restart the node against the same chain and journal.
+	restarted := newState(t, cfg)
+
+	got := mempoolKeys(restarted)
+	if len(got) != len(exp) {
+		t.Fatalf("Should restore %d transactions, got %v", len(exp), got)
+	}
+	for key := range exp {
+		if !got[key] {
+			t.Errorf("Should restore %s", key)
+		}
+	}
+	for _, tx := range []string{mined.String(), cheap.String()} {
+		if got[tx] {
+			t.Errorf("Should not restore %s", tx)
+		}
+	}
+}
@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 
 	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/peer"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
 )
 
 const baseURL = "http://%s/v1/node"
@@ -95,21 +98,75 @@ func (s *State) NetRequestPeerStatus(pr peer.Peer) (peer.PeerStatus, error) {
 	return ps, nil
 }
 
-// NetRequestPeerMempool asks the peer for the transactions in their mempool.
-func (s *State) NetRequestPeerMempool(pr peer.Peer) ([]database.BlockTx, error) {
-	s.evHandler("state: NetRequestPeerMempool: started: %s", pr)
-	defer s.evHandler("state: NetRequestPeerMempool: completed: %s", pr)
+// NetSyncPeerMempool asks the peer for a reference to each transaction in
+// their mempool, fetches just the transactions this node is missing and runs
+// each one through the same validation as a transaction submitted by a node.
+// A peer that serves invalid transactions is flagged.
+func (s *State) NetSyncPeerMempool(pr peer.Peer) error {
+	s.evHandler("state: NetSyncPeerMempool: started: %s", pr)
+	defer s.evHandler("state: NetSyncPeerMempool: completed: %s", pr)
 
-	url := fmt.Sprintf("%s/tx/list", fmt.Sprintf(baseURL, pr.Host))
+	url := fmt.Sprintf("%s/tx/hashes", fmt.Sprintf(baseURL, pr.Host))
 
-	var mempool []database.BlockTx
-	if err := send(http.MethodGet, url, nil, &mempool); err != nil {
-		return nil, err
+	var refs []mempool.TxRef
+	if err := send(http.MethodGet, url, nil, &refs); err != nil {
+		return err
+	}
+
+	missing := s.missingTxs(refs)
+
+	s.evHandler("state: NetSyncPeerMempool: refs[%d] missing[%d]", len(refs), len(missing))
+
+	if len(missing) == 0 {
+		return nil
 	}
 
-	s.evHandler("state: NetRequestPeerMempool: len[%d]", len(mempool))
+	url = fmt.Sprintf("%s/tx/fetch", fmt.Sprintf(baseURL, pr.Host))
+
+	var trans []database.BlockTx
+	if err := send(http.MethodPost, url, missing, &trans); err != nil {
+		return err
+	}
+
+	requested := make(map[string]struct{}, len(missing))
+	for _, hash := range missing {
+		requested[hash] = struct{}{}
+	}
 
-	return mempool, nil
+	// Add the transactions in nonce order for each account so they don't
+	// all end up on the queued list waiting for each other.
+	sort.Slice(trans, func(i, j int) bool {
+		if trans[i].FromID != trans[j].FromID {
+			return trans[i].FromID < trans[j].FromID
+		}
+		return trans[i].Nonce < trans[j].Nonce
+	})
+
+	var added int
+	for _, tx := range trans {
+		hash := signature.Hash(tx)
+		if _, exists := requested[hash]; !exists {
+			s.flagPeer(pr, fmt.Sprintf("tx[%s] was not requested", hash))
+			continue
+		}
+		delete(requested, hash)
+
+		err := s.UpsertNodeTransaction(tx)
+		switch {
+		case err == nil:
+			added++
+
+		case isInvalidTx(err):
+			s.flagPeer(pr, fmt.Sprintf("tx[%s]: %s", hash, err))
+
+		default:
+			s.evHandler("state: NetSyncPeerMempool: %s: drop tx[%s]: %s", pr, hash, err)
+		}
+	}
+
+	s.evHandler("state: NetSyncPeerMempool: fetched[%d] added[%d]", len(trans), added)
+
+	return nil
 }
 
 // NetRequestPeerBlocks queries the specified node asking for blocks this node does
@@ -155,6 +212,69 @@ func (s *State) NetRequestPeerBlocks(pr peer.Peer) error {
 
 // =============================================================================
 
+// maxPeerFlags represents the number of times a peer can serve invalid
+// transactions before it's removed from the known peer list.
+const maxPeerFlags = 3
+
+// missingTxs returns the hashes of the referenced transactions this node
+// doesn't have. Transactions with a nonce that has already been used on the
+// blockchain can never be mined, so they are not fetched.
+func (s *State) missingTxs(refs []mempool.TxRef) []string {
+	have := make(map[string]struct{})
+	for _, ref := range s.mempool.Refs() {
+		have[ref.Hash] = struct{}{}
+	}
+
+	nonces := make(map[database.AccountID]uint64)
+	var missing []string
+	for _, ref := range refs {
+		if _, exists := have[ref.Hash]; exists {
+			continue
+		}
+
+		nonce, exists := nonces[ref.FromID]
+		if !exists {
+			account, err := s.db.Query(ref.FromID)
+			if err == nil {
+				nonce = account.Nonce
+			}
+			nonces[ref.FromID] = nonce
+		}
+
+		if ref.Nonce <= nonce {
+			continue
+		}
+
+		have[ref.Hash] = struct{}{}
+		missing = append(missing, ref.Hash)
+	}
+
+	return missing
+}
+
+// flagPeer records that the peer served an invalid transaction and removes
+// the peer from the known peer list once it has been flagged too many times.
+func (s *State) flagPeer(pr peer.Peer, reason string) {
+	flags := s.knownPeers.Flag(pr)
+	s.evHandler("state: flagPeer: %s: WARNING: flags[%d]: %s", pr, flags, reason)
+
+	if flags >= maxPeerFlags {
+		s.evHandler("state: flagPeer: %s: WARNING: removing peer", pr)
+		s.RemoveKnownPeer(pr)
+	}
+}
+
+// isInvalidTx reports if the transaction could never have been accepted by
+// any node, as opposed to being rejected by this node's mempool policy.
+func isInvalidTx(err error) bool {
+	re := mempool.GetRejectError(err)
+	if re == nil {
+		return false
+	}
+
+	return mempool.IsInvalid(re.Reason)
+}
+
 // send is a helper function to send an HTTP request to a node.
 func send(method string, url string, dataSend any, dataRecv any) error {
 	var req *http.Request
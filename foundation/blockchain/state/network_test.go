@@ -0,0 +1,124 @@
+package state_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/peer"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+)
+
+func Test_NetSyncPeerMempool(t *testing.T) {
+	keys := newKeys(t, 4)
+
+	cfg := newConfig(t, keys)
+	cfg.KnownPeers = peer.NewPeerSet()
+	st := newState(t, cfg)
+
+	// The node already has this transaction, so it should not be fetched.
+	known := submit(t, st, signTx(t, keys[0], 1, 10))
+
+	// A valid transaction the node is missing.
+	valid := database.NewBlockTx(signTx(t, keys[1], 1, 10), 15, 30)
+
+	// A transaction with a signature that doesn't match its contents.
+	invalid := database.NewBlockTx(signTx(t, keys[2], 1, 10), 15, 30)
+	invalid.Value = 1_000
+
+	// A transaction the peer sends without being asked for it.
+	unrequested := database.NewBlockTx(signTx(t, keys[3], 1, 10), 15, 30)
+
+	var mu sync.Mutex
+	var fetched [][]string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/node/tx/hashes", func(w http.ResponseWriter, r *http.Request) {
+		refs := []mempool.TxRef{ref(known), ref(valid), ref(invalid)}
+		json.NewEncoder(w).Encode(refs)
+	})
+	mux.HandleFunc("/v1/node/tx/fetch", func(w http.ResponseWriter, r *http.Request) {
+		var hashes []string
+		json.NewDecoder(r.Body).Decode(&hashes)
+
+		mu.Lock()
+		fetched = append(fetched, hashes)
+		mu.Unlock()
+
+		trans := []database.BlockTx{valid, invalid, unrequested}
+		json.NewEncoder(w).Encode(trans)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	pr := peer.New(strings.TrimPrefix(srv.URL, "http://"))
+	st.AddKnownPeer(pr)
+
+	// The first sync flags the peer for the invalid and the unrequested
+	// transactions.
+	if err := st.NetSyncPeerMempool(pr); err != nil {
+		t.Fatalf("Should be able to sync the mempool: %s", err)
+	}
+
+	if len(fetched) != 1 {
+		t.Fatalf("Should fetch from the peer once, got %d", len(fetched))
+	}
+	exp := map[string]bool{signature.Hash(valid): true, signature.Hash(invalid): true}
+	if len(fetched[0]) != len(exp) || !exp[fetched[0][0]] || !exp[fetched[0][1]] {
+		t.Fatalf("Should only fetch the missing transactions, got %v", fetched[0])
+	}
+
+	got := mempoolKeys(st)
+	if !got[valid.String()] {
+		t.Errorf("Should add the valid transaction")
+	}
+	if got[invalid.String()] {
+		t.Errorf("Should not add the invalid transaction")
+	}
+	if got[unrequested.String()] {
+		t.Errorf("Should not add the unrequested transaction")
+	}
+
+	if !hasPeer(st, pr) {
+		t.Fatal("Should keep the peer until it reaches the flag limit")
+	}
+
+	// The second sync only fetches the invalid transaction, which flags the
+	// peer a third time and removes it.
+	if err := st.NetSyncPeerMempool(pr); err != nil {
+		t.Fatalf("Should be able to sync the mempool: %s", err)
+	}
+
+	if len(fetched) != 2 || len(fetched[1]) != 1 || fetched[1][0] != signature.Hash(invalid) {
+		t.Fatalf("Should only fetch the invalid transaction again, got %v", fetched)
+	}
+
+	if hasPeer(st, pr) {
+		t.Fatal("Should remove the peer once it reaches the flag limit")
+	}
+}
+
+// =============================================================================
+
+// ref returns the reference for the transaction a peer would serve.
+func ref(tx database.BlockTx) mempool.TxRef {
+	return mempool.TxRef{Hash: signature.Hash(tx), FromID: tx.FromID, Nonce: tx.Nonce}
+}
+
+// hasPeer reports if the peer is in the known peer list of the node.
+func hasPeer(st *state.State, pr peer.Peer) bool {
+	for _, kp := range st.KnownExternalPeers() {
+		if kp == pr {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,10 +1,19 @@
 package state
 
-import "github.com/wtran29/go-blockchain/foundation/blockchain/database"
+import (
+	"errors"
+	"strings"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/signature"
+)
 
 // QueryLastest represents to query the latest block in the chain.
 const QueryLastest = ^uint64(0) >> 1
 
+// ErrNotFound is returned when a block or transaction can't be found.
+var ErrNotFound = errors.New("not found")
+
 // =============================================================================
 
 // QueryAccount returns a copy of the account from the database.
@@ -36,25 +45,103 @@ func (s *State) QueryBlocksByNumber(from uint64, to uint64) []database.Block {
 	return out
 }
 
+// QueryBlockNumberByTime returns the number of the first block mined at or
+// after the time in milliseconds. Block timestamps never go backwards, so
+// the chain is binary searched instead of read from the start. When no block
+// is that recent, the number after the latest block is returned.
+func (s *State) QueryBlockNumberByTime(timestamp uint64) (uint64, error) {
+	lo, hi := uint64(1), s.db.LatestBlock().Header.Number+1
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		block, err := s.db.GetBlock(mid)
+		if err != nil {
+			return 0, err
+		}
+
+		if block.Header.TimeStamp < timestamp {
+			lo = mid + 1
+			continue
+		}
+		hi = mid
+	}
+
+	return lo, nil
+}
+
 // QueryBlocksByAccount returns the set of blocks by account. If the account
-// is empty, all blocks are returned. This function reads the blockchain
-// from disk first.
+// is empty, all blocks are returned. The blocks for an account are found in
+// the index of accounts, so only those blocks are read from disk.
 func (s *State) QueryBlocksByAccount(accountID database.AccountID) ([]database.Block, error) {
 	var out []database.Block
 
+	if accountID != "" {
+		for _, num := range s.QueryBlockNumbersByAccount(accountID, 1, s.db.LatestBlock().Header.Number) {
+			block, err := s.db.GetBlock(num)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, block)
+		}
+		return out, nil
+	}
+
 	iter := s.db.ForEach()
 	for block, err := iter.Next(); !iter.Done(); block, err = iter.Next() {
 		if err != nil {
 			return nil, err
 		}
+		out = append(out, block)
+	}
 
-		for _, tx := range block.MerkleTree.Values() {
-			if accountID == "" || tx.FromID == accountID || tx.ToID == accountID {
-				out = append(out, block)
-				break
-			}
+	return out, nil
+}
+
+// QueryBlockNumbersByAccount returns the numbers of the blocks inside the
+// range with a transaction sent from or to the account, in number order.
+func (s *State) QueryBlockNumbersByAccount(accountID database.AccountID, from uint64, to uint64) []uint64 {
+	return s.db.BlockNumbersByAccount(accountID, from, to)
+}
+
+// QueryBlockByHash returns the block with the specified hash. The number of
+// the block is found in the index of block hashes, so only the one block is
+// read from disk.
+func (s *State) QueryBlockByHash(hash string) (database.Block, error) {
+	num, exists := s.db.BlockNumberByHash(strings.ToLower(hash))
+	if !exists {
+		return database.Block{}, ErrNotFound
+	}
+
+	return s.db.GetBlock(num)
+}
+
+// QueryTransactionByHash returns the transaction with the specified hash.
+// When the transaction has been mined, the block it's in is returned and
+// mined is true. Otherwise the transaction is still in the mempool. The
+// block is found in the index of transaction hashes, so only the one block
+// is read from disk.
+func (s *State) QueryTransactionByHash(hash string) (tx database.BlockTx, block database.Block, mined bool, err error) {
+	hash = strings.ToLower(hash)
+
+	if trans := s.mempool.Lookup([]string{hash}); len(trans) > 0 {
+		return trans[0], database.Block{}, false, nil
+	}
+
+	num, exists := s.db.BlockNumberByTxHash(hash)
+	if !exists {
+		return database.BlockTx{}, database.Block{}, false, ErrNotFound
+	}
+
+	block, err = s.db.GetBlock(num)
+	if err != nil {
+		return database.BlockTx{}, database.Block{}, false, err
+	}
+
+	for _, tx := range block.MerkleTree.Values() {
+		if signature.Hash(tx) == hash {
+			return tx, block, true, nil
 		}
 	}
 
-	return out, nil
+	return database.BlockTx{}, database.Block{}, false, ErrNotFound
 }
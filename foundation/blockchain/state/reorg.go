@@ -11,14 +11,18 @@ func (s *State) Reorganize() error {
 
 	// Reset the state of the blockchain node.
 	s.db.Reset()
+	s.stats.Reset()
 
 	// Resync the state of the blockchain.
 	s.resyncWG.Add(1)
 	go func() {
 		s.evHandler("state: Resync: started: *****************************")
+		s.publish(TopicReorg, ReorgEvent{Status: StatusStarted, LatestBlockNumber: s.db.LatestBlock().Header.Number})
+
 		defer func() {
 			s.turnMiningOn()
 			s.evHandler("state: Resync: completed: *****************************")
+			s.publish(TopicReorg, ReorgEvent{Status: StatusCompleted, LatestBlockNumber: s.db.LatestBlock().Header.Number})
 			s.resyncWG.Done()
 		}()
 
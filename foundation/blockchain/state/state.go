@@ -3,12 +3,16 @@
 package state
 
 import (
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/genesis"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
 	"github.com/wtran29/go-blockchain/foundation/blockchain/peer"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/stats"
 )
 
 /*
@@ -51,14 +55,19 @@ type Worker interface {
 // Config represents the configuration required to start
 // the blockchain node.
 type Config struct {
-	BeneficiaryID  database.AccountID
-	Host           string
-	Storage        database.Storage
-	Genesis        genesis.Genesis
-	SelectStrategy string
-	KnownPeers     *peer.PeerSet
-	EvHandler      EventHandler
-	Consensus      string
+	BeneficiaryID   database.AccountID
+	Host            string
+	Storage         database.Storage
+	Genesis         genesis.Genesis
+	SelectStrategy  string
+	Selector        selector.Func
+	MempoolCapacity mempool.Capacity
+	MempoolJournal  string
+	KnownPeers      *peer.PeerSet
+	EvHandler       EventHandler
+	Publisher       PublishHandler
+	Consensus       string
+	MiningWorkers   int
 }
 
 // State manages the blockchain database.
@@ -70,13 +79,22 @@ type State struct {
 	beneficiaryID database.AccountID
 	host          string
 	evHandler     EventHandler
+	publish       func(topic string, payload any)
 	consensus     string
-
-	knownPeers *peer.PeerSet
-	storage    database.Storage
-	genesis    genesis.Genesis
-	mempool    *mempool.Mempool
-	db         *database.Database
+	miningWorkers int
+	strategy      string
+	hashRate      atomic.Uint64
+
+	knownPeers  *peer.PeerSet
+	storage     database.Storage
+	genesis     genesis.Genesis
+	mempool     *mempool.Mempool
+	mempoolFeed *mempool.Feed
+	journal     *mempool.Journal
+	evicted     *atomic.Bool
+	stats       *stats.Stats
+	feeHistory  feeHistory
+	db          *database.Database
 
 	Worker Worker
 }
@@ -92,17 +110,75 @@ func New(cfg Config) (*State, error) {
 	}
 
 	// Access the storage for the blockchain.
-	db, err := database.New(cfg.Genesis, cfg.Storage, ev)
+	// If PoA is being used, drop the difficulty down to 1 to speed up
+	// the mining operation.
+	var fixedDifficulty uint64
+	if cfg.Consensus == ConsensusPOA {
+		fixedDifficulty = 1
+	}
+
+	db, err := database.New(cfg.Genesis, cfg.Storage, fixedDifficulty, ev)
 	if err != nil {
 		return nil, err
 	}
 
-	// Construct a mempool with the specified sort strategy.
-	mempool, err := mempool.NewWithStrategy(cfg.SelectStrategy)
-	// mempool, err := mempool.New()
+	// Build a safe publish function for use that records the latest block.
+	publish := func(topic string, payload any) {
+		if cfg.Publisher != nil {
+			cfg.Publisher(topic, db.LatestBlock().Header.Number, payload)
+		}
+	}
+
+	// The mempool needs the current nonce and balance for an account to know
+	// which transactions can be executed and paid for. An account that
+	// doesn't exist yet has a zero nonce and balance.
+	account := func(accountID database.AccountID) database.Account {
+		account, err := db.Query(accountID)
+		if err != nil {
+			return database.Account{AccountID: accountID}
+		}
+		return account
+	}
+
+	// The journal keeps a copy of the mempool on disk so the transactions
+	// survive a restart of the node.
+	var journal *mempool.Journal
+	if cfg.MempoolJournal != "" {
+		journal = mempool.NewJournal(cfg.MempoolJournal)
+	}
+
+	// Use the select function when one is provided, otherwise look up the
+	// sort strategy by name.
+	selectFn := cfg.Selector
+	if selectFn == nil {
+		if selectFn, err = selector.Retrieve(cfg.SelectStrategy); err != nil {
+			return nil, err
+		}
+	}
+
+	// Every change to the mempool is logged and sent to the subscribers of
+	// the feed. New transactions are also published. An eviction is recorded
+	// so the evicted transaction can be dropped from the journal.
+	mempoolFeed := mempool.NewFeed()
+	evicted := new(atomic.Bool)
+	mempoolEvents := func(mev mempool.Event) {
+		ev("state: mempool: %s: tx[%s]: reason[%s]", mev.Type, mev.Tx, mev.Reason)
+		mempoolFeed.Send(mev)
+
+		switch mev.Type {
+		case mempool.EventAdd, mempool.EventReplace:
+			publish(TopicTxAdded, TxEvent{Tx: mev.Tx, Replaced: mev.Type == mempool.EventReplace})
+		case mempool.EventEvict:
+			evicted.Store(true)
+		}
+	}
+
+	// Construct a mempool with the select function and capacity.
+	mempool, err := mempool.NewWithSelector(selectFn, cfg.MempoolCapacity, account)
 	if err != nil {
 		return nil, err
 	}
+	mempool.SetEventHandler(mempoolEvents)
 
 	// Create the State to provide support for managing the blockchain.
 	state := State{
@@ -110,14 +186,39 @@ func New(cfg Config) (*State, error) {
 		host:          cfg.Host,
 		storage:       cfg.Storage,
 		evHandler:     ev,
+		publish:       publish,
+		miningWorkers: cfg.MiningWorkers,
+		strategy:      strings.ToLower(cfg.SelectStrategy),
+		consensus:     cfg.Consensus,
 
-		// consensus:     cfg.Consensus,
 		allowMining: true,
 
-		knownPeers: cfg.KnownPeers,
-		genesis:    cfg.Genesis,
-		mempool:    mempool,
-		db:         db,
+		knownPeers:  cfg.KnownPeers,
+		genesis:     cfg.Genesis,
+		mempool:     mempool,
+		mempoolFeed: mempoolFeed,
+		journal:     journal,
+		evicted:     evicted,
+		stats:       stats.New(statsRetain),
+		db:          db,
+	}
+
+	// Build the stats from the blocks already in the chain. From here on they
+	// are updated as each block is added.
+	iter := db.ForEach()
+	for block, err := iter.Next(); !iter.Done(); block, err = iter.Next() {
+		if err != nil {
+			return nil, err
+		}
+		state.stats.Add(block, db.BurnedByBlock(block.Header.Number))
+	}
+
+	// Restore the transactions that were in the mempool when the node was
+	// last shutdown.
+	if state.journal != nil {
+		if err := state.replayJournal(); err != nil {
+			return nil, err
+		}
 	}
 
 	// The Worker is not set here. The call to worker.Run will assign itself
@@ -142,6 +243,12 @@ func (s *State) Shutdown() error {
 	// Wait for any resync to finish.
 	s.resyncWG.Wait()
 
+	// Leave the journal with just the transactions still in the mempool.
+	s.rotateJournal()
+
+	// Release the subscribers to the mempool.
+	s.mempoolFeed.Shutdown()
+
 	return nil
 }
 
@@ -166,19 +273,66 @@ func (s *State) LatestBlock() database.Block {
 	return s.db.LatestBlock()
 }
 
-// MempoolLength returns the current length of the mempool.
+// HashRate returns the number of hashes per second measured during the
+// latest mining operation.
+func (s *State) HashRate() uint64 {
+	return s.hashRate.Load()
+}
+
+// MempoolLength returns the number of transactions in the mempool that
+// can be mined into the next block.
 func (s *State) MempoolLength() int {
+	return s.mempool.PendingCount()
+}
+
+// MempoolCount returns the number of transactions in the mempool, including
+// the ones queued behind a nonce gap.
+func (s *State) MempoolCount() int {
 	return s.mempool.Count()
 }
 
+// MempoolNextNonce returns the nonce the account's next transaction needs
+// to use to follow its transactions that can be mined.
+func (s *State) MempoolNextNonce(accountID database.AccountID) uint64 {
+	return s.mempool.NextNonce(accountID)
+}
+
 // Mempool returns a copy of the mempool.
 func (s *State) Mempool() []database.BlockTx {
-	return s.mempool.PickBest()
+	return s.mempool.Copy()
+}
+
+// MempoolRefs returns a reference to every transaction in the mempool.
+func (s *State) MempoolRefs() []mempool.TxRef {
+	return s.mempool.Refs()
 }
 
-// UpsertMempool adds a new transaction to the mempool.
-func (s *State) UpsertMempool(tx database.BlockTx) error {
-	return s.mempool.Upsert(tx)
+// MempoolLookup returns the transactions in the mempool that match the
+// specified hashes.
+func (s *State) MempoolLookup(hashes []string) []database.BlockTx {
+	return s.mempool.Lookup(hashes)
+}
+
+// SelectStrategy returns the name of the strategy used to select the
+// transactions for a block.
+func (s *State) SelectStrategy() string {
+	return s.strategy
+}
+
+// Genesis returns the genesis settings for the blockchain.
+func (s *State) Genesis() genesis.Genesis {
+	return s.genesis
+}
+
+// Supply returns the current supply information for the blockchain.
+func (s *State) Supply() database.Supply {
+	return s.db.Supply()
+}
+
+// NextBlockRules returns the difficulty, mining reward and base fee the
+// next block in the chain will be built with.
+func (s *State) NextBlockRules() (database.BlockRules, error) {
+	return s.db.NextBlockRules(s.db.LatestBlock())
 }
 
 // Accounts returns a copy of the database accounts.
@@ -189,7 +343,12 @@ func (s *State) Accounts() map[database.AccountID]database.Account {
 // AddKnownPeer provides the ability to add a new peer to
 // the known peer list.
 func (s *State) AddKnownPeer(peer peer.Peer) bool {
-	return s.knownPeers.Add(peer)
+	if !s.knownPeers.Add(peer) {
+		return false
+	}
+	s.publish(TopicPeerAdded, PeerEvent{Host: peer.Host})
+
+	return true
 }
 
 // RemoveKnownPeer provides the ability to remove a peer from
@@ -0,0 +1,160 @@
+package state_test
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/genesis"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool/selector"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/state"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/storage/memory"
+)
+
+// beneficiaryID is the account that receives the rewards for the blocks
+// mined in the tests.
+const beneficiaryID = database.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+
+// worker implements the state.Worker interface without doing any work.
+type worker struct{}
+
+func (worker) Shutdown()                              {}
+func (worker) Sync()                                  {}
+func (worker) SignalStartMining()                     {}
+func (worker) SignalCancelMining()                    {}
+func (worker) SignalShareTx(blockTx database.BlockTx) {}
+
+// =============================================================================
+
+// newKeys generates the private keys for the specified number of accounts.
+func newKeys(t *testing.T, n int) []*ecdsa.PrivateKey {
+	t.Helper()
+
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("Should be able to generate a private key: %s", err)
+		}
+		keys[i] = key
+	}
+
+	return keys
+}
+
+// accountID returns the account for the private key.
+func accountID(key *ecdsa.PrivateKey) database.AccountID {
+	return database.PublicKeyToAccountID(key.PublicKey)
+}
+
+// newGenesis constructs a genesis for a PoA chain that mines one transaction
+// per block and funds the accounts for the keys.
+func newGenesis(keys []*ecdsa.PrivateKey) genesis.Genesis {
+	balances := make(map[string]uint64, len(keys))
+	for _, key := range keys {
+		balances[string(accountID(key))] = 1_000_000
+	}
+
+	return genesis.Genesis{
+		Date:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ChainID:       1,
+		TransPerBlock: 1,
+		Difficulty:    1,
+		MiningReward:  700,
+		GasPrice:      15,
+		BaseFee:       5,
+		Balances:      balances,
+		Gas: genesis.GasSchedule{
+			TxBase:   21,
+			Transfer: 9,
+		},
+	}
+}
+
+// newConfig constructs the configuration for a node on a PoA chain using the
+// fee density strategy and memory storage.
+func newConfig(t *testing.T, keys []*ecdsa.PrivateKey) state.Config {
+	t.Helper()
+
+	storage, err := memory.New()
+	if err != nil {
+		t.Fatalf("Should be able to construct the storage: %s", err)
+	}
+
+	return state.Config{
+		BeneficiaryID:  beneficiaryID,
+		Host:           "localhost:9080",
+		Storage:        storage,
+		Genesis:        newGenesis(keys),
+		SelectStrategy: selector.StrategyFeeDensity,
+		Consensus:      state.ConsensusPOA,
+		MiningWorkers:  1,
+	}
+}
+
+// newState constructs a node for the configuration with a worker that does
+// nothing.
+func newState(t *testing.T, cfg state.Config) *state.State {
+	t.Helper()
+
+	st, err := state.New(cfg)
+	if err != nil {
+		t.Fatalf("Should be able to construct the state: %s", err)
+	}
+	st.Worker = worker{}
+
+	return st
+}
+
+// signTx constructs and signs a transfer to the beneficiary that pays for
+// its gas at the genesis prices.
+func signTx(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, tip uint64) database.SignedTx {
+	t.Helper()
+
+	return signTxUntil(t, key, nonce, tip, 0)
+}
+
+// signTxUntil constructs and signs a transfer like signTx that can only be
+// mined up to the specified block number.
+func signTxUntil(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, tip uint64, validUntil uint64) database.SignedTx {
+	t.Helper()
+
+	tx, err := database.NewTx(1, nonce, accountID(key), beneficiaryID, 10, tip, nil, 30, 20)
+	if err != nil {
+		t.Fatalf("Should be able to construct the transaction: %s", err)
+	}
+	tx.ValidUntil = validUntil
+
+	signedTx, err := tx.Sign(key)
+	if err != nil {
+		t.Fatalf("Should be able to sign the transaction: %s", err)
+	}
+
+	return signedTx
+}
+
+// submit adds the signed transaction to the mempool of the node and fails
+// the test when it's rejected.
+func submit(t *testing.T, st *state.State, signedTx database.SignedTx) database.BlockTx {
+	t.Helper()
+
+	tx, err := st.UpsertWalletTransaction(signedTx)
+	if err != nil {
+		t.Fatalf("Should be able to submit %s: %s", signedTx, err)
+	}
+
+	return tx
+}
+
+// mempoolKeys returns the account and nonce of every transaction in the
+// mempool of the node.
+func mempoolKeys(st *state.State) map[string]bool {
+	keys := make(map[string]bool)
+	for _, tx := range st.Mempool() {
+		keys[tx.String()] = true
+	}
+
+	return keys
+}
@@ -0,0 +1,46 @@
+package state
+
+import (
+	"sort"
+	"time"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/stats"
+)
+
+// statsRetain represents the longest period of time the chain stats can
+// report on.
+const statsRetain = 7 * 24 * time.Hour
+
+// ChainStats returns the counts since genesis along with the stats for each
+// of the periods ending now.
+func (s *State) ChainStats(periods ...time.Duration) (stats.Totals, []stats.Period) {
+	now := time.Now()
+
+	out := make([]stats.Period, len(periods))
+	for i, period := range periods {
+		out[i] = s.stats.Period(now, period)
+	}
+
+	return s.stats.Totals(), out
+}
+
+// RichList returns the accounts ordered by balance from the highest. Accounts
+// with the same balance are ordered by account.
+func (s *State) RichList() []database.Account {
+	accounts := s.db.Copy()
+
+	list := make([]database.Account, 0, len(accounts))
+	for _, account := range accounts {
+		list = append(list, account)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Balance != list[j].Balance {
+			return list[i].Balance > list[j].Balance
+		}
+		return list[i].AccountID < list[j].AccountID
+	})
+
+	return list
+}
@@ -0,0 +1,34 @@
+package state_test
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_ChainStatsBurned(t *testing.T) {
+	keys := newKeys(t, 2)
+	cfg := newConfig(t, keys)
+	st := newState(t, cfg)
+
+	for _, key := range keys {
+		submit(t, st, signTx(t, key, 1, 10))
+		if _, err := st.MineNewBlock(context.Background()); err != nil {
+			t.Fatalf("Should be able to mine a block: %s", err)
+		}
+	}
+
+	burned := st.Supply().Burned
+	if burned == 0 {
+		t.Fatal("Should burn the base fees of the mined transactions")
+	}
+
+	if totals, _ := st.ChainStats(); totals.Burned != burned {
+		t.Fatalf("Should report the burn from the supply %d, got %d", burned, totals.Burned)
+	}
+
+	// The stats are rebuilt from the chain when the node restarts.
+	restarted := newState(t, cfg)
+	if totals, _ := restarted.ChainStats(); totals.Burned != burned {
+		t.Fatalf("Should rebuild the burn from the supply %d, got %d", burned, totals.Burned)
+	}
+}
@@ -1,49 +1,159 @@
 package state
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
 )
 
 // UpsertWalletTransaction accepts a transaction from a wallet for inclusion.
-func (s *State) UpsertWalletTransaction(signedTx database.SignedTx) error {
+// The block transaction that was added to the mempool is returned.
+func (s *State) UpsertWalletTransaction(signedTx database.SignedTx) (database.BlockTx, error) {
 
-	// CORE NOTE: It's up to the wallet to make sure the account has a proper
-	// balance and this transaction has a proper nonce. Fees will be taken if
-	// this transaction is mined into a block it doesn't have enough money to
-	// pay or the nonce isn't the next expected nonce for the account.
+	// CORE NOTE: The mempool rejects a transaction with a nonce that has
+	// already been used or when the account can't cover the value, tip and
+	// max gas for all of its transactions in the pool. The balance can still
+	// change before the transaction is mined, so fees can still be taken if
+	// the account doesn't have enough money to pay when the block is mined.
 
 	// Check the signed transaction has a proper signature, the from matches the
 	// signature, and the from and to fields are properly formatted.
 	if err := signedTx.Validate(s.genesis.ChainID); err != nil {
-		return err
+		return database.BlockTx{}, validateError(err)
+	}
+
+	// Charge the transaction for its size and the value it moves.
+	gasUnits := database.IntrinsicGas(signedTx.Tx, s.genesis.Gas)
+
+	tx := database.NewBlockTx(signedTx, s.genesis.GasPrice, gasUnits)
+	if err := s.validateGas(tx); err != nil {
+		return database.BlockTx{}, err
+	}
+
+	if err := s.validateExpiry(tx); err != nil {
+		return database.BlockTx{}, err
 	}
 
-	const oneUnitOfGas = 1
-	tx := database.NewBlockTx(signedTx, s.genesis.GasPrice, oneUnitOfGas)
 	if err := s.mempool.Upsert(tx); err != nil {
-		return err
+		return database.BlockTx{}, err
 	}
+	s.journalTx(tx)
 
 	s.Worker.SignalShareTx(tx)
 	s.Worker.SignalStartMining()
 
-	return nil
+	return tx, nil
 }
 
 // UpsertNodeTransaction accepts a transaction from a node for inclusion.
 func (s *State) UpsertNodeTransaction(tx database.BlockTx) error {
 
-	// Check the signed transaction has a proper signature, the from matches the
-	// signature, and the from and to fields are properly formatted.
-	if err := tx.Validate(s.genesis.ChainID); err != nil {
+	if err := s.validateTx(tx); err != nil {
 		return err
 	}
 
 	if err := s.mempool.Upsert(tx); err != nil {
 		return err
 	}
+	s.journalTx(tx)
 
 	s.Worker.SignalStartMining()
 
 	return nil
 }
+
+// =============================================================================
+
+// validateTx checks the transaction received from a node has a proper
+// signature, the from matches the signature, the from and to fields are
+// properly formatted, it can pay for its gas and it has not expired.
+func (s *State) validateTx(tx database.BlockTx) error {
+	if err := tx.Validate(s.genesis.ChainID); err != nil {
+		return validateError(err)
+	}
+
+	if err := s.validateGas(tx); err != nil {
+		return err
+	}
+
+	return s.validateExpiry(tx)
+}
+
+// validateGas checks the transaction can pay for its gas at the price of gas
+// required by the next block.
+func (s *State) validateGas(tx database.BlockTx) error {
+	rules, err := s.db.NextBlockRules(s.db.LatestBlock())
+	if err != nil {
+		return err
+	}
+
+	if err := tx.ValidateGas(rules); err != nil {
+		return mempool.NewRejectError(mempool.ReasonInvalidGas, err)
+	}
+
+	return nil
+}
+
+// validateExpiry checks the transaction can still be mined into the next block.
+func (s *State) validateExpiry(tx database.BlockTx) error {
+	nextNumber := s.db.LatestBlock().Header.Number + 1
+	if tx.IsExpired(nextNumber) {
+		err := fmt.Errorf("transaction expired, valid until %d, next block %d", tx.ValidUntil, nextNumber)
+		return mempool.NewRejectError(mempool.ReasonExpired, err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// SweepMempool removes the transactions from the mempool that have been
+// waiting longer than the TTL or can no longer be mined into the next block.
+func (s *State) SweepMempool() {
+	if s.sweepMempool() > 0 {
+
+		// Drop the evicted transactions from the journal.
+		s.rotateJournal()
+	}
+}
+
+// sweepMempool performs the sweep of the mempool and returns the number of
+// transactions that were evicted. The mempool sends an event for each one.
+func (s *State) sweepMempool() int {
+	nextNumber := s.db.LatestBlock().Header.Number + 1
+
+	return len(s.mempool.Sweep(time.Now(), nextNumber))
+}
+
+// =============================================================================
+
+// SubscribeMempool takes a unique id and returns a channel that receives the
+// changes to the mempool. When accounts are provided, only the changes for
+// transactions sent from or to those accounts are received.
+func (s *State) SubscribeMempool(id string, accounts ...database.AccountID) <-chan mempool.Event {
+	return s.mempoolFeed.Subscribe(id, accounts...)
+}
+
+// UnsubscribeMempool closes and removes the channel that was provided by the
+// call to SubscribeMempool.
+func (s *State) UnsubscribeMempool(id string) {
+	s.mempoolFeed.Unsubscribe(id)
+}
+
+// =============================================================================
+
+// validateError wraps the error from validating a signed transaction with
+// the reason for the check that failed.
+func validateError(err error) error {
+	switch {
+	case errors.Is(err, database.ErrInvalidChainID):
+		return mempool.NewRejectError(mempool.ReasonInvalidChainID, err)
+	case errors.Is(err, database.ErrInvalidAccount):
+		return mempool.NewRejectError(mempool.ReasonInvalidAccount, err)
+	default:
+		return mempool.NewRejectError(mempool.ReasonInvalidSignature, err)
+	}
+}
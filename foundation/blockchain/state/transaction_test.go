@@ -0,0 +1,52 @@
+package state_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/mempool"
+)
+
+func Test_SweepValidUntil(t *testing.T) {
+	keys := newKeys(t, 4)
+	st := newState(t, newConfig(t, keys))
+
+	// The next block is block 1.
+	mined := submit(t, st, signTx(t, keys[0], 1, 100))
+	lastBlock := submit(t, st, signTxUntil(t, keys[1], 1, 1, 1))
+	nextBlock := submit(t, st, signTxUntil(t, keys[2], 1, 1, 2))
+
+	if _, err := st.UpsertWalletTransaction(signTxUntil(t, keys[3], 1, 1, 0)); err != nil {
+		t.Fatalf("Should accept a transaction that never expires: %s", err)
+	}
+
+	// Mining block 1 leaves a transaction that is valid until block 1 behind,
+	// so it can't be mined anymore. The next block is block 2.
+	if _, err := st.MineNewBlock(context.Background()); err != nil {
+		t.Fatalf("Should be able to mine a block: %s", err)
+	}
+
+	got := mempoolKeys(st)
+	if got[mined.String()] {
+		t.Errorf("Should remove the mined transaction %s", mined)
+	}
+	if got[lastBlock.String()] {
+		t.Errorf("Should evict the transaction valid until block 1 once block 1 is mined")
+	}
+	if !got[nextBlock.String()] {
+		t.Errorf("Should keep the transaction valid until block 2 for block 2")
+	}
+	if len(got) != 2 {
+		t.Errorf("Should keep 2 transactions, got %v", got)
+	}
+
+	// A transaction valid until the latest block is rejected, while one
+	// valid until the next block is accepted.
+	_, err := st.UpsertWalletTransaction(signTxUntil(t, keys[0], 2, 1, 1))
+	if re := mempool.GetRejectError(err); re == nil || re.Reason != mempool.ReasonExpired {
+		t.Errorf("Should reject a transaction valid until the latest block, got %v", err)
+	}
+	if _, err := st.UpsertWalletTransaction(signTxUntil(t, keys[0], 2, 1, 2)); err != nil {
+		t.Errorf("Should accept a transaction valid until the next block: %s", err)
+	}
+}
@@ -0,0 +1,185 @@
+// Package stats maintains statistics about the blocks added to the chain so
+// they can be reported without reading the chain from disk.
+package stats
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+)
+
+// CORE NOTE: The stats are updated once for every block that is added to the
+// chain. A summary of each recent block is kept in memory and every period is
+// calculated from those summaries when asked for. Only the longest period
+// needs to be kept, so older summaries are dropped as new blocks arrive. The
+// totals since genesis are kept as running counts.
+
+// Totals represents the counts since the genesis of the chain.
+type Totals struct {
+	Blocks       uint64
+	Transactions uint64
+	Fees         uint64 // Gas fees and tips paid to the beneficiaries.
+	Burned       uint64 // Base fees that were burned.
+}
+
+// Period represents the statistics for the blocks mined in a period of time.
+type Period struct {
+	Duration        time.Duration
+	Blocks          int
+	Transactions    int
+	AvgBlockTime    time.Duration // Average time between the blocks.
+	TxPerSecond     float64       // Transactions mined for each second of the period.
+	Fees            uint64        // Gas fees and tips paid to the beneficiaries.
+	AvgFeesPerBlock uint64        // Average of the fees paid for each block.
+	Burned          uint64        // Base fees that were burned.
+	HashRate        uint64        // Estimated hashes per second to mine the blocks.
+	ActiveAccounts  int           // Accounts that sent or received a transaction.
+}
+
+// blockStats represents the summary of a block that is kept in memory.
+type blockStats struct {
+	timeStamp  uint64
+	difficulty uint64
+	trans      int
+	fees       uint64
+	burned     uint64
+	accounts   []database.AccountID
+}
+
+// =============================================================================
+
+// Stats maintains the statistics for the blocks in the chain.
+type Stats struct {
+	mu     sync.RWMutex
+	retain time.Duration
+	blocks []blockStats
+	totals Totals
+}
+
+// New constructs the stats to keep the summaries of the blocks mined within
+// the retain duration of the latest block.
+func New(retain time.Duration) *Stats {
+	return &Stats{
+		retain: retain,
+	}
+}
+
+// Add updates the statistics with the block that was added to the chain and
+// the base fees its transactions burned. The burn is taken from the database,
+// since only the balances at the time can tell what a sender could pay.
+func (s *Stats) Add(block database.Block, burned uint64) {
+	bs := blockStats{
+		timeStamp:  block.Header.TimeStamp,
+		difficulty: block.Header.Difficulty,
+		burned:     burned,
+	}
+
+	seen := make(map[database.AccountID]struct{})
+	for _, tx := range block.MerkleTree.Values() {
+		bs.trans++
+		bs.fees += tx.Fee()
+
+		for _, accountID := range []database.AccountID{tx.FromID, tx.ToID} {
+			if _, exists := seen[accountID]; !exists {
+				seen[accountID] = struct{}{}
+				bs.accounts = append(bs.accounts, accountID)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.totals.Blocks++
+	s.totals.Transactions += uint64(bs.trans)
+	s.totals.Fees += bs.fees
+	s.totals.Burned += bs.burned
+
+	s.blocks = append(s.blocks, bs)
+
+	// Drop the summaries older than the retain duration. One older summary is
+	// kept so the time to mine the oldest block in a period is known.
+	cutoff := int64(bs.timeStamp) - s.retain.Milliseconds()
+	var drop int
+	for drop < len(s.blocks)-1 && int64(s.blocks[drop+1].timeStamp) < cutoff {
+		drop++
+	}
+	if drop > 0 {
+		s.blocks = append([]blockStats(nil), s.blocks[drop:]...)
+	}
+}
+
+// Reset removes all the statistics so they can be built again when the
+// chain is resynced.
+func (s *Stats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blocks = nil
+	s.totals = Totals{}
+}
+
+// Totals returns the counts since the genesis of the chain.
+func (s *Stats) Totals() Totals {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.totals
+}
+
+// Period returns the statistics for the blocks mined within the duration
+// before now. A duration longer than the retain duration only reports the
+// blocks that are retained.
+func (s *Stats) Period(now time.Time, duration time.Duration) Period {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p := Period{
+		Duration: duration,
+	}
+
+	from := uint64(now.Add(-duration).UnixMilli())
+	accounts := make(map[database.AccountID]struct{})
+
+	var elapsed, work uint64
+	var timed int
+	for i, bs := range s.blocks {
+		if bs.timeStamp < from {
+			continue
+		}
+
+		p.Blocks++
+		p.Transactions += bs.trans
+		p.Fees += bs.fees
+		p.Burned += bs.burned
+		for _, accountID := range bs.accounts {
+			accounts[accountID] = struct{}{}
+		}
+
+		// The time to mine a block is only known when the previous block
+		// is retained.
+		if i > 0 {
+			elapsed += bs.timeStamp - s.blocks[i-1].timeStamp
+			work += bs.difficulty
+			timed++
+		}
+	}
+
+	p.ActiveAccounts = len(accounts)
+
+	if p.Blocks > 0 {
+		p.AvgFeesPerBlock = p.Fees / uint64(p.Blocks)
+	}
+	if seconds := duration.Seconds(); seconds > 0 {
+		p.TxPerSecond = float64(p.Transactions) / seconds
+	}
+	if timed > 0 {
+		p.AvgBlockTime = time.Duration(elapsed/uint64(timed)) * time.Millisecond
+	}
+	if elapsed > 0 {
+		p.HashRate = uint64(float64(work) / (float64(elapsed) / 1000))
+	}
+
+	return p
+}
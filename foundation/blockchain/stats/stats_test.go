@@ -0,0 +1,122 @@
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wtran29/go-blockchain/foundation/blockchain/database"
+	"github.com/wtran29/go-blockchain/foundation/blockchain/stats"
+)
+
+const (
+	accountA = database.AccountID("0xF01813E4B85e178A83e29B8E7bF26BD830a25f32")
+	accountB = database.AccountID("0xdd6B972ffcc631a62CAE1BB9d80b7ff429c8ebA4")
+	accountC = database.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+)
+
+func Test_Period(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	s := stats.New(24 * time.Hour)
+	s.Add(newBlock(t, 1, now.Add(-70*time.Minute), newTx(1, accountB, accountC)), 50)
+	s.Add(newBlock(t, 2, now.Add(-50*time.Minute), newTx(1, accountA, accountB), newTx(2, accountA, accountB)), 100)
+	s.Add(newBlock(t, 3, now.Add(-30*time.Minute), newTx(2, accountB, accountC)), 50)
+
+	// The sender in the last block couldn't pay for its gas, so the base fee
+	// for the gas it used wasn't burned.
+	s.Add(newBlock(t, 4, now.Add(-10*time.Minute), newTx(1, accountC, accountA)), 0)
+
+	totals := s.Totals()
+	if totals.Blocks != 4 || totals.Transactions != 5 {
+		t.Fatalf("Should have totals for every block, got %d blocks and %d transactions", totals.Blocks, totals.Transactions)
+	}
+	if totals.Fees != 105 || totals.Burned != 200 {
+		t.Fatalf("Should have the fees of every transaction, got %d fees and %d burned", totals.Fees, totals.Burned)
+	}
+
+	p := s.Period(now, time.Hour)
+
+	if p.Blocks != 3 || p.Transactions != 4 {
+		t.Fatalf("Should only count the blocks in the hour, got %d blocks and %d transactions", p.Blocks, p.Transactions)
+	}
+	if p.AvgBlockTime != 20*time.Minute {
+		t.Errorf("Should have the average time between blocks, got %s", p.AvgBlockTime)
+	}
+	if p.HashRate != 1000 {
+		t.Errorf("Should estimate the hash rate from the difficulty, got %d", p.HashRate)
+	}
+	if p.AvgFeesPerBlock != 28 {
+		t.Errorf("Should have the average fees for a block, got %d", p.AvgFeesPerBlock)
+	}
+	if p.Burned != 150 {
+		t.Errorf("Should have the base fees the database burned, got %d", p.Burned)
+	}
+	if p.ActiveAccounts != 3 {
+		t.Errorf("Should count each active account once, got %d", p.ActiveAccounts)
+	}
+}
+
+func Test_Retain(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	s := stats.New(30 * time.Minute)
+	s.Add(newBlock(t, 1, now.Add(-70*time.Minute), newTx(1, accountA, accountB)), 50)
+	s.Add(newBlock(t, 2, now.Add(-50*time.Minute), newTx(2, accountA, accountB)), 50)
+	s.Add(newBlock(t, 3, now.Add(-30*time.Minute), newTx(3, accountA, accountB)), 50)
+	s.Add(newBlock(t, 4, now.Add(-10*time.Minute), newTx(4, accountA, accountB)), 50)
+
+	p := s.Period(now, 2*time.Hour)
+	if p.Blocks != 3 {
+		t.Fatalf("Should keep one block older than the retain duration, got %d blocks", p.Blocks)
+	}
+	if p.AvgBlockTime != 20*time.Minute {
+		t.Errorf("Should only time the blocks with a retained previous block, got %s", p.AvgBlockTime)
+	}
+
+	if totals := s.Totals(); totals.Blocks != 4 {
+		t.Errorf("Should keep the totals for the dropped blocks, got %d blocks", totals.Blocks)
+	}
+
+	s.Reset()
+	if p := s.Period(now, 2*time.Hour); p.Blocks != 0 {
+		t.Errorf("Should have no blocks after a reset, got %d blocks", p.Blocks)
+	}
+}
+
+// =============================================================================
+
+func newBlock(t *testing.T, number uint64, minedAt time.Time, trans ...database.BlockTx) database.Block {
+	t.Helper()
+
+	block, err := database.ToBlock(database.BlockData{
+		Header: database.BlockHeader{
+			Number:     number,
+			TimeStamp:  uint64(minedAt.UnixMilli()),
+			Difficulty: 1_200_000,
+			BaseFee:    5,
+		},
+		Trans: trans,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the block: %s", err)
+	}
+
+	return block
+}
+
+func newTx(nonce uint64, from database.AccountID, to database.AccountID) database.BlockTx {
+	tx := database.Tx{
+		ChainID: 1,
+		Nonce:   nonce,
+		FromID:  from,
+		ToID:    to,
+		Value:   10,
+		Tip:     1,
+	}
+
+	return database.BlockTx{
+		SignedTx: database.SignedTx{Tx: tx},
+		GasPrice: 2,
+		GasUnits: 10,
+	}
+}
@@ -49,18 +49,19 @@ func (m *Memory) GetBlock(num uint64) (database.BlockData, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	// Block numbers start at 1 and the first block is stored at index 0.
 	l := uint64(len(m.blocks))
-	if l == 0 || num >= l {
+	if num == 0 || num > l {
 		return database.BlockData{}, errors.New("block does not exist")
 	}
 
-	return m.blocks[num], nil
+	return m.blocks[num-1], nil
 }
 
 // ForEach returns an iterator to walk through all the blocks
 // starting with block number 1.
 func (m *Memory) ForEach() database.Iterator {
-	return &memoryIterator{storage: m}
+	return &memoryIterator{storage: m, current: 1}
 }
 
 // Reset will clear out the blockchain on disk.
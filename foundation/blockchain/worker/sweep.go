@@ -0,0 +1,39 @@
+package worker
+
+import "time"
+
+// CORE NOTE: Transactions that will never be mined, like a transaction
+// waiting on a nonce gap that is never filled, would sit in the mempool
+// forever. This goroutine sweeps the mempool on an interval and evicts the
+// transactions that have been waiting longer than the configured TTL.
+
+// mempoolSweepInterval represents the interval of sweeping the mempool for
+// transactions that have been waiting too long.
+const mempoolSweepInterval = time.Minute
+
+// =============================================================================
+
+// sweepOperations handles evicting stale transactions from the mempool.
+func (w *Worker) sweepOperations() {
+	w.evHandler("worker: sweepOperations: G started")
+	defer w.evHandler("worker: sweepOperations: G completed")
+
+	ticker := time.NewTicker(mempoolSweepInterval)
+	defer ticker.Stop()
+
+	// Transactions restored from the journal may have expired while the
+	// node was down.
+	w.state.SweepMempool()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !w.isShutdown() {
+				w.state.SweepMempool()
+			}
+		case <-w.shut:
+			w.evHandler("worker: sweepOperations: received shut signal")
+			return
+		}
+	}
+}
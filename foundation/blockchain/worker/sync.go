@@ -21,14 +21,9 @@ func (w *Worker) Sync() {
 		// Add new peers to this nodes list.
 		w.addNewPeers(peerStatus.KnownPeers)
 
-		// Retrieve the mempool from the peer.
-		pool, err := w.state.NetRequestPeerMempool(peer)
-		if err != nil {
-			w.evHandler("worker: sync: retrievePeerMempool: %s: ERROR: %s", peer.Host, err)
-		}
-		for _, tx := range pool {
-			w.evHandler("worker: sync: retrievePeerMempool: %s: Add Tx: %s", peer.Host, tx.SignatureString()[:16])
-			w.state.UpsertMempool(tx)
+		// Retrieve the transactions from the peer's mempool we don't have.
+		if err := w.state.NetSyncPeerMempool(peer); err != nil {
+			w.evHandler("worker: sync: syncPeerMempool: %s: ERROR: %s", peer.Host, err)
 		}
 
 		// If this peer has blocks we don't have, we need to add them.
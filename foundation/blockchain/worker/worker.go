@@ -59,6 +59,7 @@ func Run(st *state.State, evHandler state.EventHandler) {
 		// w.powOperations,
 		w.peerOperations,
 		w.shareTxOperations,
+		w.sweepOperations,
 		consensusOperation,
 	}
 
@@ -2,55 +2,201 @@
 package events
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// CORE NOTE: A subscriber that connects late or reconnects needs the events
+// it missed. The most recent events are kept in a bounded history and each
+// event gets a sequence number and the block height of the chain when it
+// happened. A subscriber can resume from the last event it saw or from a
+// block height. Once events fall out of the history they are gone, so a
+// subscriber resuming from a block height is told which blocks it needs to
+// backfill from storage.
+//
+// The sequence numbers start over when the process restarts, so each process
+// picks an epoch when it starts and the token a subscriber resumes with holds
+// both. A token from a different epoch can't be trusted. Topics that fire
+// many times a second, like mining progress, are only worth receiving live
+// and would push everything else out of the history, so they are not kept.
+
+// historySize represents the number of events kept for replay.
+const historySize = 1000
+
+// ErrHistoryExhausted is returned when a subscriber asks to resume from an
+// event that is no longer in the history.
+var ErrHistoryExhausted = errors.New("event history exhausted")
+
+// Event represents something that happened in the system. The topic
+// identifies what happened and the payload carries the details. Block is the
+// number of the latest block in the chain when the event happened. Dropped is
+// the number of events the subscriber missed right before this one because
+// it was not keeping up. ID is the token to resume from this event.
+type Event struct {
+	ID      string `json:"id,omitempty"`
+	Seq     uint64 `json:"seq"`
+	Block   uint64 `json:"block"`
+	Topic   string `json:"topic"`
+	Dropped uint64 `json:"dropped,omitempty"`
+	Payload any    `json:"payload"`
+}
+
+// subscriber represents a registered channel and the topics it wants.
+type subscriber struct {
+	ch      chan Event
+	topics  map[string]struct{}
+	dropped atomic.Uint64
+}
+
+// wants reports if the subscriber is interested in the topic. A subscriber
+// without any topics wants them all.
+func (sub *subscriber) wants(topic string) bool {
+	if len(sub.topics) == 0 {
+		return true
+	}
+
+	_, exists := sub.topics[topic]
+	return exists
+}
+
+// =============================================================================
+
 // Events maintains a mapping of unique id and channels so goroutines
 // can register and receive events.
 type Events struct {
-	m  map[string]chan string
+	m  map[string]*subscriber
 	mu sync.RWMutex
+
+	epoch    string
+	volatile map[string]struct{}
+	seq      uint64
+	history  []Event
+	next     int
+	evicted  *Event
 }
 
-// New constructs an events for registering and receiving events.
-func New() *Events {
+// New constructs an events for registering and receiving events. Events for
+// the volatile topics are sent to the subscribers but not kept in the history.
+func New(volatile ...string) *Events {
+	evt := Events{
+		m:        make(map[string]*subscriber),
+		epoch:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		volatile: make(map[string]struct{}, len(volatile)),
+		history:  make([]Event, 0, historySize),
+	}
 
-	return &Events{
-		m: make(map[string]chan string),
+	for _, topic := range volatile {
+		evt.volatile[topic] = struct{}{}
 	}
+
+	return &evt
 }
 
 // Shutdown closes and removes all channels that were provided by
 // the call to Acquire.
 func (evt *Events) Shutdown() {
-	evt.mu.RLock()
-	defer evt.mu.RUnlock()
+	evt.mu.Lock()
+	defer evt.mu.Unlock()
 
-	for id, ch := range evt.m {
+	for id, sub := range evt.m {
 		delete(evt.m, id)
-		close(ch)
+		close(sub.ch)
 	}
 }
 
-// Acquire takes a unique id and returns a channel that can be used
-// to receive events.
-func (evt *Events) Acquire(id string) chan string {
+// Acquire takes a unique id and returns a channel that can be used to
+// receive events for the specified topics. When no topics are provided,
+// events for every topic are received.
+func (evt *Events) Acquire(id string, topics ...string) <-chan Event {
 	evt.mu.Lock()
 	defer evt.mu.Unlock()
 
-	ch, exists := evt.m[id]
+	return evt.acquire(id, topics)
+}
+
+// AcquireSince works like Acquire but also returns the events for the topics
+// that happened after the event with the specified token. ErrHistoryExhausted
+// is returned when some of those events are no longer in the history or the
+// token is from before this process was restarted.
+func (evt *Events) AcquireSince(id string, token string, topics ...string) (<-chan Event, []Event, error) {
+	epoch, seq, err := ParseToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	evt.mu.Lock()
+	defer evt.mu.Unlock()
+
+	if epoch != evt.epoch || seq > evt.seq || (evt.evicted != nil && evt.evicted.Seq > seq) {
+		return nil, nil, ErrHistoryExhausted
+	}
+
+	ch := evt.acquire(id, topics)
+	sub := evt.m[id]
+
+	var replay []Event
+	for _, ev := range evt.ordered() {
+		if ev.Seq > seq && sub.wants(ev.Topic) {
+			replay = append(replay, ev)
+		}
+	}
+
+	return ch, replay, nil
+}
+
+// AcquireFromBlock works like Acquire but also returns the events for the
+// topics that happened once the chain reached the specified block height.
+// The backfill value is the highest block whose events may have fallen out
+// of the history. Blocks from the specified height through backfill need to
+// be read from storage. A backfill of 0 means nothing is missing.
+func (evt *Events) AcquireFromBlock(id string, block uint64, topics ...string) (ch <-chan Event, replay []Event, backfill uint64) {
+	evt.mu.Lock()
+	defer evt.mu.Unlock()
+
+	ch = evt.acquire(id, topics)
+	sub := evt.m[id]
+
+	for _, ev := range evt.ordered() {
+		if ev.Block >= block && sub.wants(ev.Topic) {
+			replay = append(replay, ev)
+		}
+	}
+
+	if evt.evicted != nil && evt.evicted.Block >= block {
+		backfill = evt.evicted.Block
+	}
+
+	return ch, replay, backfill
+}
+
+// acquire registers the subscriber for the topics. The caller must hold
+// the write lock.
+func (evt *Events) acquire(id string, topics []string) <-chan Event {
+	sub, exists := evt.m[id]
 	if exists {
-		return ch
+		return sub.ch
 	}
 
-	// Since a message will be dropped if the websocket receiver is
-	// not ready to receive, this arbitrary buffer should give the receiver
-	// enough time to not lose a message. Websocket send could take long.
-	const messageBuffer = 100
+	// Since an event will be dropped if the websocket receiver is not
+	// ready to receive, this arbitrary buffer should give the receiver
+	// enough time to not lose an event. Websocket send could take long.
+	const eventBuffer = 100
+
+	sub = &subscriber{
+		ch:     make(chan Event, eventBuffer),
+		topics: make(map[string]struct{}, len(topics)),
+	}
+	for _, topic := range topics {
+		sub.topics[topic] = struct{}{}
+	}
 
-	evt.m[id] = make(chan string, messageBuffer)
-	return evt.m[id]
+	evt.m[id] = sub
+	return sub.ch
 }
 
 // Release closes and removes the channel that was provided by
@@ -59,26 +205,99 @@ func (evt *Events) Release(id string) error {
 	evt.mu.Lock()
 	defer evt.mu.Unlock()
 
-	ch, exists := evt.m[id]
+	sub, exists := evt.m[id]
 	if !exists {
 		return fmt.Errorf("id %q does not exist", id)
 	}
 
 	delete(evt.m, id)
-	close(ch)
+	close(sub.ch)
 	return nil
 }
 
-// Send signals a message to ever registered channel. Send will not block
-// waiting for a receiver on any given channel.
-func (evt *Events) Send(s string) {
-	evt.mu.RLock()
-	defer evt.mu.RUnlock()
+// Send records an event for the topic at the specified block height and
+// signals it to every channel registered for the topic. Send will not block
+// waiting for a receiver on any given channel. When a receiver is not ready,
+// the event is counted as dropped and that count is reported with the next
+// event the receiver gets.
+func (evt *Events) Send(topic string, block uint64, payload any) {
+	evt.mu.Lock()
+	defer evt.mu.Unlock()
+
+	evt.seq++
+	ev := Event{
+		ID:      token(evt.epoch, evt.seq),
+		Seq:     evt.seq,
+		Block:   block,
+		Topic:   topic,
+		Payload: payload,
+	}
+
+	if _, exists := evt.volatile[topic]; !exists {
+		evt.record(ev)
+	}
+
+	for _, sub := range evt.m {
+		if !sub.wants(topic) {
+			continue
+		}
+
+		ev.Dropped = sub.dropped.Swap(0)
 
-	for _, ch := range evt.m {
 		select {
-		case ch <- s:
+		case sub.ch <- ev:
 		default:
+			sub.dropped.Add(ev.Dropped + 1)
 		}
 	}
 }
+
+// =============================================================================
+
+// record adds the event to the history, replacing the oldest event once the
+// history is full. The caller must hold the write lock.
+func (evt *Events) record(ev Event) {
+	if len(evt.history) < historySize {
+		evt.history = append(evt.history, ev)
+		return
+	}
+
+	evicted := evt.history[evt.next]
+	evt.evicted = &evicted
+
+	evt.history[evt.next] = ev
+	evt.next = (evt.next + 1) % historySize
+}
+
+// ordered returns the events in the history from oldest to newest. The
+// caller must hold a lock.
+func (evt *Events) ordered() []Event {
+	list := make([]Event, 0, len(evt.history))
+	list = append(list, evt.history[evt.next:]...)
+	list = append(list, evt.history[:evt.next]...)
+
+	return list
+}
+
+// =============================================================================
+
+// ParseToken splits the token a subscriber resumes from into the epoch of the
+// process and the sequence number of the event.
+func ParseToken(token string) (epoch string, seq uint64, err error) {
+	epoch, num, found := strings.Cut(token, "-")
+	if !found || epoch == "" {
+		return "", 0, fmt.Errorf("token %q is not properly formatted", token)
+	}
+
+	seq, err = strconv.ParseUint(num, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("token %q is not properly formatted: %w", token, err)
+	}
+
+	return epoch, seq, nil
+}
+
+// token constructs the token for the event with the sequence number.
+func token(epoch string, seq uint64) string {
+	return fmt.Sprintf("%s-%d", epoch, seq)
+}
@@ -0,0 +1,123 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wtran29/go-blockchain/foundation/events"
+)
+
+// More events than the history can hold.
+const overflow = 1_100
+
+func Test_AcquireSince(t *testing.T) {
+	evts := events.New()
+	ch := evts.Acquire("first")
+
+	evts.Send("block", 1, nil)
+	evts.Send("tx", 1, nil)
+	evts.Send("block", 2, nil)
+
+	first := <-ch
+	if first.ID == "" {
+		t.Fatal("Should give the event an id to resume from")
+	}
+
+	_, replay, err := evts.AcquireSince("second", first.ID, "block")
+	if err != nil {
+		t.Fatalf("Should be able to resume from the first event: %s", err)
+	}
+
+	if len(replay) != 1 || replay[0].Block != 2 || replay[0].Topic != "block" {
+		t.Fatalf("Should replay only the later block event, got %v", replay)
+	}
+}
+
+func Test_AcquireFromBlock(t *testing.T) {
+	evts := events.New()
+
+	for block := uint64(1); block <= overflow; block++ {
+		evts.Send("block", block, nil)
+	}
+
+	tt := []struct {
+		name     string
+		block    uint64
+		backfill bool
+	}{
+		{name: "in-history", block: overflow - 10, backfill: false},
+		{name: "evicted", block: 50, backfill: true},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			_, replay, backfill := evts.AcquireFromBlock(tst.name, tst.block)
+			defer evts.Release(tst.name)
+
+			if (backfill > 0) != tst.backfill {
+				t.Fatalf("Should backfill %t, got %d", tst.backfill, backfill)
+			}
+
+			from := tst.block
+			if backfill > 0 {
+				from = backfill + 1
+			}
+
+			if len(replay) == 0 || replay[0].Block != from || replay[len(replay)-1].Block != overflow {
+				t.Fatalf("Should replay the blocks from %d to %d, got %d events", from, overflow, len(replay))
+			}
+		})
+	}
+}
+
+func Test_HistoryExhausted(t *testing.T) {
+	evts := events.New()
+	ch := evts.Acquire("first")
+
+	evts.Send("block", 1, nil)
+	first := <-ch
+
+	// An event from an earlier run of the process with the same sequence
+	// number can't be resumed from.
+	_, _, err := events.New().AcquireSince("second", first.ID)
+	if !errors.Is(err, events.ErrHistoryExhausted) {
+		t.Fatalf("Should not resume from an event of another epoch, got %v", err)
+	}
+
+	if _, _, err := evts.AcquireSince("second", "1"); err == nil || errors.Is(err, events.ErrHistoryExhausted) {
+		t.Fatalf("Should reject an id that is not properly formatted, got %v", err)
+	}
+
+	for i := 0; i < overflow; i++ {
+		evts.Send("block", 1, nil)
+	}
+
+	_, _, err = evts.AcquireSince("second", first.ID)
+	if !errors.Is(err, events.ErrHistoryExhausted) {
+		t.Fatalf("Should not resume from an event out of the history, got %v", err)
+	}
+}
+
+func Test_VolatileTopics(t *testing.T) {
+	evts := events.New("progress")
+	ch := evts.Acquire("first")
+
+	evts.Send("block", 1, nil)
+	first := <-ch
+
+	for i := 0; i < overflow; i++ {
+		evts.Send("progress", 1, nil)
+	}
+
+	if ev := <-ch; ev.Topic != "progress" {
+		t.Fatalf("Should send the volatile topic to the subscribers, got %s", ev.Topic)
+	}
+
+	_, replay, err := evts.AcquireSince("second", first.ID)
+	if err != nil {
+		t.Fatalf("Should not keep the volatile topic in the history: %s", err)
+	}
+	if len(replay) != 0 {
+		t.Fatalf("Should not replay the volatile topic, got %d events", len(replay))
+	}
+}